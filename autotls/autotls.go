@@ -0,0 +1,96 @@
+// Package autotls serves a dispatcher Router over HTTPS with
+// certificates obtained automatically from an ACME certificate
+// authority such as Let's Encrypt.
+package autotls
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+import (
+	"golang.org/x/crypto/acme/autocert"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultChallengeAddr is the address the plaintext listener answering
+// ACME HTTP-01 challenges listens on by default.
+const DefaultChallengeAddr = ":80"
+
+// ErrNoDomains is returned when serving without any allowed domains.
+var ErrNoDomains = errors.New("autotls: at least one allowed domain is required")
+
+// Options configures how certificates are obtained.
+type Options struct {
+	// Domains are the host names certificates may be obtained for.
+	// Requests for other hosts are refused during the TLS handshake.
+	Domains []string
+	// CacheDir is the directory certificates are cached in between
+	// restarts. If empty, certificates are only held in memory.
+	CacheDir string
+	// Email is an optional contact address given to the certificate
+	// authority.
+	Email string
+	// ChallengeAddr is the address of the plaintext listener answering
+	// HTTP-01 challenges and redirecting other requests to HTTPS. If
+	// empty, DefaultChallengeAddr is used.
+	ChallengeAddr string
+}
+
+// Serve behaves as the Router's ServeTLS method, obtaining certificates
+// for the allowed domains automatically. A companion plaintext server
+// answering ACME HTTP-01 challenges, and redirecting all other requests
+// to HTTPS, is started on `opts.ChallengeAddr` and shut down along with
+// the returned Server.
+func Serve(router *dispatcher.Router, addr string, opts Options, serveOpts dispatcher.ServeOptions) (*dispatcher.Server, error) {
+	if 0 == len(opts.Domains) {
+		return nil, ErrNoDomains
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(opts.Domains...),
+		Email:      opts.Email,
+	}
+
+	if "" != opts.CacheDir {
+		manager.Cache = autocert.DirCache(opts.CacheDir)
+	}
+
+	challengeAddr := opts.ChallengeAddr
+
+	if "" == challengeAddr {
+		challengeAddr = DefaultChallengeAddr
+	}
+
+	listener, err := net.Listen("tcp", challengeAddr)
+
+	if nil != err {
+		return nil, err
+	}
+
+	challenges := &http.Server{
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: serveOpts.ReadHeaderTimeout,
+	}
+
+	go challenges.Serve(listener)
+
+	server, err := router.ServeTLSConfig(addr, manager.TLSConfig(), serveOpts)
+
+	if nil != err {
+		challenges.Close()
+		return nil, err
+	}
+
+	server.OnShutdown(func(ctx context.Context) error {
+		return challenges.Shutdown(ctx)
+	})
+
+	return server, nil
+}
@@ -0,0 +1,508 @@
+package dispatcher
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxBodySize is the number of bytes Bind reads from a
+	// request body when BindOptions.MaxBodySize is unset.
+	DefaultMaxBodySize = 1 << 20
+	// DefaultMultipartMemory is the number of bytes of a multipart
+	// body's file parts held in memory before they are written to
+	// temporary files.
+	DefaultMultipartMemory = 32 << 20
+)
+
+// BindOptions configures Bind.
+type BindOptions struct {
+	// MaxBodySize limits the number of bytes read from the request
+	// body. If zero, DefaultMaxBodySize is used; if negative, the body
+	// is not limited.
+	MaxBodySize int64
+	// DisallowUnknownFields causes JSON and form bodies containing
+	// fields the destination struct has no field for to be rejected.
+	DisallowUnknownFields bool
+}
+
+// File is a file part of a multipart form bound by Bind. Its contents
+// are read with Open.
+type File struct {
+	*multipart.FileHeader
+}
+
+// fileType is the reflected type of File.
+var fileType = reflect.TypeOf(File{})
+
+// BindError is returned when a request can not be bound to a struct,
+// carrying the HTTP status code the failure should be answered with
+// and, where known, the field at fault.
+type BindError struct {
+	Status int
+	Field  string
+	Err    error
+}
+
+// Error describes the failure, prefixed with the field at fault.
+func (e *BindError) Error() string {
+	if "" == e.Field {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%v: %v", e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code the failure should be
+// answered with.
+func (e *BindError) StatusCode() int {
+	return e.Status
+}
+
+// Bind decodes the request body into the struct pointed to by `dst`,
+// choosing a decoder by the request's Content-Type. JSON bodies
+// (`application/json` and `+json` types, or bodies without a
+// Content-Type) are decoded with encoding/json and XML bodies
+// (`application/xml`, `text/xml` and `+xml` types) with encoding/xml.
+// Urlencoded and multipart forms are decoded into fields using their
+// `form` struct tags, with multipart file parts bound to fields of
+// type File, *File or slices of either. Codecs registered with
+// RegisterCodec take precedence for the values they accept, i.e.
+//
+//	var user struct {
+//		Name string `json:"name" form:"name"`
+//	}
+//
+//	if err := dispatcher.Bind(req, &user); nil != err {
+//		dispatcher.WriteError(res, req, err)
+//		return
+//	}
+//
+// Failures to decode the body are reported as a *BindError. Once
+// decoded, the value is validated by its Validate method, if it
+// implements Validator, and by the ValidatorFuncs registered with
+// RegisterValidator; failures are reported as a *ValidationError.
+// Either can be written to the response with WriteError.
+func Bind(req *http.Request, dst any, opts ...BindOptions) error {
+	var options BindOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	if 0 == options.MaxBodySize {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+
+	body := req.Body
+
+	if nil == body {
+		body = http.NoBody
+	}
+
+	if 0 < options.MaxBodySize {
+		body = http.MaxBytesReader(nil, body, options.MaxBodySize)
+	}
+
+	var (
+		typ    = "application/json"
+		params map[string]string
+		err    error
+	)
+
+	if header := req.Header.Get("Content-Type"); "" != header {
+		if typ, params, err = mime.ParseMediaType(header); nil != err {
+			return &BindError{Status: http.StatusUnsupportedMediaType, Err: err}
+		}
+	}
+
+	switch codec := lookupCodec(typ, dst); {
+	case nil != codec:
+		err = decodeError(codec.Decode(body, dst))
+	case "application/json" == typ, strings.HasSuffix(typ, "+json"):
+		err = bindJSON(body, dst, options)
+	case "application/xml" == typ, "text/xml" == typ, strings.HasSuffix(typ, "+xml"):
+		err = bindXML(body, dst)
+	case "application/x-www-form-urlencoded" == typ:
+		err = bindForm(body, dst, options)
+	case "multipart/form-data" == typ:
+		err = bindMultipart(req, body, params["boundary"], dst, options)
+	default:
+		err = &BindError{Status: http.StatusUnsupportedMediaType, Err: fmt.Errorf("unsupported content type %q", typ)}
+	}
+
+	if nil != err {
+		return err
+	}
+
+	return validate(dst)
+}
+
+// BindQuery decodes the request's query string into the struct pointed
+// to by `dst`, using the fields' `query` struct tags.
+func BindQuery(req *http.Request, dst any) error {
+	return bindValues(req.URL.Query(), dst, "query", false)
+}
+
+// BindParams decodes the path parameters of the Route matching the
+// request into the struct pointed to by `dst`, using the fields'
+// `param` struct tags.
+func BindParams(req *http.Request, dst any) error {
+	values := make(url.Values)
+
+	for _, param := range ParamsFromContext(req.Context()) {
+		values.Add(param.Key, param.Value)
+	}
+
+	return bindValues(values, dst, "param", false)
+}
+
+// bindJSON decodes a single JSON value from `body` into `dst`.
+func bindJSON(body io.Reader, dst any, options BindOptions) error {
+	decoder := json.NewDecoder(body)
+
+	if options.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(dst); nil != err {
+		return decodeError(err)
+	}
+
+	if decoder.More() {
+		return &BindError{Status: http.StatusBadRequest, Err: errors.New("body must contain a single JSON value")}
+	}
+
+	return nil
+}
+
+// bindXML decodes an XML document from `body` into `dst`.
+func bindXML(body io.Reader, dst any) error {
+	var syntaxError *xml.SyntaxError
+
+	switch err := xml.NewDecoder(body).Decode(dst); {
+	case nil == err:
+		return nil
+	case errors.As(err, &syntaxError):
+		return &BindError{Status: http.StatusBadRequest, Err: fmt.Errorf("body contains malformed XML on line %d", syntaxError.Line)}
+	default:
+		return decodeError(err)
+	}
+}
+
+// decodeError describes an error encountered reading or decoding a
+// request body.
+func decodeError(err error) error {
+	var (
+		syntaxError *json.SyntaxError
+		typeError   *json.UnmarshalTypeError
+		sizeError   *http.MaxBytesError
+	)
+
+	switch {
+	case nil == err:
+		return nil
+	case errors.As(err, &sizeError):
+		return &BindError{Status: http.StatusRequestEntityTooLarge, Err: fmt.Errorf("body exceeds %d bytes", sizeError.Limit)}
+	case io.EOF == err:
+		return &BindError{Status: http.StatusBadRequest, Err: errors.New("body is empty")}
+	case multipart.ErrMessageTooLarge == err:
+		return &BindError{Status: http.StatusRequestEntityTooLarge, Err: errors.New("body exceeds the multipart memory limit")}
+	case io.ErrUnexpectedEOF == err:
+		return &BindError{Status: http.StatusBadRequest, Err: errors.New("body is truncated")}
+	case errors.As(err, &syntaxError):
+		return &BindError{Status: http.StatusBadRequest, Err: fmt.Errorf("body contains malformed JSON at offset %d", syntaxError.Offset)}
+	case errors.As(err, &typeError):
+		return &BindError{Status: http.StatusBadRequest, Field: typeError.Field, Err: fmt.Errorf("expected %v, got JSON %v", typeError.Type, typeError.Value)}
+	}
+
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return &BindError{Status: http.StatusBadRequest, Field: strings.Trim(field, `"`), Err: errors.New("unknown field")}
+	}
+
+	return &BindError{Status: http.StatusBadRequest, Err: err}
+}
+
+// bindForm decodes a urlencoded form from `body` into `dst`.
+func bindForm(body io.Reader, dst any, options BindOptions) error {
+	data, err := io.ReadAll(body)
+
+	if nil != err {
+		return decodeError(err)
+	}
+
+	values, err := url.ParseQuery(string(data))
+
+	if nil != err {
+		return &BindError{Status: http.StatusBadRequest, Err: fmt.Errorf("body contains a malformed form: %w", err)}
+	}
+
+	return bindValues(values, dst, "form", options.DisallowUnknownFields)
+}
+
+// bindMultipart decodes a multipart form from `body` into `dst`. The
+// form is stored on the request so its temporary files are removed
+// once the request has been served.
+func bindMultipart(req *http.Request, body io.Reader, boundary string, dst any, options BindOptions) error {
+	if "" == boundary {
+		return &BindError{Status: http.StatusBadRequest, Err: errors.New("multipart body has no boundary")}
+	}
+
+	form, err := multipart.NewReader(body, boundary).ReadForm(DefaultMultipartMemory)
+
+	if nil != err {
+		return decodeError(err)
+	}
+
+	req.MultipartForm = form
+	fields, err := structFields(dst, "form")
+
+	if nil != err {
+		return err
+	}
+
+	for _, key := range sortedKeys(form.File) {
+		field, ok := fields[key]
+
+		switch {
+		case ok:
+			if err := setFiles(field, form.File[key]); nil != err {
+				return &BindError{Status: http.StatusBadRequest, Field: key, Err: err}
+			}
+		case options.DisallowUnknownFields:
+			return &BindError{Status: http.StatusBadRequest, Field: key, Err: errors.New("unknown field")}
+		}
+	}
+
+	return setValues(fields, form.Value, options.DisallowUnknownFields)
+}
+
+// bindValues sets the fields of the struct pointed to by `dst` from
+// `values`, naming fields by their `tag` struct tags. Keys without a
+// field are ignored unless `disallowUnknown` is set.
+func bindValues(values url.Values, dst any, tag string, disallowUnknown bool) error {
+	fields, err := structFields(dst, tag)
+
+	if nil != err {
+		return err
+	}
+
+	return setValues(fields, values, disallowUnknown)
+}
+
+// structFields returns the settable fields of the struct pointed to by
+// `dst`, keyed by their `tag` struct tags.
+func structFields(dst any, tag string) (map[string]reflect.Value, error) {
+	target := reflect.ValueOf(dst)
+
+	if reflect.Pointer != target.Kind() || target.IsNil() || reflect.Struct != target.Elem().Kind() {
+		return nil, fmt.Errorf("dispatcher: bind destination must be a pointer to a struct, got %T", dst)
+	}
+
+	fields := make(map[string]reflect.Value)
+	collectFields(target.Elem(), tag, fields)
+	return fields, nil
+}
+
+// setValues sets `fields` from `values`.
+func setValues(fields map[string]reflect.Value, values map[string][]string, disallowUnknown bool) error {
+	for _, key := range sortedKeys(values) {
+		field, ok := fields[key]
+
+		if !ok {
+			if disallowUnknown {
+				return &BindError{Status: http.StatusBadRequest, Field: key, Err: errors.New("unknown field")}
+			}
+
+			continue
+		}
+
+		if err := setField(field, values[key]); nil != err {
+			return &BindError{Status: http.StatusBadRequest, Field: key, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of `m` in order, so binding fails on the
+// same field each time.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// collectFields adds the settable fields of the struct `value` to
+// `fields`, keyed by the name given by their `tag` struct tag or their
+// Go name. Untagged embedded structs are flattened.
+func collectFields(value reflect.Value, tag string, fields map[string]reflect.Value) {
+	typ := value.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+
+		if "-" == name {
+			continue
+		}
+
+		if field.Anonymous && "" == name && reflect.Struct == field.Type.Kind() {
+			collectFields(value.Field(i), tag, fields)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if "" == name {
+			name = field.Name
+		}
+
+		fields[name] = value.Field(i)
+	}
+}
+
+// durationType is the reflected type of time.Duration, which is parsed
+// from strings like `1m30s` rather than as an integer.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField sets `field` from the string values `raw`. Slices receive
+// every value; other types receive the first.
+func setField(field reflect.Value, raw []string) error {
+	if 0 == len(raw) {
+		return nil
+	}
+
+	if reflect.Pointer == field.Kind() {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		return setField(field.Elem(), raw)
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw[0]))
+	}
+
+	if reflect.Slice == field.Kind() && reflect.Uint8 != field.Type().Elem().Kind() {
+		slice := reflect.MakeSlice(field.Type(), len(raw), len(raw))
+
+		for i, value := range raw {
+			if err := setField(slice.Index(i), []string{value}); nil != err {
+				return err
+			}
+		}
+
+		field.Set(slice)
+		return nil
+	}
+
+	return setScalar(field, raw[0])
+}
+
+// setFiles sets `field` from the file parts `headers`.
+func setFiles(field reflect.Value, headers []*multipart.FileHeader) error {
+	switch typ := field.Type(); {
+	case fileType == typ:
+		field.Set(reflect.ValueOf(File{headers[0]}))
+	case reflect.Pointer == typ.Kind() && fileType == typ.Elem():
+		field.Set(reflect.ValueOf(&File{headers[0]}))
+	case reflect.Slice == typ.Kind():
+		slice := reflect.MakeSlice(typ, len(headers), len(headers))
+
+		for i, header := range headers {
+			if err := setFiles(slice.Index(i), []*multipart.FileHeader{header}); nil != err {
+				return err
+			}
+		}
+
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %v for a file", typ)
+	}
+
+	return nil
+}
+
+// setScalar parses `raw` into `field` according to its kind.
+func setScalar(field reflect.Value, raw string) error {
+	if durationType == field.Type() {
+		duration, err := time.ParseDuration(raw)
+
+		if nil != err {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Slice:
+		field.SetBytes([]byte(raw))
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+
+		if nil != err {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+
+		if nil != err {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+
+		if nil != err {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+
+		field.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(raw, field.Type().Bits())
+
+		if nil != err {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+
+		field.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package dispatcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	Name  string   `json:"name" form:"name"`
+	Age   int      `json:"age" form:"age"`
+	Admin *bool    `json:"admin" form:"admin"`
+	Tags  []string `json:"tags" form:"tag"`
+}
+
+// generateBindRequest creates a POST request with body `body` of type
+// `typ`.
+func generateBindRequest(typ, body string) *http.Request {
+	req := httptest.NewRequest(POST, "/", strings.NewReader(body))
+
+	if "" != typ {
+		req.Header.Set("Content-Type", typ)
+	}
+
+	return req
+}
+
+// TestBind ensures JSON and form bodies are decoded by Content-Type.
+func TestBind(t *testing.T) {
+	requests := []*http.Request{
+		generateBindRequest("", `{"name":"Ada","age":36,"admin":true,"tags":["a","b"]}`),
+		generateBindRequest("application/json; charset=utf-8", `{"name":"Ada","age":36,"admin":true,"tags":["a","b"]}`),
+		generateBindRequest("application/x-www-form-urlencoded", "name=Ada&age=36&admin=true&tag=a&tag=b"),
+	}
+
+	for _, req := range requests {
+		var user bindUser
+
+		if err := Bind(req, &user); nil != err {
+			t.Errorf("Expected %q body to bind, got %v.", req.Header.Get("Content-Type"), err)
+			continue
+		}
+
+		if "Ada" != user.Name || 36 != user.Age || nil == user.Admin || !*user.Admin || "a,b" != strings.Join(user.Tags, ",") {
+			t.Errorf("Expected %q body to bind all fields, got %+v.", req.Header.Get("Content-Type"), user)
+		}
+	}
+}
+
+// TestBindErrors ensures binding failures are reported with status
+// codes and fields.
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		req     *http.Request
+		options BindOptions
+		status  int
+		field   string
+	}{
+		{generateBindRequest("", ""), BindOptions{}, http.StatusBadRequest, ""},
+		{generateBindRequest("", `{"name":`), BindOptions{}, http.StatusBadRequest, ""},
+		{generateBindRequest("", `{"age":"old"}`), BindOptions{}, http.StatusBadRequest, "age"},
+		{generateBindRequest("", `{"name":"Ada"} {}`), BindOptions{}, http.StatusBadRequest, ""},
+		{generateBindRequest("", `{"email":"ada@example.com"}`), BindOptions{DisallowUnknownFields: true}, http.StatusBadRequest, "email"},
+		{generateBindRequest("", `{"name":"Ada Lovelace"}`), BindOptions{MaxBodySize: 8}, http.StatusRequestEntityTooLarge, ""},
+		{generateBindRequest("application/x-www-form-urlencoded", "age=old"), BindOptions{}, http.StatusBadRequest, "age"},
+		{generateBindRequest("application/x-www-form-urlencoded", "email=x"), BindOptions{DisallowUnknownFields: true}, http.StatusBadRequest, "email"},
+		{generateBindRequest("text/csv", "name\nAda"), BindOptions{}, http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, test := range tests {
+		var bindError *BindError
+
+		if err := Bind(test.req, &bindUser{}, test.options); !errors.As(err, &bindError) {
+			t.Errorf("Expected a BindError, got %v.", err)
+		} else if test.status != bindError.StatusCode() || test.field != bindError.Field {
+			t.Errorf("Expected status %d for field %q, got %d for %q (%v).", test.status, test.field, bindError.StatusCode(), bindError.Field, err)
+		}
+	}
+}
+
+// TestBindQueryAndParams ensures query strings and path parameters are
+// decoded into structs.
+func TestBindQueryAndParams(t *testing.T) {
+	var (
+		query struct {
+			Page    int           `query:"page"`
+			Timeout time.Duration `query:"timeout"`
+			Since   time.Time     `query:"since"`
+		}
+		params struct {
+			ID   uint64 `param:"id"`
+			Slug string `param:"slug"`
+		}
+		queryErr, paramsErr error
+	)
+
+	router := NewRouter().Get("/posts/:id/:slug", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		queryErr = BindQuery(req, &query)
+		paramsErr = BindParams(req, &params)
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/posts/42/hello?page=2&timeout=1m&since=2013-01-02T00:00:00Z"))
+
+	if nil != queryErr || 2 != query.Page || time.Minute != query.Timeout || 2013 != query.Since.Year() {
+		t.Errorf("Expected query to bind, got %+v (%v).", query, queryErr)
+	}
+
+	if nil != paramsErr || 42 != params.ID || "hello" != params.Slug {
+		t.Errorf("Expected params to bind, got %+v (%v).", params, paramsErr)
+	}
+}
+
+// TestBindXML ensures XML bodies are decoded.
+func TestBindXML(t *testing.T) {
+	var user struct {
+		Name string `xml:"name"`
+		Age  int    `xml:"age"`
+	}
+
+	req := generateBindRequest("application/xml", "<user><name>Ada</name><age>36</age></user>")
+
+	if err := Bind(req, &user); nil != err || "Ada" != user.Name || 36 != user.Age {
+		t.Errorf("Expected XML body to bind, got %+v (%v).", user, err)
+	}
+
+	var bindError *BindError
+
+	if err := Bind(generateBindRequest("text/xml", "<user><name>"), &user); !errors.As(err, &bindError) || http.StatusBadRequest != bindError.StatusCode() {
+		t.Errorf("Expected malformed XML to fail with a 400, got %v.", err)
+	}
+}
+
+// TestBindMultipart ensures multipart values and file parts are
+// decoded.
+func TestBindMultipart(t *testing.T) {
+	var (
+		body   bytes.Buffer
+		writer = multipart.NewWriter(&body)
+	)
+
+	writer.WriteField("name", "Ada")
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		part, _ := writer.CreateFormFile("attachments", name)
+		part.Write([]byte("contents of " + name))
+	}
+
+	part, _ := writer.CreateFormFile("avatar", "ada.png")
+	part.Write([]byte("png"))
+	writer.Close()
+
+	var upload struct {
+		Name        string `form:"name"`
+		Avatar      *File  `form:"avatar"`
+		Attachments []File `form:"attachments"`
+	}
+
+	req := generateBindRequest(writer.FormDataContentType(), body.String())
+
+	if err := Bind(req, &upload); nil != err {
+		t.Fatal(err)
+	}
+
+	if "Ada" != upload.Name || nil == upload.Avatar || "ada.png" != upload.Avatar.Filename || 2 != len(upload.Attachments) {
+		t.Fatalf("Expected multipart body to bind all fields, got %+v.", upload)
+	}
+
+	file, err := upload.Attachments[1].Open()
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if contents, _ := io.ReadAll(file); "contents of b.txt" != string(contents) {
+		t.Errorf("Expected file contents to be readable, got %q.", contents)
+	}
+
+	if nil == req.MultipartForm {
+		t.Error("Expected the multipart form to be stored on the request for cleanup.")
+	}
+}
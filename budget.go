@@ -0,0 +1,51 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RemainingBudget returns the time left before the deadline of `ctx`,
+// i.e. that of a request served with ServeOptions.HandlerTimeout or
+// WriteTimeout, and whether it has one, so downstream calls can be
+// given the time the request has left:
+//
+//	if budget, ok := dispatcher.RemainingBudget(req.Context()); ok {
+//		client.Timeout = budget
+//	}
+//
+// The budget is zero once the deadline has passed.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		return 0, false
+	}
+
+	return max(0, time.Until(deadline)), true
+}
+
+// requestBudget returns how long requests are served for under `opts`,
+// or zero if they are not limited. The ReadTimeout only limits reading
+// requests, so it does not shorten the budget.
+func requestBudget(opts ServeOptions) time.Duration {
+	budget := opts.HandlerTimeout
+
+	if 0 < opts.WriteTimeout && (0 >= budget || opts.WriteTimeout < budget) {
+		budget = opts.WriteTimeout
+	}
+
+	return max(0, budget)
+}
+
+// limitBudget is a middleware handler giving each request a context
+// deadline `budget` after it started being served.
+func limitBudget(next http.Handler, budget time.Duration) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), budget)
+		defer cancel()
+
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
@@ -0,0 +1,65 @@
+package dispatcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestRemainingBudget ensures requests served with a HandlerTimeout are
+// given a deadline within it.
+func TestRemainingBudget(t *testing.T) {
+	router := NewRouter().Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		budget, ok := RemainingBudget(req.Context())
+
+		if !ok || budget > time.Second || budget < 500*time.Millisecond {
+			t.Errorf("Expected a budget within the write timeout, got %v %v.", budget, ok)
+		}
+
+		io.WriteString(res, "ok")
+	}))
+
+	server, err := router.Serve("127.0.0.1:0", ServeOptions{HandlerTimeout: time.Minute, WriteTimeout: time.Second})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer server.Shutdown(context.Background())
+
+	res, err := http.Get("http://" + server.Addr().String() + "/")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if _, ok := RemainingBudget(context.Background()); ok {
+		t.Error("Expected no budget without a deadline.")
+	}
+}
+
+// TestRequestBudget ensures the earliest of the handler and write
+// timeouts is used.
+func TestRequestBudget(t *testing.T) {
+	tests := []struct {
+		opts     ServeOptions
+		expected time.Duration
+	}{
+		{ServeOptions{}, 0},
+		{ServeOptions{ReadTimeout: time.Second}, 0},
+		{ServeOptions{HandlerTimeout: time.Second}, time.Second},
+		{ServeOptions{WriteTimeout: time.Second}, time.Second},
+		{ServeOptions{HandlerTimeout: 2 * time.Second, WriteTimeout: time.Second}, time.Second},
+		{ServeOptions{HandlerTimeout: time.Second, WriteTimeout: 2 * time.Second}, time.Second},
+	}
+
+	for _, test := range tests {
+		if budget := requestBudget(test.opts); test.expected != budget {
+			t.Errorf("Expected a budget of %v for %+v, got %v.", test.expected, test.opts, budget)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package dispatcher
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Clone returns a deep copy of the Router: its Routes, Plugins,
+// Middleware, HandlerWrappers, hooks and settings. Routes, names and
+// registrations added to either Router afterwards do not affect the
+// other, so a Router can be used as a template, i.e. for per-tenant
+// Routers. Handlers and Middleware values themselves are shared.
+func (r *Router) Clone() *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	clone := &Router{
+		Mutex:                   &sync.Mutex{},
+		dispatcher:              NewDispatcher(),
+		plugins:                 append([]prioritizedPlugin(nil), r.plugins...),
+		middleware:              append([]namedMiddleware(nil), r.middleware...),
+		routeMiddleware:         append([]RouteMiddleware(nil), r.routeMiddleware...),
+		wrappers:                append([]prioritizedWrapper(nil), r.wrappers...),
+		notFoundHandler:         r.notFoundHandler,
+		methodNotAllowedHandler: r.methodNotAllowedHandler,
+		problemJSON:             r.problemJSON,
+		problemHTML:             r.problemHTML,
+		fallback:                r.fallback,
+		errorMappings:           r.errorMappings,
+		trapCallback:            r.trapCallback,
+		renderer:                r.renderer,
+		strict:                  r.strict,
+		tracing:                 r.tracing,
+		locales:                 r.locales,
+		versions:                append([]string(nil), r.versions...),
+		latest:                  r.latest,
+		headers:                 r.headers,
+		scheduled:               r.scheduled,
+		traceAllowed:            r.traceAllowed,
+		inFlight:                newRequestCounter(),
+		hooks: routerHooks{
+			request:      append([]RequestHook(nil), r.hooks.request...),
+			routeMatched: append([]RouteMatchedHook(nil), r.hooks.routeMatched...),
+			notFound:     append([]NotFoundHook(nil), r.hooks.notFound...),
+			panic:        append([]PanicHook(nil), r.hooks.panic...),
+			response:     append([]ResponseHook(nil), r.hooks.response...),
+		},
+	}
+
+	if nil != r.stats {
+		// The clone collects its own statistics.
+		clone.stats = &statsCollector{options: r.stats.options, routes: make(map[*Route]*routeStats)}
+	}
+
+	if nil != r.misses {
+		clone.misses = newMissCache(r.misses.size)
+	}
+
+	if nil != r.fallbacks {
+		clone.fallbacks = make(map[string]string, len(r.fallbacks))
+
+		for prefix, fallback := range r.fallbacks {
+			clone.fallbacks[prefix] = fallback
+		}
+	}
+
+	for method, routes := range r.dispatcher {
+		for _, registered := range routes {
+			copied := *registered.Route
+			copied.deprecation = registered.Route.deprecation.clone()
+			clone.dispatcher[method] = append(clone.dispatcher[method], RouteHandler{&copied, registered.Handler})
+			clone.rename(&copied)
+		}
+
+		clone.reindex(method)
+	}
+
+	if 0 < len(clone.wrappers) {
+		// The wrapped handler must dispatch to the clone's Routes.
+		clone.handler = http.HandlerFunc(clone.dispatch)
+
+		for i := len(clone.wrappers) - 1; 0 <= i; i-- {
+			clone.handler = clone.wrappers[i].wrapper(clone.handler)
+		}
+	}
+
+	return clone
+}
+
+// Merge adds copies of the Routes of `other` to the Router, with
+// `prefix` prepended to their paths, so route sets defined separately
+// (i.e. in other packages) can be combined:
+//
+//	router.Merge(users.Routes(), "/users")
+//
+// Route names, documentation, tags, strictness and deprecation are
+// kept; names replace any already registered. Only Routes are merged:
+// the Middleware, Plugins and other settings of `other` are not
+// carried over.
+func (r *Router) Merge(other *Router, prefix string) *Router {
+	other.Lock()
+
+	var merged []RouteHandler
+
+	for _, method := range supportedMethods {
+		for _, registered := range other.dispatcher[method] {
+			route := registered.Route
+			copied := NewRoute(strings.TrimSuffix(prefix, "/")+route.path, route.strict)
+			copied.method, copied.name, copied.doc = route.method, route.name, route.doc
+			copied.meta, copied.locales, copied.localized = route.meta, route.locales, route.localized
+			copied.deprecation, copied.active, copied.headers = route.deprecation.clone(), route.active, route.headers
+			merged = append(merged, RouteHandler{copied, registered.Handler})
+		}
+	}
+
+	other.Unlock()
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.current = nil
+
+	for _, registered := range merged {
+		if routes, ok := r.dispatcher[registered.Route.method]; ok && r.registers(registered.Route.method) {
+			r.dispatcher[registered.Route.method] = append(routes, registered)
+			r.indexRoute(registered.Route)
+			r.rename(registered.Route)
+			r.current = append(r.current, registered.Route)
+			r.scheduled = r.scheduled || nil != registered.Route.active
+		}
+	}
+
+	return r
+}
+
+// rename records the name of `route`, if it has one. The Router must
+// be locked by the caller.
+func (r *Router) rename(route *Route) {
+	if "" == route.name || route.localized {
+		return
+	}
+
+	if nil == r.names {
+		r.names = make(map[string]*Route)
+	}
+
+	r.names[route.name] = route
+}
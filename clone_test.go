@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClone ensures clones serve the same Routes and Middleware while
+// later changes to either Router stay separate.
+func TestClone(t *testing.T) {
+	var wrapped int
+
+	template := NewRouter().
+		RegisterWrapper(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				wrapped++
+				next.ServeHTTP(res, req)
+			})
+		}).
+		RegisterNamedMiddleware("tenant", MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+			return false
+		})).
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusAccepted)
+		})).Name("user")
+
+	clone := template.Clone()
+	clone.Get("/clone-only", http.NotFoundHandler()).Name("user").Doc("Replaced name")
+	clone.RemoveMiddleware("tenant")
+
+	res := httptest.NewRecorder()
+	clone.ServeHTTP(res, generateHttpRequest(GET, "/users/1"))
+
+	if http.StatusAccepted != res.Code || 1 != wrapped {
+		t.Errorf("Expected the clone to serve the template's routes through its wrappers, got %d and %d.", res.Code, wrapped)
+	}
+
+	if path, _ := template.URL("user", "1"); "/users/1" != path {
+		t.Errorf("Expected the template's names to be unaffected, got %q.", path)
+	}
+
+	if 1 != len(template.Routes()) || 1 != len(template.MiddlewareNames()) {
+		t.Error("Expected the template's routes and middleware to be unaffected.")
+	}
+}
+
+// TestMerge ensures Routes of another Router are added under a prefix.
+func TestMerge(t *testing.T) {
+	users := NewRouter().
+		Get("/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(ParamsFromContext(req.Context()).Get("id")))
+		})).Name("users.show").Doc("Shows a user")
+
+	router := NewRouter().Merge(users, "/users/")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/users/7"))
+
+	if "7" != res.Body.String() {
+		t.Errorf("Expected merged route to serve /users/7, got %q.", res.Body.String())
+	}
+
+	if path, err := router.URL("users.show", "7"); nil != err || "/users/7" != path {
+		t.Errorf("Expected merged route names to be kept, got %q (%v).", path, err)
+	}
+
+	if routes := router.Routes(); 1 != len(routes) || "Shows a user" != routes[0].Doc {
+		t.Errorf("Expected merged route docs to be kept, got %v.", routes)
+	}
+}
@@ -0,0 +1,157 @@
+package dispatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Codec is implemented by types encoding and decoding values of a
+// media type, extending Bind and Respond beyond their built-in JSON
+// and XML support.
+type Codec interface {
+	// Accepts reports whether the Codec can encode and decode `v`,
+	// allowing Codecs to claim a media type only for some values
+	// (i.e. protocol buffer messages).
+	Accepts(v any) bool
+	Decode(r io.Reader, v any) error
+	Encode(w io.Writer, v any) error
+}
+
+// registeredCodec pairs a Codec with the media type it was registered
+// for.
+type registeredCodec struct {
+	mediaType string
+	codec     Codec
+}
+
+// codecs is the registry of Codecs consulted by Bind and Respond.
+var codecs struct {
+	sync.RWMutex
+	registered []registeredCodec
+}
+
+// RegisterCodec registers `codec` for the media type `mediaType`.
+// Codecs registered later take precedence over earlier ones and the
+// built-in JSON and XML handling for values they accept.
+func RegisterCodec(mediaType string, codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	codecs.registered = append(codecs.registered, registeredCodec{mediaType, codec})
+}
+
+// lookupCodec returns the most recently registered Codec for
+// `mediaType` accepting `v`, or nil if there is none.
+func lookupCodec(mediaType string, v any) Codec {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	for i := len(codecs.registered) - 1; 0 <= i; i-- {
+		if registered := codecs.registered[i]; mediaType == registered.mediaType && registered.codec.Accepts(v) {
+			return registered.codec
+		}
+	}
+
+	return nil
+}
+
+// codecMediaTypes returns the media types `v` can be encoded as, JSON
+// and XML first, followed by those of registered Codecs. XML is only
+// offered for values encoding/xml may encode, i.e. not for maps.
+func codecMediaTypes(v any) []string {
+	types := []string{"application/json"}
+
+	if xmlEncodable(v) {
+		types = append(types, "application/xml")
+	}
+
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	for _, registered := range codecs.registered {
+		if !registered.codec.Accepts(v) {
+			continue
+		}
+
+		found := false
+
+		for _, typ := range types {
+			found = found || typ == registered.mediaType
+		}
+
+		if !found {
+			types = append(types, registered.mediaType)
+		}
+	}
+
+	return types
+}
+
+// Respond writes `v` with status `status`, encoded as the media type
+// best matching the request's Accept header among JSON, XML and the
+// types of registered Codecs accepting `v`. JSON is used when nothing
+// acceptable is offered, or the value fails to encode as XML, i.e. for
+// nested maps. The value is encoded in full before anything is
+// written, so a failed encoding does not leave a partial response.
+func Respond(res http.ResponseWriter, req *http.Request, status int, v any) error {
+	offers := codecMediaTypes(v)
+	typ := Negotiate(req, offers...)
+
+	if "" == typ {
+		typ = offers[0]
+	}
+
+	var buffer bytes.Buffer
+
+	err := encode(&buffer, typ, v)
+
+	if nil != err && "application/xml" == typ && nil == lookupCodec(typ, v) {
+		buffer.Reset()
+		typ, err = "application/json", encode(&buffer, "application/json", v)
+	}
+
+	if nil != err {
+		return err
+	}
+
+	res.Header().Set("Content-Type", typ)
+	res.WriteHeader(status)
+	_, err = buffer.WriteTo(res)
+	return err
+}
+
+// encode writes `v` to `w` as the media type `typ`.
+func encode(w io.Writer, typ string, v any) error {
+	if codec := lookupCodec(typ, v); nil != codec {
+		return codec.Encode(w, v)
+	}
+
+	if "application/xml" == typ {
+		return xml.NewEncoder(w).Encode(v)
+	}
+
+	return json.NewEncoder(w).Encode(v)
+}
+
+// xmlEncodable reports whether `v` is of a kind encoding/xml may
+// encode. Values it accepts can still fail to encode, i.e. structs with
+// map fields.
+func xmlEncodable(v any) bool {
+	value := reflect.ValueOf(v)
+
+	for reflect.Pointer == value.Kind() || reflect.Interface == value.Kind() {
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Map, reflect.Func, reflect.Chan, reflect.Invalid:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package dispatcher
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// point is a value encoded as `x,y` by pointCodec.
+type point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// pointCodec is a Codec accepting only points.
+type pointCodec struct{}
+
+func (pointCodec) Accepts(v any) bool {
+	_, ok := v.(*point)
+	return ok
+}
+
+func (pointCodec) Decode(r io.Reader, v any) error {
+	p := v.(*point)
+	_, err := fmt.Fscanf(r, "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func (pointCodec) Encode(w io.Writer, v any) error {
+	p := v.(*point)
+	_, err := fmt.Fprintf(w, "%d,%d", p.X, p.Y)
+	return err
+}
+
+// TestCodecs ensures registered Codecs are used to bind and respond
+// with the values they accept.
+func TestCodecs(t *testing.T) {
+	RegisterCodec("text/x-point", pointCodec{})
+
+	var p point
+
+	if err := Bind(generateBindRequest("text/x-point", "3,4"), &p); nil != err || 3 != p.X || 4 != p.Y {
+		t.Errorf("Expected codec to bind point, got %+v (%v).", p, err)
+	}
+
+	if err := Bind(generateBindRequest("text/x-point", "3,4"), &struct{}{}); nil == err {
+		t.Error("Expected codec to be skipped for values it does not accept.")
+	}
+
+	tests := map[string]string{
+		"text/x-point":     "3,4",
+		"application/xml":  "<point><X>3</X><Y>4</Y></point>",
+		"application/json": "{\"x\":3,\"y\":4}\n",
+		"image/png":        "{\"x\":3,\"y\":4}\n",
+	}
+
+	for accept, expected := range tests {
+		req := generateHttpRequest(GET, "/")
+		req.Header.Set("Accept", accept)
+		res := httptest.NewRecorder()
+
+		if err := Respond(res, req, http.StatusCreated, &p); nil != err {
+			t.Errorf("Expected %q response, got %v.", accept, err)
+		} else if http.StatusCreated != res.Code || expected != res.Body.String() {
+			t.Errorf("Expected %d %q for %q, got %d %q.", http.StatusCreated, expected, accept, res.Code, res.Body.String())
+		}
+	}
+}
+
+// TestRespondXMLUnencodable ensures values encoding/xml can't encode
+// are answered with JSON when XML is requested.
+func TestRespondXMLUnencodable(t *testing.T) {
+	for name, v := range map[string]any{
+		"map":        map[string]any{"a": 1},
+		"nested map": &struct{ Tags map[string]int }{map[string]int{"a": 1}},
+	} {
+		req := generateHttpRequest(GET, "/")
+		req.Header.Set("Accept", "application/xml")
+		res := httptest.NewRecorder()
+
+		if err := Respond(res, req, http.StatusOK, v); nil != err {
+			t.Errorf("Expected %s to be answered, got %v.", name, err)
+		} else if "application/json" != res.Header().Get("Content-Type") || 0 == res.Body.Len() {
+			t.Errorf("Expected %s to be answered with JSON, got %q %q.", name, res.Header().Get("Content-Type"), res.Body.String())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Package dav mounts golang.org/x/net/webdav handlers on a dispatcher
+// Router, serving a file system over WebDAV beneath a path prefix.
+package dav
+
+import (
+	"strings"
+)
+
+import (
+	"golang.org/x/net/webdav"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Methods are the WebDAV methods Mount registers Routes for, besides
+// the HTTP methods dispatcher.Router.Match registers.
+var Methods = []string{
+	dispatcher.PROPFIND,
+	dispatcher.PROPPATCH,
+	dispatcher.MKCOL,
+	dispatcher.COPY,
+	dispatcher.MOVE,
+	dispatcher.LOCK,
+	dispatcher.UNLOCK,
+}
+
+// Mount registers Routes for the HTTP methods and Methods matching
+// `prefix` and the paths beneath it served by `handler`, i.e.
+//
+//	dav.Mount(router, "/files", &webdav.Handler{
+//		FileSystem: webdav.Dir("./shared"),
+//		LockSystem: webdav.NewMemLS(),
+//	}).Name("files")
+//
+// The handler is served the full request path, and a copy of it with
+// its Prefix set to `prefix` is mounted, so the paths of resources and
+// of `Destination` headers resolve beneath the prefix.
+func Mount(router *dispatcher.Router, prefix string, handler *webdav.Handler) *dispatcher.Router {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	mounted := *handler
+	mounted.Prefix = strings.TrimSuffix(prefix, "/")
+
+	return router.Mount(prefix, &mounted, dispatcher.MountOptions{Methods: Methods})
+}
+
+// MountDir mounts the directory `dir` at `prefix` with Mount, locking
+// resources in memory.
+func MountDir(router *dispatcher.Router, prefix, dir string) *dispatcher.Router {
+	return Mount(router, prefix, &webdav.Handler{
+		FileSystem: webdav.Dir(dir),
+		LockSystem: webdav.NewMemLS(),
+	})
+}
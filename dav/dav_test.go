@@ -0,0 +1,48 @@
+package dav
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"golang.org/x/net/webdav"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestMount ensures WebDAV handlers are mounted for the WebDAV methods
+// with the mount prefix, leaving other Routes unaffected.
+func TestMount(t *testing.T) {
+	handler := &webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()}
+
+	router := dispatcher.NewRouter().
+		Match("/status", http.NotFoundHandler())
+
+	Mount(router, "/files/", handler)
+
+	for _, method := range append([]string{dispatcher.GET, dispatcher.PUT}, Methods...) {
+		for _, path := range []string{"/files", "/files/*"} {
+			mounted, ok := router.Handler(method, path)
+
+			if !ok {
+				t.Errorf("Expected %s %s to be mounted.", method, path)
+				continue
+			}
+
+			if dav, ok := mounted.(*webdav.Handler); !ok || "/files" != dav.Prefix {
+				t.Errorf("Expected %s %s to be served by a WebDAV handler with the prefix /files, got %#v.", method, path, mounted)
+			}
+		}
+
+		if _, ok := router.Handler(method, "/status"); ok != (dispatcher.GET == method || dispatcher.PUT == method) {
+			t.Errorf("Expected %s /status to be registered only for HTTP methods, got %t.", method, ok)
+		}
+	}
+
+	if "" != handler.Prefix {
+		t.Error("Expected the mounted handler to be a copy.")
+	}
+}
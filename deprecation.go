@@ -0,0 +1,117 @@
+package dispatcher
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DeprecationStats reports how often a deprecated Route is still hit.
+type DeprecationStats struct {
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Name       string    `json:"name,omitempty"`
+	Deprecated time.Time `json:"deprecated"`
+	Sunset     time.Time `json:"sunset"`
+	Link       string    `json:"link,omitempty"`
+	Hits       uint64    `json:"hits"`
+}
+
+// deprecation holds the deprecation details of a Route.
+type deprecation struct {
+	since  time.Time
+	sunset time.Time
+	link   string
+	hits   atomic.Uint64
+}
+
+// Deprecated marks the Routes created by the most recent registration
+// call as deprecated, i.e.
+//
+//	router.Get("/v1/users", ListUsersHandler).Deprecated(sunset, "https://example.com/migrate")
+//
+// Responses served by the Routes carry a `Deprecation` header holding
+// the time of the call, as RFC 9745 specifies, a `Sunset` header if
+// `sunset` is not zero, and a `Link` header to `link`, describing the
+// migration, if it is not empty. Requests served by the Routes are
+// counted and reported by Deprecations.
+func (r *Router) Deprecated(sunset time.Time, link string) *Router {
+	return r.DeprecatedSince(time.Now(), sunset, link)
+}
+
+// DeprecatedSince behaves as Deprecated, with the Routes deprecated
+// since `since`, which may be in the future.
+func (r *Router) DeprecatedSince(since, sunset time.Time, link string) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, route := range r.current {
+		route.deprecation = &deprecation{since: since, sunset: sunset, link: link}
+	}
+
+	return r
+}
+
+// Deprecations returns the DeprecationStats of each deprecated Route
+// registered with the Router, ordered by path and then by method.
+func (r *Router) Deprecations() (stats []DeprecationStats) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, routes := range r.dispatcher {
+		for _, registered := range routes {
+			route := registered.Route
+
+			if nil == route.deprecation {
+				continue
+			}
+
+			stats = append(stats, DeprecationStats{
+				Method:     route.method,
+				Path:       route.path,
+				Name:       route.name,
+				Deprecated: route.deprecation.since,
+				Sunset:     route.deprecation.sunset,
+				Link:       route.deprecation.link,
+				Hits:       route.deprecation.hits.Load(),
+			})
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Path != stats[j].Path {
+			return stats[i].Path < stats[j].Path
+		}
+
+		return stats[i].Method < stats[j].Method
+	})
+
+	return
+}
+
+// deprecate counts the request and sets the deprecation headers of
+// the response.
+func (d *deprecation) deprecate(header http.Header) {
+	d.hits.Add(1)
+	header.Set("Deprecation", "@"+strconv.FormatInt(d.since.Unix(), 10))
+
+	if !d.sunset.IsZero() {
+		header.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+	}
+
+	if "" != d.link {
+		header.Add("Link", "<"+d.link+`>; rel="deprecation"`)
+	}
+}
+
+// clone returns a copy of the deprecation with its hits reset, so
+// copied Routes count their requests separately.
+func (d *deprecation) clone() *deprecation {
+	if nil == d {
+		return nil
+	}
+
+	return &deprecation{since: d.since, sunset: d.sunset, link: d.link}
+}
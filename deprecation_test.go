@@ -0,0 +1,67 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeprecated ensures deprecated Routes emit deprecation headers
+// and count the requests they serve.
+func TestDeprecated(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	before := time.Now().Unix()
+
+	router := NewRouter().
+		Get("/v1/users", ok).Deprecated(sunset, "https://example.com/migrate").
+		Get("/v1/orders", ok).DeprecatedSince(time.Unix(1688169599, 0), time.Time{}, "").
+		Get("/v2/users", ok)
+
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/v1/users"))
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/v1/users"))
+
+	if since, err := strconv.ParseInt(strings.TrimPrefix(res.Header().Get("Deprecation"), "@"), 10, 64); nil != err || since < before || since > time.Now().Unix() {
+		t.Errorf("Expected Deprecation header holding the registration time, got %q.", res.Header().Get("Deprecation"))
+	}
+
+	if "Tue, 01 Jan 2030 00:00:00 GMT" != res.Header().Get("Sunset") {
+		t.Errorf("Expected Sunset header, got %q.", res.Header().Get("Sunset"))
+	}
+
+	if `<https://example.com/migrate>; rel="deprecation"` != res.Header().Get("Link") {
+		t.Errorf("Expected Link header, got %q.", res.Header().Get("Link"))
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/v1/orders"))
+
+	if "@1688169599" != res.Header().Get("Deprecation") || "" != res.Header().Get("Sunset") {
+		t.Errorf("Expected Deprecation header @1688169599 without Sunset, got %q %q.", res.Header().Get("Deprecation"), res.Header().Get("Sunset"))
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/v2/users"))
+
+	if "" != res.Header().Get("Deprecation") {
+		t.Error("Expected routes not deprecated to have no Deprecation header.")
+	}
+
+	stats := router.Deprecations()
+
+	if 2 != len(stats) || "/v1/users" != stats[1].Path || 3 != stats[1].Hits {
+		t.Errorf("Expected 3 hits of the deprecated route, got %+v.", stats)
+	}
+
+	if stats := router.Clone().Deprecations(); 2 != len(stats) || 0 != stats[1].Hits {
+		t.Errorf("Expected clones to count hits separately, got %+v.", stats)
+	}
+}
@@ -0,0 +1,106 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// StatusClientClosedRequest is the non-standard status code, borrowed
+// from nginx, logged for requests whose clients disconnected before
+// they were served.
+const StatusClientClosedRequest = 499
+
+// ErrClientGone is returned by writes to the responses of clients that
+// disconnected.
+var ErrClientGone = errors.New("dispatcher: client disconnected")
+
+// DisconnectOptions configures AbortOnDisconnect.
+type DisconnectOptions struct {
+	// OnGone, if set, is called once a request whose client
+	// disconnected was served, with the time it was served for, i.e.
+	// to log the "client gone" outcome.
+	OnGone func(req *http.Request, duration time.Duration)
+}
+
+// disconnectWriter discards writes once the client of its request has
+// disconnected.
+type disconnectWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+// ClientGone reports whether the client of `req` disconnected, as
+// opposed to its context's deadline passing, so long running handlers
+// can stop early:
+//
+//	for _, item := range items {
+//		if dispatcher.ClientGone(req) {
+//			return
+//		}
+//		process(item)
+//	}
+func ClientGone(req *http.Request) bool {
+	return errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// OnClientGone calls `fn` in its own goroutine if the client of `req`
+// disconnects, i.e. to cancel work not bound to the request's context.
+// The returned func stops `fn` from being called, reporting whether it
+// did, and should be called once the request was served.
+func OnClientGone(req *http.Request, fn func()) (stop func() bool) {
+	ctx := req.Context()
+
+	return context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			fn()
+		}
+	})
+}
+
+// AbortOnDisconnect returns a HandlerWrapper discarding what handlers
+// write once their client disconnected, failing their writes with
+// ErrClientGone so they stop rendering, and reporting such requests to
+// DisconnectOptions.OnGone.
+func AbortOnDisconnect(opts DisconnectOptions) HandlerWrapper {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(&disconnectWriter{res, req.Context()}, req)
+
+			if nil != opts.OnGone && ClientGone(req) {
+				opts.OnGone(req, time.Since(start))
+			}
+		})
+	}
+}
+
+// WriteHeader writes the header, unless the client disconnected.
+func (w *disconnectWriter) WriteHeader(status int) {
+	if !errors.Is(w.ctx.Err(), context.Canceled) {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write writes `data`, unless the client disconnected.
+func (w *disconnectWriter) Write(data []byte) (int, error) {
+	if errors.Is(w.ctx.Err(), context.Canceled) {
+		return 0, ErrClientGone
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush flushes the response, if supported, unless the client
+// disconnected.
+func (w *disconnectWriter) Flush() {
+	if !errors.Is(w.ctx.Err(), context.Canceled) {
+		http.NewResponseController(w.ResponseWriter).Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *disconnectWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
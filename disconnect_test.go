@@ -0,0 +1,77 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClientGone ensures disconnections are told apart from deadlines.
+func TestClientGone(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now())
+	defer cancelExpired()
+
+	for ctx, expected := range map[context.Context]bool{context.Background(): false, canceled: true, expired: false} {
+		if gone := ClientGone(generateHttpRequest(GET, "/").WithContext(ctx)); expected != gone {
+			t.Errorf("Expected ClientGone to be %v for %v, got %v.", expected, ctx, gone)
+		}
+	}
+}
+
+// TestOnClientGone ensures callbacks run once clients disconnect,
+// unless stopped.
+func TestOnClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := generateHttpRequest(GET, "/").WithContext(ctx)
+	called := make(chan struct{})
+
+	OnClientGone(req, func() { close(called) })
+	stopped := OnClientGone(req, func() { t.Error("Expected a stopped callback not to be called.") })
+
+	if !stopped() {
+		t.Error("Expected the callback to be stopped.")
+	}
+
+	cancel()
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Error("Expected the callback to be called.")
+	}
+}
+
+// TestAbortOnDisconnect ensures writes fail once the client is gone,
+// and the outcome is reported.
+func TestAbortOnDisconnect(t *testing.T) {
+	var (
+		writeErr error
+		gone     *http.Request
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handler := AbortOnDisconnect(DisconnectOptions{OnGone: func(req *http.Request, duration time.Duration) { gone = req }})(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		io.WriteString(res, "partial")
+		cancel()
+		_, writeErr = io.WriteString(res, "rest")
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, generateHttpRequest(GET, "/").WithContext(ctx))
+
+	if !errors.Is(writeErr, ErrClientGone) || "partial" != res.Body.String() {
+		t.Errorf("Expected writes to fail after disconnecting, got %v writing %q.", writeErr, res.Body.String())
+	}
+
+	if nil == gone {
+		t.Error("Expected the disconnection to be reported.")
+	}
+}
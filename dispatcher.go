@@ -3,11 +3,13 @@
 package dispatcher
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Regular expressions used for splitting paths and generating
@@ -32,16 +34,36 @@ const (
 	PATCH   = "PATCH"
 )
 
+// Constants representing supported WebDAV methods (RFC 4918).
+const (
+	PROPFIND  = "PROPFIND"
+	PROPPATCH = "PROPPATCH"
+	MKCOL     = "MKCOL"
+	COPY      = "COPY"
+	MOVE      = "MOVE"
+	LOCK      = "LOCK"
+	UNLOCK    = "UNLOCK"
+)
+
 // httpMethods is an array of strings containing the supported
-// HTTP methods.
+// HTTP methods, which Match registers Routes for. webdavMethods
+// contains the supported WebDAV methods, only registered explicitly.
 var (
-	httpMethods = []string{GET, PUT, POST, DELETE, OPTIONS, HEAD, TRACE, CONNECT, PATCH}
+	httpMethods      = []string{GET, PUT, POST, DELETE, OPTIONS, HEAD, TRACE, CONNECT, PATCH}
+	webdavMethods    = []string{PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK}
+	supportedMethods = append(append([]string(nil), httpMethods...), webdavMethods...)
 )
 
 // The Dispatcher type is an adapter to shorten creation
-// of a map of strings to a map of pointers to Routes to
-// HandlerFunc types from the http package.
-type Dispatcher map[string]map[*Route]http.Handler
+// of a map of HTTP methods to the Routes registered for them,
+// in the order they were registered.
+type Dispatcher map[string][]RouteHandler
+
+// RouteHandler pairs a Route with the handler registered for it.
+type RouteHandler struct {
+	Route   *Route
+	Handler http.Handler
+}
 
 // The Middleware type is an adapter to allow the use of
 // ordinary functions as middleware handlers.
@@ -56,22 +78,113 @@ type Middleware interface {
 	ServeHTTP(res http.ResponseWriter, req *http.Request) bool
 }
 
+// The RouteMiddlewareHandler type is an adapter to allow the use of
+// ordinary functions as route middleware handlers.
+type RouteMiddlewareHandler func(res http.ResponseWriter, req *http.Request, route *Route, params Params) bool
+
+// ServeRoute calls m(res, req, route, params)
+func (m RouteMiddlewareHandler) ServeRoute(res http.ResponseWriter, req *http.Request, route *Route, params Params) bool {
+	return m(res, req, route, params)
+}
+
+// RouteMiddleware runs after a request has matched a Route, receiving
+// the Route and its parameters, so decisions (i.e. authorization or
+// metrics labels) can be based on the Route rather than the raw path.
+type RouteMiddleware interface {
+	ServeRoute(res http.ResponseWriter, req *http.Request, route *Route, params Params) bool
+}
+
 type Router struct {
 	*sync.Mutex
 	// Dispatcher map used for looking up the Router's Routes.
 	dispatcher Dispatcher
+	// Plugins each request is passed through before matching.
+	plugins []prioritizedPlugin
 	// Middleware each request served by the router should pass through.
-	middleware []Middleware
+	// The slice is replaced rather than modified in place, so requests
+	// in flight keep iterating the Middleware they started with.
+	middleware []namedMiddleware
+	// RouteMiddleware each request matching a Route should pass through.
+	routeMiddleware []RouteMiddleware
+	// HandlerWrappers wrapping the Router's Middleware and Routes.
+	wrappers []prioritizedWrapper
+	// handler serving requests once Plugins have run, built from the
+	// Router's HandlerWrappers.
+	handler http.Handler
 	// handler used when Middleware and Routes fail to service the request.
 	notFoundHandler http.Handler
+	// handler used when the path of a request matches Routes for other
+	// methods only.
+	methodNotAllowedHandler http.Handler
+	// problemJSON and problemHTML render the built-in 404 and 405
+	// responses, if set.
+	problemJSON ProblemRenderer
+	problemHTML ProblemRenderer
+	// handler requests Middleware and Routes fail to service are
+	// delegated to, if set, instead of the notFoundHandler.
+	fallback http.Handler
+	// errorMappings map errors to the status codes WriteError responds
+	// with.
+	errorMappings []errorMapping
+	// callback invoked when a request hits a decoy Route.
+	trapCallback TrapFunc
+	// renderer used by the Router's Render method.
+	renderer Renderer
 	// strict flag to use when creating new Routes.
 	strict bool
+	// tracing configures which requests are traced, if set.
+	tracing *TraceOptions
+	// stats collects the latency statistics of the Router's Routes, if
+	// set.
+	stats *statsCollector
+	// locales new Routes are given locale-prefixed variants for.
+	locales []string
+	// current Routes created by the most recent registration call.
+	current []*Route
+	// names maps Route names to the Routes they were given to.
+	names map[string]*Route
+	// hooks called at points of each request's lifecycle.
+	hooks routerHooks
+	// versions are the path prefixes of the Router's API versions.
+	versions []string
+	// latest is the path prefix of the API version unversioned paths
+	// resolve to.
+	latest string
+	// fallbacks maps the path prefixes of API versions to those of the
+	// versions they fall back to.
+	fallbacks map[string]string
+	// static indexes the first Route matching each static path by
+	// method and path.
+	static map[string]map[string]RouteHandler
+	// misses caches the paths no Route matched, if set.
+	misses *missCache
+	// headers are set on every response the Router serves.
+	headers http.Header
+	// scheduled is set once a Route is only active at times, so misses
+	// are not cached.
+	scheduled bool
+	// traceAllowed is set once TRACE Routes may be registered.
+	traceAllowed bool
+	// inFlight tracks the requests the Router is serving.
+	inFlight *requestCounter
 }
 
 type Route struct {
-	path    string         // path is the original path the Route was created for.
-	keys    []string       // keys represents the names of the Route's parameters.
-	matcher *regexp.Regexp // matcher is the regular expression used for matching the Route.
+	method      string               // method is the HTTP method the Route was registered for.
+	name        string               // name is the name the Route is referred to by.
+	doc         string               // doc is a human readable description of the Route.
+	path        string               // path is the original path the Route was created for.
+	strict      bool                 // strict is whether the Route rejects unexpected trailing slashes.
+	deprecation *deprecation         // deprecation is set if the Route is deprecated.
+	version     string               // version is the API version the Route was registered for.
+	locales     []string             // locales are those the Route has a locale-prefixed variant for.
+	localized   bool                 // localized is whether the Route is the locale-prefixed variant of another.
+	meta        map[string][]string  // meta holds the tags the Route was given.
+	keys        []string             // keys represents the names of the Route's parameters.
+	matcher     *regexp.Regexp       // matcher is the regular expression used for matching the Route.
+	segments    []segment            // segments match the Route without its matcher, if its path allows.
+	active      func(time.Time) bool // active reports whether a scheduled Route matches at a given time.
+	headers     http.Header          // headers are set on the responses of the Route.
 }
 
 // fragmentedPathParameter is a struct that represents the strings
@@ -102,6 +215,44 @@ func (r *Router) UnrestrictRouteMatching() *Router {
 	return r
 }
 
+// Strict causes the Routes created by the most recent registration
+// call to fail to match paths ending with an unexpected trailing
+// slash `/`, regardless of the Router's setting, i.e.
+//
+//	router.Put("/accounts/:id", UpdateAccountHandler).Strict()
+func (r *Router) Strict() *Router {
+	return r.restrictCurrent(true)
+}
+
+// Lenient allows the Routes created by the most recent registration
+// call to match paths ending with an unexpected trailing slash `/`,
+// regardless of the Router's setting.
+func (r *Router) Lenient() *Router {
+	return r.restrictCurrent(false)
+}
+
+// restrictCurrent recompiles the current Routes with `strict`.
+func (r *Router) restrictCurrent(strict bool) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	methods := make(map[string]bool)
+
+	for _, route := range r.current {
+		compiled := NewRoute(route.path, strict)
+		route.strict, route.keys, route.matcher, route.segments = compiled.strict, compiled.keys, compiled.matcher, compiled.segments
+		methods[route.method] = true
+	}
+
+	// Changing what a Route matches may change which Route is the first
+	// to match a static path.
+	for method := range methods {
+		r.reindex(method)
+	}
+
+	return r
+}
+
 // Get registers a route to match the given path argument for
 // HTTP GET requests. When a route is encounted that matches
 // the path, the handler function argument is used to serve the
@@ -153,7 +304,8 @@ func (r *Router) Head(path string, handler http.Handler) *Router {
 // Trace registers a route to match the given path argument for
 // HTTP TRACE requests. When a route is encounted that matches
 // the path, the handler function argument is used to serve the
-// requests.
+// requests. The route is only registered once the Router allows
+// TRACE, see AllowTraceMethod.
 func (r *Router) Trace(path string, handler http.Handler) *Router {
 	return r.AddHandler(TRACE, path, handler)
 }
@@ -174,15 +326,68 @@ func (r *Router) Patch(path string, handler http.Handler) *Router {
 	return r.AddHandler(PATCH, path, handler)
 }
 
+// Propfind registers a route to match the given path argument for
+// WebDAV PROPFIND requests, retrieving the properties of a resource.
+func (r *Router) Propfind(path string, handler http.Handler) *Router {
+	return r.AddHandler(PROPFIND, path, handler)
+}
+
+// Proppatch registers a route to match the given path argument for
+// WebDAV PROPPATCH requests, changing the properties of a resource.
+func (r *Router) Proppatch(path string, handler http.Handler) *Router {
+	return r.AddHandler(PROPPATCH, path, handler)
+}
+
+// Mkcol registers a route to match the given path argument for
+// WebDAV MKCOL requests, creating a collection.
+func (r *Router) Mkcol(path string, handler http.Handler) *Router {
+	return r.AddHandler(MKCOL, path, handler)
+}
+
+// Copy registers a route to match the given path argument for
+// WebDAV COPY requests, copying a resource to the URL of the
+// request's `Destination` header.
+func (r *Router) Copy(path string, handler http.Handler) *Router {
+	return r.AddHandler(COPY, path, handler)
+}
+
+// Move registers a route to match the given path argument for
+// WebDAV MOVE requests, moving a resource to the URL of the
+// request's `Destination` header.
+func (r *Router) Move(path string, handler http.Handler) *Router {
+	return r.AddHandler(MOVE, path, handler)
+}
+
+// LockResource registers a route to match the given path argument
+// for WebDAV LOCK requests, locking a resource. It is not named Lock
+// as the Router's Lock method is that of its embedded Mutex.
+func (r *Router) LockResource(path string, handler http.Handler) *Router {
+	return r.AddHandler(LOCK, path, handler)
+}
+
+// UnlockResource registers a route to match the given path argument
+// for WebDAV UNLOCK requests, removing a lock from a resource.
+func (r *Router) UnlockResource(path string, handler http.Handler) *Router {
+	return r.AddHandler(UNLOCK, path, handler)
+}
+
 // Match registers a route to match the given path argument for
 // any supported HTTP method. When a route is encounted that
 // matches the path, the handler function argument is used to serve
-// the requests.
+// the requests. TRACE is left out unless the Router allows it, and
+// WebDAV methods are left out.
 func (r *Router) Match(path string, handler http.Handler) *Router {
+	var created []*Route
+
 	for _, method := range httpMethods {
 		r.AddHandler(method, path, handler)
+		created = append(created, r.current...)
 	}
 
+	r.Lock()
+	defer r.Unlock()
+
+	r.current = created
 	return r
 }
 
@@ -191,14 +396,32 @@ func (r *Router) Match(path string, handler http.Handler) *Router {
 // If the Router's dispatcher map does not previously have a key
 // for `method`, the AddHandler assumes the `method` is unsupported
 // and the Route created nor its handler will be added to the
-// dispatcher.
+// dispatcher. TRACE Routes are not added either unless the Router
+// allows them, see AllowTraceMethod.
 func (r *Router) AddHandler(method, path string, handler http.Handler) *Router {
 	r.Lock()
 	defer r.Unlock()
 
-	if routes, ok := r.dispatcher[method]; ok {
+	r.current = nil
+
+	if routes, ok := r.dispatcher[method]; ok && r.registers(method) {
+		if 0 < len(r.locales) {
+			// The variant is registered first, so wildcard Routes
+			// don't shadow it.
+			variant := NewRoute(localizePath(path, r.locales), r.strict)
+			variant.method, variant.localized = method, true
+			routes = append(routes, RouteHandler{variant, handler})
+			r.current = append(r.current, variant)
+		}
+
 		route := NewRoute(path, r.strict)
-		routes[route] = handler
+		route.method, route.locales = method, r.locales
+		r.dispatcher[method] = append(routes, RouteHandler{route, handler})
+		r.current = append(r.current, route)
+
+		for _, created := range r.current {
+			r.indexRoute(created)
+		}
 	}
 
 	return r
@@ -210,13 +433,61 @@ func (r *Router) RegisterMiddleware(middleware Middleware) *Router {
 	r.Lock()
 	defer r.Unlock()
 
-	r.middleware = append(r.middleware, middleware)
+	r.addMiddleware(namedMiddleware{middleware: middleware})
+	return r
+}
+
+// Use registers each of `middleware` in order, as with
+// RegisterMiddleware, i.e.
+//
+//	router.Use(logRequest, authenticate, dispatcher.NewStack(limit, audit))
+//
+// Each may be a value implementing Middleware, such as a Stack, or a
+// function of the MiddlewareHandler signature, which is converted to a
+// MiddlewareHandler. Use panics if given any other value, before
+// registering any of `middleware`.
+func (r *Router) Use(middleware ...any) *Router {
+	converted := make([]Middleware, len(middleware))
+
+	for i, m := range middleware {
+		switch m := m.(type) {
+		case Middleware:
+			converted[i] = m
+		case func(http.ResponseWriter, *http.Request) bool:
+			converted[i] = MiddlewareHandler(m)
+		default:
+			panic(fmt.Sprintf("dispatcher: Use given %T, which is neither a Middleware nor a MiddlewareHandler function", m))
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for _, m := range converted {
+		r.addMiddleware(namedMiddleware{middleware: m})
+	}
+
+	return r
+}
+
+// RegisterRouteMiddleware registers middleware called with each
+// request that matches a Route, after Middleware registered with
+// RegisterMiddleware and before the Route's handler. Like Middleware,
+// returning `true` signals the request was served and the handler is
+// not called.
+func (r *Router) RegisterRouteMiddleware(middleware RouteMiddleware) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.routeMiddleware = append(r.routeMiddleware, middleware)
 	return r
 }
 
 // NotFound sets the routers handler that will be called when
 // middleware does not handle the request's response and the
-// path fails to match a known route.
+// path fails to match a known route. By default a 404 Not Found
+// response negotiated with the request is written, see
+// RenderProblems.
 func (r *Router) NotFound(handler http.Handler) *Router {
 	r.Lock()
 	defer r.Unlock()
@@ -225,6 +496,24 @@ func (r *Router) NotFound(handler http.Handler) *Router {
 	return r
 }
 
+// Fallback sets a handler serving requests which neither middleware
+// nor any Route serve in place of the not found handler, i.e. an
+// http.ServeMux or another Router being migrated from:
+//
+//	router := dispatcher.NewRouter().Fallback(legacyMux)
+//
+// Requests whose path matches a Route registered for other methods are
+// delegated too. Not found hooks are not called for delegated requests.
+func (r *Router) Fallback(next http.Handler) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.fallback = next
+	// Misses cached while a fallback was set lack the methods allowed.
+	r.misses.reset()
+	return r
+}
+
 // findMatchingRouteAndHandler looks into the Router's dispatcher
 // object in an attempt to find a matching route and handler function.
 // If a pair are found, they are returned, else both will be nil.
@@ -232,13 +521,22 @@ func (r *Router) findMatchingRouteAndHandler(req *http.Request) (*Route, http.Ha
 	r.Lock()
 	defer r.Unlock()
 
-	method := strings.ToUpper(req.Method)
+	return r.matchPath(req.Method, req.URL.Path)
+}
 
-	if routes, ok := r.dispatcher[method]; ok {
-		for route, handler := range routes {
-			if route.matcher.MatchString(req.URL.Path) {
-				return route, handler
-			}
+// matchPath returns the first Route registered for `method` matching
+// `path` and its handler, or nil for both. The Router must be locked
+// by the caller.
+func (r *Router) matchPath(method, path string) (*Route, http.Handler) {
+	method = strings.ToUpper(method)
+
+	if registered, ok := r.static[method][path]; ok && registered.Route.activeNow() {
+		return registered.Route, registered.Handler
+	}
+
+	for _, registered := range r.dispatcher[method] {
+		if registered.Route.match(path, nil) && registered.Route.activeNow() {
+			return registered.Route, registered.Handler
 		}
 	}
 
@@ -246,45 +544,208 @@ func (r *Router) findMatchingRouteAndHandler(req *http.Request) (*Route, http.Ha
 	return nil, nil
 }
 
+// Lookup returns the Route a request for `method` and `path` would be
+// served by and the Params it captures, resolving API versions as
+// ServeHTTP does, i.e. to assert how paths are routed in tests. The
+// Router's Plugins and Middleware are not run.
+func (r *Router) Lookup(method, path string) (*Route, Params, bool) {
+	r.Lock()
+	route, handler := r.matchPath(method, path)
+	r.Unlock()
+
+	resolved := path
+
+	if nil == route || nil == handler {
+		route, handler, resolved = r.findVersionedRouteAndHandler(method, path)
+	}
+
+	if nil == route || nil == handler {
+		return nil, nil, false
+	}
+
+	return route, route.params(resolved), true
+}
+
 // ServeHTTP handles all incoming HTTP requests. The request is first
-// passed to each of the registered middleware functions. If the middleware
-// function returns a boolean value of `true`, ServeHTTP returns early,
-// assuming that the response has been served by it. If a middleware
-// function fails to serve the request by returning `false`, ServeHTTP
-// attempts to search for a Route that matches the requests URL. If a
-// route is found, the request and response writer are handed over to
-// the matched handler. If no middleware or route is found to handle
-// the request, the Router's not found handler is used.
+// passed through each of the registered Plugins, then through the
+// registered HandlerWrappers to each of the registered middleware
+// functions. If the middleware function returns a boolean value of
+// `true`, ServeHTTP returns early, assuming that the response has been
+// served by it. If a middleware function fails to serve the request by
+// returning `false`, ServeHTTP attempts to search for a Route that
+// matches the requests URL, trying Routes in the order they were
+// registered. If a route is found, the request and response writer are
+// handed over to the matched handler. If no middleware or route is
+// found to handle the request, the Router's not found handler is used.
 func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	for _, middleware := range r.middleware {
-		if middleware.ServeHTTP(res, req) {
+	r.inFlight.begin()
+	defer r.inFlight.end()
+
+	r.Lock()
+	plugins, handler, hooks := r.plugins, r.handler, r.hooks
+	r.Unlock()
+
+	req = applyPlugins(plugins, req)
+
+	if nil == handler {
+		handler = http.HandlerFunc(r.dispatch)
+	} else {
+		// HandlerWrappers run before matching, but may read the
+		// matched Route once the request was served.
+		req = req.WithContext(context.WithValue(req.Context(), routeContextKey{}, new(matchedRoute)))
+	}
+
+	if hooks.active() {
+		serveWithHooks(hooks, handler, res, req)
+		return
+	}
+
+	handler.ServeHTTP(res, req)
+}
+
+// dispatch serves a request with the Router's middleware and Routes.
+func (r *Router) dispatch(res http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	middleware, misses, headers := r.middleware, r.misses, r.headers
+
+	if r.scheduled {
+		misses = nil
+	}
+
+	r.Unlock()
+
+	setHeaders(res, headers)
+
+	trace := r.startTrace(req)
+	defer trace.done()
+
+	for i, named := range middleware {
+		trace.ranMiddleware(i, named.name)
+
+		if named.middleware.ServeHTTP(res, req) {
 			// Midleware returned true meaning it handled the response, return
 			// early.
+			if nil != trace {
+				trace.ServedBy = trace.Middleware[i]
+			}
+
 			return
 		}
 	}
 
-	route, handler := r.findMatchingRouteAndHandler(req)
+	var (
+		route   *Route
+		handler http.Handler
+		path    = req.URL.Path
+	)
+
+	allow, missed := misses.get(req.Method, path)
+
+	if !missed {
+		route, handler = r.findMatchingRouteAndHandler(req)
+
+		if nil == route || nil == handler {
+			route, handler, path = r.findVersionedRouteAndHandler(req.Method, path)
+		}
+	}
+
+	if nil != trace {
+		r.traceRoutes(trace, path)
+		trace.report(res)
+	}
+
+	r.Lock()
+	hooks, routeMiddleware, fallback, mappings, stats := r.hooks, r.routeMiddleware, r.fallback, r.errorMappings, r.stats
+	r.Unlock()
+
+	if (nil == route || nil == handler) && nil != fallback {
+		if !missed {
+			misses.add(req.Method, req.URL.Path, nil)
+		}
+
+		fallback.ServeHTTP(res, req)
+		return
+	}
 
 	if nil == route || nil == handler {
+		for _, hook := range hooks.notFound {
+			hook(req)
+		}
+
+		if !missed {
+			allow = r.allowedMethods(req.Method, req.URL.Path)
+			misses.add(req.Method, req.URL.Path, allow)
+		}
+
 		// No appropriate route and handler combination was found, allow
-		// the notFoundHandler to serve the HTTP Request.
-		r.notFoundHandler.ServeHTTP(res, req)
+		// the notFoundHandler, or the methodNotAllowedHandler if Routes
+		// for other methods match, to serve the HTTP Request.
+		r.serveUnmatched(res, req, allow)
 		return
 	}
 
 	// Middleware did not serve the request, pass it to the
-	// handler.
+	// handler along with the Route's parameters. They are captured
+	// into a pooled buffer and copied, as handlers may retain them.
+	buffer := paramsPool.Get().(*Params)
+	*buffer = (*buffer)[:0]
+	route.appendParams(path, buffer)
+
+	var params Params
+
+	if 0 < len(*buffer) {
+		params = append(make(Params, 0, len(*buffer)), *buffer...)
+	}
+
+	paramsPool.Put(buffer)
+
+	if nil != route.deprecation {
+		route.deprecation.deprecate(res.Header())
+	}
+
+	for _, hook := range hooks.routeMatched {
+		hook(req, route, params)
+	}
+
+	if "" != route.version {
+		res.Header().Set(APIVersionHeader, route.version)
+	}
+
+	setHeaders(res, route.headers)
+
+	ctx := WithRoute(req.Context(), route, params)
+
+	if 0 < len(mappings) {
+		ctx = context.WithValue(ctx, errorMappingsContextKey{}, mappings)
+	}
+
+	req = req.WithContext(ctx)
+
+	if nil != stats {
+		start, writer := time.Now(), NewResponseWriter(res)
+		res = writer
+
+		defer func() {
+			stats.record(route, time.Since(start), writer.Status())
+		}()
+	}
+
+	for _, middleware := range routeMiddleware {
+		if middleware.ServeRoute(res, req, route, params) {
+			return
+		}
+	}
+
 	handler.ServeHTTP(res, req)
 }
 
 // NewDispatcher creates a new Dispatcher map, creating
-// submaps for all supported HTTP methods.
+// entries for all supported HTTP and WebDAV methods.
 func NewDispatcher() (dispatcher Dispatcher) {
 	dispatcher = make(Dispatcher)
 
-	for _, method := range httpMethods {
-		dispatcher[method] = make(map[*Route]http.Handler)
+	for _, method := range supportedMethods {
+		dispatcher[method] = nil
 	}
 
 	return
@@ -292,13 +753,14 @@ func NewDispatcher() (dispatcher Dispatcher) {
 
 // NewRouter creates a new Router object, returning a pointer
 // to it. The Router's dispatcher is set with by calling the
-// NewDispatcher method, and its not found handler is set to
-// the http packages NotFoundHandler by default.
+// NewDispatcher method, and requests matching no route are
+// answered with the Router's built-in 404 and 405 responses
+// by default.
 func NewRouter() (r *Router) {
 	r = new(Router)
 	r.dispatcher = NewDispatcher()
-	r.notFoundHandler = http.NotFoundHandler()
 	r.Mutex = &sync.Mutex{}
+	r.inFlight = newRequestCounter()
 	return
 }
 
@@ -310,6 +772,7 @@ func NewRouter() (r *Router) {
 func NewRoute(path string, strict bool) (route *Route) {
 	route = new(Route)
 	route.path = path
+	route.strict = strict
 
 	compiled := replaceCaptureParams.ReplaceAllString(path, `(?:/`)
 	parameters := splitRoutePathParams.FindAllStringSubmatch(path, -1)
@@ -338,11 +801,11 @@ func NewRoute(path string, strict bool) (route *Route) {
 		}
 
 		if 0 < len(fragmented.capture) {
-			formatted = fmt.Sprintf("%v%v", formatted, fragmented.capture)
+			formatted = fmt.Sprintf("%v(?P<%v>%v)", formatted, fragmented.name, fragmented.capture)
 		} else if 0 < len(fragmented.format) {
-			formatted = fmt.Sprintf("%v([^/.]+?)", formatted)
+			formatted = fmt.Sprintf("%v(?P<%v>[^/.]+?)", formatted, fragmented.name)
 		} else {
-			formatted = fmt.Sprintf("%v([^/]+?)", formatted)
+			formatted = fmt.Sprintf("%v(?P<%v>[^/]+?)", formatted, fragmented.name)
 		}
 
 		formatted = fmt.Sprintf("%v)", formatted)
@@ -356,8 +819,9 @@ func NewRoute(path string, strict bool) (route *Route) {
 	}
 
 	compiled = replaceSlashes.ReplaceAllString(compiled, "\\$1")
-	compiled = replaceWildcards.ReplaceAllString(compiled, "(.*)")
+	compiled = replaceWildcards.ReplaceAllString(compiled, fmt.Sprintf("(?P<%v>.*)", wildcardGroup))
 	route.matcher = regexp.MustCompile(fmt.Sprintf(`^%v$`, compiled))
+	route.segments = compileSegments(path)
 
 	return
 }
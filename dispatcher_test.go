@@ -2,6 +2,7 @@ package dispatcher
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -27,6 +28,30 @@ func TestRestrictedRoute(t *testing.T) {
 	}
 }
 
+// TestPerRouteStrictness ensures Strict and Lenient override the
+// Router's strictness for the most recently registered Routes.
+func TestPerRouteStrictness(t *testing.T) {
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	router := NewRouter().
+		Get("/lenient", ok).
+		Get("/strict", ok).Strict()
+
+	if _, matched := router.findMatchingRouteAndHandler(generateHttpRequest(GET, "/lenient/")); nil == matched {
+		t.Error("Expected lenient route to match path with trailing slash.")
+	}
+
+	if _, matched := router.findMatchingRouteAndHandler(generateHttpRequest(GET, "/strict/")); nil != matched {
+		t.Error("Expected strict route to fail to match path with trailing slash.")
+	}
+
+	router.RestrictRouteMatching().Get("/exception", ok).Lenient()
+
+	if _, matched := router.findMatchingRouteAndHandler(generateHttpRequest(GET, "/exception/")); nil == matched {
+		t.Error("Expected lenient route on a restricted router to match path with trailing slash.")
+	}
+}
+
 // TestOptionalRouteParameters ensures routes are matched when optional
 // route parameters are ommited.
 func TestOptionalRouteParameters(t *testing.T) {
@@ -190,6 +215,7 @@ func TestRoutedTraceRequest(t *testing.T) {
 	path := "/path/:to/:use"
 
 	NewRouter().
+		AllowTraceMethod().
 		Get(path, generateCountableHandler(&counter)).
 		Put(path, generateCountableHandler(&counter)).
 		Post(path, generateCountableHandler(&counter)).
@@ -259,6 +285,7 @@ func TestRoutedMatchRequest(t *testing.T) {
 	path := "/path/:to/:use"
 
 	router := NewRouter().
+		AllowTraceMethod().
 		Match(path, generateCountableHandler(&counter))
 
 	for _, method := range httpMethods {
@@ -357,3 +384,127 @@ func generateCountableMiddleware(n *int, handle bool) MiddlewareHandler {
 		return handle
 	}
 }
+
+// TestRegistrationOrder ensures overlapping Routes match in the order
+// they were registered, including static Routes.
+func TestRegistrationOrder(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(name))
+		})
+	}
+
+	serve := func(router *Router, path string) string {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+		return res.Body.String()
+	}
+
+	paramFirst := NewRouter().
+		Get("/a/:b", handler("param")).
+		Get("/a/special", handler("special"))
+
+	for i := 0; i < 10; i++ {
+		if body := serve(paramFirst, "/a/special"); "param" != body {
+			t.Fatalf("Expected the first registered route to match, got %q.", body)
+		}
+	}
+
+	specialFirst := NewRouter().
+		Get("/a/special", handler("special")).
+		Get("/a/:b", handler("param"))
+
+	if body := serve(specialFirst, "/a/special"); "special" != body {
+		t.Errorf("Expected the first registered route to match, got %q.", body)
+	}
+
+	if body := serve(specialFirst, "/a/other"); "param" != body {
+		t.Errorf("Expected the parameterized route to match other paths, got %q.", body)
+	}
+
+	// Making the parameterized Route strict stops it shadowing the
+	// trailing slash path of the static Route.
+	shadowed := NewRouter().
+		Get("/a/:b", handler("param")).
+		Get("/a/special", handler("special"))
+
+	if body := serve(shadowed, "/a/special/"); "param" != body {
+		t.Errorf("Expected the lenient parameterized route to match, got %q.", body)
+	}
+
+	shadowed.current = []*Route{shadowed.dispatcher[GET][0].Route}
+	shadowed.Strict()
+
+	if body := serve(shadowed, "/a/special/"); "special" != body {
+		t.Errorf("Expected the static route to match once the first is strict, got %q.", body)
+	}
+}
+
+// TestFallback ensures requests matching no Route are delegated to
+// the fallback handler.
+func TestFallback(t *testing.T) {
+	var notFound int
+
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("/legacy", func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("legacy"))
+	})
+
+	router := NewRouter().
+		Fallback(legacy).
+		OnNotFound(func(req *http.Request) { notFound++ }).
+		Get("/users", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("users"))
+		}))
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{GET, "/users", "users", http.StatusOK},
+		{GET, "/legacy", "legacy", http.StatusOK},
+		{POST, "/users", "404 page not found\n", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(test.method, test.path))
+
+		if test.status != res.Code || test.body != res.Body.String() {
+			t.Errorf("Expected %s %s to be served %d %q, got %d %q.", test.method, test.path, test.status, test.body, res.Code, res.Body.String())
+		}
+	}
+
+	if 0 != notFound {
+		t.Errorf("Expected not found hooks not to be called for delegated requests, got %d calls.", notFound)
+	}
+}
+
+// TestRoutedWebDAVRequests ensures functions registered via the
+// Routers WebDAV methods respond to requests of their method only.
+func TestRoutedWebDAVRequests(t *testing.T) {
+	counters := make(map[string]*int)
+
+	for _, method := range []string{PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK} {
+		counters[method] = new(int)
+	}
+
+	path := "/path/:to/:use"
+
+	router := NewRouter().
+		Propfind(path, generateCountableHandler(counters[PROPFIND])).
+		Proppatch(path, generateCountableHandler(counters[PROPPATCH])).
+		Mkcol(path, generateCountableHandler(counters[MKCOL])).
+		Copy(path, generateCountableHandler(counters[COPY])).
+		Move(path, generateCountableHandler(counters[MOVE])).
+		LockResource(path, generateCountableHandler(counters[LOCK])).
+		UnlockResource(path, generateCountableHandler(counters[UNLOCK]))
+
+	for method, counter := range counters {
+		router.ServeHTTP(nil, generateHttpRequest(method, "/path/to/use"))
+
+		if 1 != *counter {
+			t.Errorf("Expected %s Route to be served once, counter set to %d.", method, *counter)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Package dispatchertest provides a fluent client for testing Routers,
+// and other http.Handlers, without a network or hand built requests:
+//
+//	dispatchertest.New(router).
+//		Get("/users/1").
+//		WithHeader("Accept", "application/json").
+//		Expect(t).
+//		Status(200).
+//		Param("id", "1").
+//		JSONPath("$.id", 1)
+package dispatchertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Client builds requests served by a handler.
+type Client struct {
+	handler http.Handler
+	header  http.Header
+}
+
+// Request is a request being built by a Client.
+type Request struct {
+	client *Client
+	req    *http.Request
+	err    error
+}
+
+// Response is the response a Request was served, with assertions
+// reporting failures to the test it was created for.
+type Response struct {
+	t        testing.TB
+	recorder *httptest.ResponseRecorder
+	match    *match
+}
+
+// match records the Route a request matched and its Params.
+type match struct {
+	route  *dispatcher.Route
+	params dispatcher.Params
+}
+
+// matchContextKey is the context key a request's match is recorded
+// under.
+type matchContextKey struct{}
+
+// hooked holds the Routers the hook recording matches was registered
+// with, so Clients created for the same Router share it.
+var hooked sync.Map
+
+// New creates a Client serving requests with `handler`. If `handler` is
+// a *dispatcher.Router, a hook is registered recording the Route each
+// request matches, so Responses can assert on it. The hook is
+// registered once per Router and only records requests served by a
+// Client.
+func New(handler http.Handler) *Client {
+	if router, ok := handler.(*dispatcher.Router); ok {
+		if _, loaded := hooked.LoadOrStore(router, struct{}{}); !loaded {
+			router.OnRouteMatched(recordMatch)
+		}
+	}
+
+	return &Client{handler: handler, header: make(http.Header)}
+}
+
+// recordMatch records the Route `req` matched and its Params.
+func recordMatch(req *http.Request, route *dispatcher.Route, params dispatcher.Params) {
+	if matched, ok := req.Context().Value(matchContextKey{}).(*match); ok {
+		matched.route, matched.params = route, params
+	}
+}
+
+// WithHeader sets a header sent with every request built by the
+// Client, i.e. credentials.
+func (c *Client) WithHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// Get builds a GET request for `target`.
+func (c *Client) Get(target string) *Request {
+	return c.Request(http.MethodGet, target)
+}
+
+// Head builds a HEAD request for `target`.
+func (c *Client) Head(target string) *Request {
+	return c.Request(http.MethodHead, target)
+}
+
+// Post builds a POST request for `target`.
+func (c *Client) Post(target string) *Request {
+	return c.Request(http.MethodPost, target)
+}
+
+// Put builds a PUT request for `target`.
+func (c *Client) Put(target string) *Request {
+	return c.Request(http.MethodPut, target)
+}
+
+// Patch builds a PATCH request for `target`.
+func (c *Client) Patch(target string) *Request {
+	return c.Request(http.MethodPatch, target)
+}
+
+// Delete builds a DELETE request for `target`.
+func (c *Client) Delete(target string) *Request {
+	return c.Request(http.MethodDelete, target)
+}
+
+// Options builds an OPTIONS request for `target`.
+func (c *Client) Options(target string) *Request {
+	return c.Request(http.MethodOptions, target)
+}
+
+// Request builds a `method` request for `target`, a path optionally
+// followed by a query.
+func (c *Client) Request(method, target string) *Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header = c.header.Clone()
+
+	return &Request{client: c, req: req}
+}
+
+// WithHeader sets a header of the request.
+func (r *Request) WithHeader(key, value string) *Request {
+	r.req.Header.Set(key, value)
+	return r
+}
+
+// WithQuery adds a query parameter to the request.
+func (r *Request) WithQuery(key, value string) *Request {
+	query := r.req.URL.Query()
+	query.Add(key, value)
+	r.req.URL.RawQuery = query.Encode()
+	r.req.RequestURI = r.req.URL.RequestURI()
+
+	return r
+}
+
+// WithCookie adds a cookie to the request.
+func (r *Request) WithCookie(cookie *http.Cookie) *Request {
+	r.req.AddCookie(cookie)
+	return r
+}
+
+// WithContext replaces the context of the request.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.req = r.req.WithContext(ctx)
+	return r
+}
+
+// WithBody sets the body of the request and its Content-Type.
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.req.Body = io.NopCloser(bytes.NewReader(body))
+	r.req.ContentLength = int64(len(body))
+	r.req.Header.Set("Content-Type", contentType)
+
+	return r
+}
+
+// WithJSON sets the body of the request to `v` encoded as JSON.
+func (r *Request) WithJSON(v any) *Request {
+	body, err := json.Marshal(v)
+
+	if nil != err {
+		r.err = err
+		return r
+	}
+
+	return r.WithBody("application/json", body)
+}
+
+// WithForm sets the body of the request to urlencoded `values`.
+func (r *Request) WithForm(values url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+// Do serves the request, returning the recorded response.
+func (r *Request) Do() *httptest.ResponseRecorder {
+	recorder, _ := r.serve()
+	return recorder
+}
+
+// Expect serves the request, returning its Response for assertions
+// reported to `t`.
+func (r *Request) Expect(t testing.TB) *Response {
+	t.Helper()
+
+	if nil != r.err {
+		t.Fatalf("Failed to build %s %s: %v", r.req.Method, r.req.URL, r.err)
+	}
+
+	recorder, matched := r.serve()
+	return &Response{t: t, recorder: recorder, match: matched}
+}
+
+// serve serves the request, recording the Route it matches.
+func (r *Request) serve() (*httptest.ResponseRecorder, *match) {
+	matched := new(match)
+	recorder := httptest.NewRecorder()
+
+	r.client.handler.ServeHTTP(recorder, r.req.WithContext(context.WithValue(r.req.Context(), matchContextKey{}, matched)))
+	return recorder, matched
+}
+
+// Recorder returns the recorded response.
+func (r *Response) Recorder() *httptest.ResponseRecorder {
+	return r.recorder
+}
+
+// Status asserts the response's status code is `status`.
+func (r *Response) Status(status int) *Response {
+	r.t.Helper()
+
+	if status != r.recorder.Code {
+		r.t.Errorf("Expected status %d, got %d.", status, r.recorder.Code)
+	}
+
+	return r
+}
+
+// Header asserts the response's header `key` is `value`.
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+
+	if got := r.recorder.Header().Get(key); value != got {
+		r.t.Errorf("Expected header %s to be %q, got %q.", key, value, got)
+	}
+
+	return r
+}
+
+// Body asserts the response's body is `body`.
+func (r *Response) Body(body string) *Response {
+	r.t.Helper()
+
+	if got := r.recorder.Body.String(); body != got {
+		r.t.Errorf("Expected body %q, got %q.", body, got)
+	}
+
+	return r
+}
+
+// BodyContains asserts the response's body contains `substr`.
+func (r *Response) BodyContains(substr string) *Response {
+	r.t.Helper()
+
+	if got := r.recorder.Body.String(); !strings.Contains(got, substr) {
+		r.t.Errorf("Expected body to contain %q, got %q.", substr, got)
+	}
+
+	return r
+}
+
+// JSON decodes the response's body into `dst`.
+func (r *Response) JSON(dst any) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), dst); nil != err {
+		r.t.Errorf("Expected a JSON body, got %q: %v.", r.recorder.Body.String(), err)
+	}
+
+	return r
+}
+
+// JSONPath asserts the value at `path` in the response's JSON body
+// equals `expected` once encoded as JSON, so `1` matches the number 1.
+// Paths select object keys and array indexes from the root `$`, i.e.
+// `$.users[0].name`.
+func (r *Response) JSONPath(path string, expected any) *Response {
+	r.t.Helper()
+
+	var body any
+
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), &body); nil != err {
+		r.t.Errorf("Expected a JSON body, got %q: %v.", r.recorder.Body.String(), err)
+		return r
+	}
+
+	got, err := lookupJSONPath(body, path)
+
+	if nil != err {
+		r.t.Errorf("Expected %s in %s: %v.", path, r.recorder.Body.String(), err)
+		return r
+	}
+
+	want, err := normalizeJSON(expected)
+
+	if nil != err {
+		r.t.Errorf("Failed to encode %v as JSON: %v.", expected, err)
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("Expected %s to be %v, got %v.", path, want, got)
+	}
+
+	return r
+}
+
+// Route asserts the request matched the Route at `path`, the path it
+// was registered with. Only requests served by a *dispatcher.Router
+// record the Route they match.
+func (r *Response) Route(path string) *Response {
+	r.t.Helper()
+
+	switch {
+	case nil == r.match.route:
+		r.t.Errorf("Expected route %q to match, got none.", path)
+	case path != r.match.route.Info().Path:
+		r.t.Errorf("Expected route %q to match, got %q.", path, r.match.route.Info().Path)
+	}
+
+	return r
+}
+
+// Param asserts the Route matched captured `value` for the parameter
+// `key`.
+func (r *Response) Param(key, value string) *Response {
+	r.t.Helper()
+
+	if got, ok := r.match.params.Lookup(key); !ok || value != got {
+		r.t.Errorf("Expected parameter %s to be %q, got %q.", key, value, got)
+	}
+
+	return r
+}
+
+// lookupJSONPath returns the value at `path` in the decoded JSON
+// `value`.
+func lookupJSONPath(value any, path string) (any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %q does not start at the root $", path)
+	}
+
+	rest := path[1:]
+
+	for "" != rest {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+
+			if 0 == end {
+				end = len(rest)
+			}
+
+			object, ok := value.(map[string]any)
+
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", strings.TrimSuffix(path, rest))
+			}
+
+			key := rest[1:end]
+
+			if value, ok = object[key]; !ok {
+				return nil, fmt.Errorf("%s has no key %q", strings.TrimSuffix(path, rest), key)
+			}
+
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+
+			if -1 == end {
+				return nil, fmt.Errorf("unterminated index in %q", path)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+
+			if nil != err {
+				return nil, fmt.Errorf("invalid index in %q", path)
+			}
+
+			array, ok := value.([]any)
+
+			if !ok || 0 > index || len(array) <= index {
+				return nil, fmt.Errorf("%s has no index %d", strings.TrimSuffix(path, rest), index)
+			}
+
+			value, rest = array[index], rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", rest[0], path)
+		}
+	}
+
+	return value, nil
+}
+
+// normalizeJSON returns `v` as it decodes from JSON, so it can be
+// compared to decoded values.
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+
+	if nil != err {
+		return nil, err
+	}
+
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+
+	return normalized, err
+}
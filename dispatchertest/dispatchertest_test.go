@@ -0,0 +1,72 @@
+package dispatchertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestClient ensures requests are built, served and asserted on.
+func TestClient(t *testing.T) {
+	router := dispatcher.NewRouter().
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			id := dispatcher.ParamsFromContext(req.Context()).Get("id")
+
+			res.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(res).Encode(map[string]any{
+				"id":    1,
+				"name":  req.Header.Get("X-Name"),
+				"roles": []string{"admin", req.URL.Query().Get("role")},
+				"path":  id,
+			})
+		}))
+
+	New(router).
+		WithHeader("X-Name", "ann").
+		Get("/users/1").
+		WithQuery("role", "owner").
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/json").
+		Route("/users/:id").
+		Param("id", "1").
+		JSONPath("$.id", 1).
+		JSONPath("$.name", "ann").
+		JSONPath("$.roles[1]", "owner")
+
+	New(router).Get("/missing").Expect(t).Status(http.StatusNotFound)
+}
+
+// TestClientRetainsParams ensures the Params recorded for a Response
+// are not overwritten by later requests.
+func TestClientRetainsParams(t *testing.T) {
+	router := dispatcher.NewRouter().
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+
+	first := New(router).Get("/users/1").Expect(t)
+	second := New(router).Get("/users/2").Expect(t)
+
+	first.Param("id", "1")
+	second.Param("id", "2")
+}
+
+// TestLookupJSONPath ensures JSON paths select nested values and
+// report missing ones.
+func TestLookupJSONPath(t *testing.T) {
+	var body any
+	json.Unmarshal([]byte(`{"users":[{"name":"ann","tags":{"a.b":1}}]}`), &body)
+
+	if value, err := lookupJSONPath(body, "$.users[0].name"); nil != err || "ann" != value {
+		t.Errorf("Expected ann, got %v (%v).", value, err)
+	}
+
+	for _, path := range []string{"users", "$.users[1]", "$.users.name", "$.users[0].age", "$.users[0"} {
+		if _, err := lookupJSONPath(body, path); nil == err {
+			t.Errorf("Expected %q to fail.", path)
+		}
+	}
+}
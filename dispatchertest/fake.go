@@ -0,0 +1,122 @@
+package dispatchertest
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// FakeRouter is a Router for unit testing code that wires Routes and
+// Middleware. The code under test is given the embedded Router, after
+// which the FakeRouter reports what was registered; nothing needs to be
+// served:
+//
+//	fake := dispatchertest.NewFakeRouter()
+//	users.Register(fake.Router)
+//	fake.AssertRegistered(t, "GET", "/users")
+//	fake.AssertMiddleware(t, "auth")
+type FakeRouter struct {
+	*dispatcher.Router
+}
+
+// Registration describes a Route registered with a FakeRouter.
+type Registration struct {
+	dispatcher.RouteInfo
+	Handler http.Handler
+}
+
+// NewFakeRouter creates a FakeRouter.
+func NewFakeRouter() *FakeRouter {
+	return &FakeRouter{dispatcher.NewRouter()}
+}
+
+// Registrations returns each Route registered, ordered by path and
+// then by method.
+func (f *FakeRouter) Registrations() (registrations []Registration) {
+	for _, info := range f.Routes() {
+		handler, _ := f.Handler(info.Method, info.Path)
+		registrations = append(registrations, Registration{info, handler})
+	}
+
+	return
+}
+
+// Registered returns the Registration of the Route registered for
+// `method` requests with the path `path`, and whether there is one.
+func (f *FakeRouter) Registered(method, path string) (Registration, bool) {
+	for _, registration := range f.Registrations() {
+		if method == registration.Method && path == registration.Path {
+			return registration, true
+		}
+	}
+
+	return Registration{}, false
+}
+
+// AssertRegistered asserts a Route was registered for `method` requests
+// with the path `path`, returning its Registration.
+func (f *FakeRouter) AssertRegistered(t testing.TB, method, path string) Registration {
+	t.Helper()
+
+	registration, ok := f.Registered(method, path)
+
+	if !ok {
+		t.Errorf("Expected %s %s to be registered.", method, path)
+	}
+
+	return registration
+}
+
+// AssertNotRegistered asserts no Route was registered for `method`
+// requests with the path `path`.
+func (f *FakeRouter) AssertNotRegistered(t testing.TB, method, path string) {
+	t.Helper()
+
+	if _, ok := f.Registered(method, path); ok {
+		t.Errorf("Expected %s %s not to be registered.", method, path)
+	}
+}
+
+// AssertMiddleware asserts named Middleware `name` was registered.
+func (f *FakeRouter) AssertMiddleware(t testing.TB, name string) {
+	t.Helper()
+
+	for _, registered := range f.MiddlewareNames() {
+		if name == registered {
+			return
+		}
+	}
+
+	t.Errorf("Expected middleware %q to be registered, got %v.", name, f.MiddlewareNames())
+}
+
+// AssertMeta asserts the Route registered for `method` requests with
+// the path `path` was tagged with `values` under `key`, i.e. to check
+// it requires a role.
+func (f *FakeRouter) AssertMeta(t testing.TB, method, path, key string, values ...string) {
+	t.Helper()
+
+	registration, ok := f.Registered(method, path)
+
+	if !ok {
+		t.Errorf("Expected %s %s to be registered.", method, path)
+		return
+	}
+
+	got := registration.Meta[key]
+
+	if len(values) != len(got) {
+		t.Errorf("Expected %s %s to be tagged %s=%v, got %v.", method, path, key, values, got)
+		return
+	}
+
+	for i := range values {
+		if values[i] != got[i] {
+			t.Errorf("Expected %s %s to be tagged %s=%v, got %v.", method, path, key, values, got)
+			return
+		}
+	}
+}
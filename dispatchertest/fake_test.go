@@ -0,0 +1,40 @@
+package dispatchertest
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// registerUsers wires Routes as a package under test would.
+func registerUsers(router *dispatcher.Router) {
+	auth := dispatcher.MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+		return false
+	})
+
+	router.RegisterNamedMiddleware("auth", auth).
+		Get("/users", http.NotFoundHandler()).
+		Delete("/users/:id", http.NotFoundHandler()).Meta("roles", "admin")
+}
+
+// TestFakeRouter ensures registrations are reported without serving
+// requests.
+func TestFakeRouter(t *testing.T) {
+	fake := NewFakeRouter()
+	registerUsers(fake.Router)
+
+	if registration := fake.AssertRegistered(t, "GET", "/users"); nil == registration.Handler {
+		t.Error("Expected registration to carry its handler.")
+	}
+
+	fake.AssertNotRegistered(t, "POST", "/users")
+	fake.AssertMiddleware(t, "auth")
+	fake.AssertMeta(t, "DELETE", "/users/:id", "roles", "admin")
+
+	if 2 != len(fake.Registrations()) {
+		t.Errorf("Expected 2 registrations, got %v.", fake.Registrations())
+	}
+}
@@ -0,0 +1,66 @@
+package dispatchertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// NewRequest returns a request for `target` carrying `params`, as if
+// it matched a Route capturing them, so a handler can be tested in
+// isolation:
+//
+//	req := dispatchertest.NewRequest("GET", "/users/1", dispatcher.Params{{Key: "id", Value: "1"}})
+//	ShowUserHandler(httptest.NewRecorder(), req)
+func NewRequest(method, target string, params dispatcher.Params) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(dispatcher.WithParams(req.Context(), params))
+}
+
+// NewRouteRequest returns a request for `target` carrying the context
+// `router` would give it when serving it: the Params and API version of
+// the Route it matches. The test fails if no Route matches.
+func NewRouteRequest(t testing.TB, router *dispatcher.Router, method, target string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	route, params, ok := router.Lookup(method, req.URL.Path)
+
+	if !ok {
+		t.Fatalf("Expected a route to match %s %s.", method, req.URL.Path)
+	}
+
+	return req.WithContext(dispatcher.WithRoute(req.Context(), route, params))
+}
+
+// AssertRoute asserts a request for `method` and `path` resolves to the
+// Route registered with the path `want`, returning the Params it
+// captures.
+func AssertRoute(t testing.TB, router *dispatcher.Router, method, path, want string) dispatcher.Params {
+	t.Helper()
+
+	route, params, ok := router.Lookup(method, path)
+
+	switch {
+	case !ok:
+		t.Errorf("Expected %s %s to resolve to %q, got none.", method, path, want)
+	case want != route.Info().Path:
+		t.Errorf("Expected %s %s to resolve to %q, got %q.", method, path, want, route.Info().Path)
+	}
+
+	return params
+}
+
+// AssertNoRoute asserts a request for `method` and `path` matches no
+// Route.
+func AssertNoRoute(t testing.TB, router *dispatcher.Router, method, path string) {
+	t.Helper()
+
+	if route, _, ok := router.Lookup(method, path); ok {
+		t.Errorf("Expected %s %s to match no route, got %q.", method, path, route.Info().Path)
+	}
+}
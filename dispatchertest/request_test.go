@@ -0,0 +1,45 @@
+package dispatchertest
+
+import (
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestNewRequest ensures requests carry the Params they are seeded
+// with.
+func TestNewRequest(t *testing.T) {
+	req := NewRequest("GET", "/users/1", dispatcher.Params{{Key: "id", Value: "1"}})
+
+	if "1" != dispatcher.ParamsFromContext(req.Context()).Get("id") {
+		t.Error("Expected request to carry its params.")
+	}
+}
+
+// TestRouteRequests ensures requests are seeded and paths resolved as
+// the Router would.
+func TestRouteRequests(t *testing.T) {
+	router := dispatcher.NewRouter().
+		Version("/v2", func(v *dispatcher.Group) {
+			v.Latest().Get("/users/:id", http.NotFoundHandler())
+		})
+
+	req := NewRouteRequest(t, router, "GET", "/users/7?expand=true")
+
+	if "7" != dispatcher.ParamsFromContext(req.Context()).Get("id") {
+		t.Error("Expected route request to carry the route's params.")
+	}
+
+	if version, _ := dispatcher.VersionFromContext(req.Context()); "v2" != version {
+		t.Errorf("Expected route request to carry the route's version, got %q.", version)
+	}
+
+	if params := AssertRoute(t, router, "GET", "/v2/users/8", "/v2/users/:id"); "8" != params.Get("id") {
+		t.Errorf("Expected resolved params, got %v.", params)
+	}
+
+	AssertNoRoute(t, router, "POST", "/v2/users/8")
+}
@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// requestCounter tracks the number of requests being served.
+type requestCounter struct {
+	// count of requests being served.
+	count atomic.Int64
+	// drained is signalled when a request completes.
+	drained chan struct{}
+}
+
+// newRequestCounter creates a requestCounter with no requests in
+// flight.
+func newRequestCounter() *requestCounter {
+	return &requestCounter{drained: make(chan struct{}, 1)}
+}
+
+// begin records a request starting to be served.
+func (c *requestCounter) begin() {
+	c.count.Add(1)
+}
+
+// end records a request having been served.
+func (c *requestCounter) end() {
+	c.count.Add(-1)
+
+	select {
+	case c.drained <- struct{}{}:
+	default:
+	}
+}
+
+// drain blocks until no requests are in flight or `ctx` expires,
+// returning its error in the latter case.
+func (c *requestCounter) drain(ctx context.Context) error {
+	for 0 < c.count.Load() {
+		select {
+		case <-c.drained:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// InFlight returns the number of requests the Router is serving.
+func (r *Router) InFlight() int64 {
+	return r.inFlight.count.Load()
+}
+
+// Drain blocks until the requests the Router is serving have completed,
+// or `ctx` expires, in which case its error is returned. The Router
+// keeps accepting requests, so callers stop routing traffic to it
+// first, i.e. during an orchestrated failover:
+//
+//	balancer.Remove(instance)
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	err := router.Drain(ctx)
+//
+// Unlike Server.Shutdown, which only waits for the requests of its
+// Server, Drain waits for every request the Router serves, whichever
+// Server or handler it was reached through.
+func (r *Router) Drain(ctx context.Context) error {
+	return r.inFlight.drain(ctx)
+}
@@ -0,0 +1,45 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRouterDrain ensures Drain waits for requests in flight, and gives
+// up once its context expires.
+func TestRouterDrain(t *testing.T) {
+	router := NewRouter()
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router.Get("/slow", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/slow"))
+	<-started
+
+	if 1 != router.InFlight() {
+		t.Errorf("Expected 1 request in flight, found %d.", router.InFlight())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := router.Drain(ctx); context.DeadlineExceeded != err {
+		t.Errorf("Expected Drain to time out, got %v.", err)
+	}
+
+	close(release)
+
+	if err := router.Drain(context.Background()); nil != err {
+		t.Errorf("Expected Drain to succeed, got %v.", err)
+	}
+
+	if 0 != router.InFlight() {
+		t.Errorf("Expected no requests in flight, found %d.", router.InFlight())
+	}
+}
@@ -0,0 +1,145 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+)
+
+// errorBody is the response body written by WriteError.
+type errorBody struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Message string   `json:"error" xml:",chardata"`
+}
+
+// errorMapping maps errors matching `target` to `status`.
+type errorMapping struct {
+	target error
+	status int
+}
+
+// errorMappingsContextKey is the context key the error mappings of the
+// Router serving a request are stored under.
+type errorMappingsContextKey struct{}
+
+// ErrorHandlerFunc is an adapter to allow the use of functions
+// returning errors as handlers. Errors returned are written with
+// WriteError, i.e.
+//
+//	router.Get("/users/:id", dispatcher.ErrorHandlerFunc(func(res http.ResponseWriter, req *http.Request) error {
+//		user, err := store.Find(dispatcher.ParamsFromContext(req.Context()).Get("id"))
+//
+//		if nil != err {
+//			return err // sql.ErrNoRows is served 404 if mapped with MapError.
+//		}
+//
+//		return dispatcher.Respond(res, req, http.StatusOK, user)
+//	}))
+type ErrorHandlerFunc func(res http.ResponseWriter, req *http.Request) error
+
+// ServeHTTP calls f(res, req), writing the error returned, if any,
+// with WriteError.
+func (f ErrorHandlerFunc) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if err := f(res, req); nil != err {
+		WriteError(res, req, err)
+	}
+}
+
+// MapError makes WriteError respond with `status` to errors matching
+// `target` in requests served by the Router, so domain errors are
+// served consistently, i.e.
+//
+//	router.MapError(sql.ErrNoRows, http.StatusNotFound)
+//	router.MapError(&PermissionError{}, http.StatusForbidden)
+//
+// Errors match if errors.Is reports they wrap `target`. A `target`
+// which is the zero value of its type, or a pointer to one, also
+// matches any error errors.As finds of its type. Mappings are tried in
+// the order they were registered and take precedence over the
+// StatusCode method of errors.
+func (r *Router) MapError(target error, status int) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.errorMappings = append(r.errorMappings[:len(r.errorMappings):len(r.errorMappings)], errorMapping{target, status})
+	return r
+}
+
+// WriteError responds to the request with `err`, using the status code
+// mapped to it with MapError, the status code reported by its
+// StatusCode method (as on BindError and ValidationError) or 500
+// Internal Server Error. The body is encoded with Respond;
+// ValidationErrors are written as their per-field list, other errors
+// as `{"error": "..."}`. Messages of server errors are replaced by the
+// status text so internal details are not leaked.
+func WriteError(res http.ResponseWriter, req *http.Request, err error) {
+	var (
+		status          = http.StatusInternalServerError
+		coded           interface{ StatusCode() int }
+		validationError *ValidationError
+	)
+
+	if mapped, ok := mappedStatus(req.Context(), err); ok {
+		status = mapped
+	} else if errors.As(err, &coded) {
+		status = coded.StatusCode()
+	}
+
+	var body any = errorBody{Message: err.Error()}
+
+	switch {
+	case http.StatusInternalServerError <= status:
+		body = errorBody{Message: http.StatusText(status)}
+	case errors.As(err, &validationError):
+		body = validationError
+	}
+
+	Respond(res, req, status, body)
+}
+
+// mappedStatus returns the status the error mappings stored in `ctx`
+// map `err` to, and whether any mapping matched.
+func mappedStatus(ctx context.Context, err error) (int, bool) {
+	mappings, _ := ctx.Value(errorMappingsContextKey{}).([]errorMapping)
+
+	for _, mapping := range mappings {
+		if errors.Is(err, mapping.target) {
+			return mapping.status, true
+		}
+
+		if !zeroError(mapping.target) {
+			continue
+		}
+
+		// Match errors of the target's type, whatever their value.
+		typed := reflect.New(reflect.TypeOf(mapping.target))
+
+		if errors.As(err, typed.Interface()) {
+			return mapping.status, true
+		}
+	}
+
+	return 0, false
+}
+
+// zeroError reports whether `err` is the zero value of its type, or a
+// pointer to one, so it stands for any error of its type.
+func zeroError(err error) bool {
+	if nil == err {
+		return false
+	}
+
+	value := reflect.ValueOf(err)
+
+	if reflect.Pointer == value.Kind() {
+		if value.IsNil() {
+			return false
+		}
+
+		value = value.Elem()
+	}
+
+	return value.IsZero()
+}
@@ -0,0 +1,61 @@
+package dispatcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// permissionError is a domain error mapped by type.
+type permissionError struct {
+	action string
+}
+
+// Error returns the error's message.
+func (e *permissionError) Error() string {
+	return "not permitted to " + e.action
+}
+
+// TestMapError ensures mapped errors returned by handlers are served
+// with their mapped status codes.
+func TestMapError(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	errOther := errors.New("other")
+
+	router := NewRouter().
+		MapError(errNoRows, http.StatusNotFound).
+		MapError(&permissionError{}, http.StatusForbidden).
+		Get("/:case", ErrorHandlerFunc(func(res http.ResponseWriter, req *http.Request) error {
+			switch ParamsFromContext(req.Context()).Get("case") {
+			case "missing":
+				return fmt.Errorf("finding user: %w", errNoRows)
+			case "forbidden":
+				return fmt.Errorf("deleting user: %w", &permissionError{"delete"})
+			case "invalid":
+				return &BindError{Status: http.StatusBadRequest, Err: errOther}
+			case "other":
+				return errOther
+			}
+
+			return nil
+		}))
+
+	tests := map[string]int{
+		"/missing":   http.StatusNotFound,
+		"/forbidden": http.StatusForbidden,
+		"/invalid":   http.StatusBadRequest,
+		"/other":     http.StatusInternalServerError,
+		"/ok":        http.StatusOK,
+	}
+
+	for path, status := range tests {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if status != res.Code {
+			t.Errorf("Expected %s to be served %d, got %d.", path, status, res.Code)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package dispatcher_test
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Applications can expose their effective route table with a
+// `-routes` flag, i.e. `./myapp -routes`, printing it and exiting
+// before starting the server.
+func ExampleDumpRoutesJSON() {
+	flags := flag.NewFlagSet("myapp", flag.ExitOnError)
+	routes := flags.Bool("routes", false, "print the route table as JSON and exit")
+	flags.Parse([]string{"-routes"})
+
+	router := dispatcher.NewRouter().
+		Get("/users/:id", http.NotFoundHandler()).Doc("Shows a user")
+
+	if *routes {
+		data, err := dispatcher.DumpRoutesJSON(router)
+
+		if nil != err {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Println(string(data))
+		return
+	}
+
+	http.ListenAndServe(":3000", router)
+
+	// Output:
+	// [
+	//   {
+	//     "method": "GET",
+	//     "path": "/users/:id",
+	//     "doc": "Shows a user"
+	//   }
+	// ]
+}
@@ -0,0 +1,153 @@
+package dispatcher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+)
+
+// DefaultExperimentCookie is the name of the cookie holding the client
+// ID used to bucket requests when ExperimentOptions.ClientID is nil.
+const DefaultExperimentCookie = "experiment_client"
+
+// ExperimentOptions configures how an Experiment identifies clients.
+type ExperimentOptions struct {
+	// ClientID, if set, returns a value identifying the client, i.e. a
+	// user ID. Otherwise clients are identified by a random ID stored
+	// in a cookie.
+	ClientID func(req *http.Request) string
+	// Cookie is the name of the cookie holding the client ID. If empty,
+	// DefaultExperimentCookie is used.
+	Cookie string
+}
+
+// Experiment is an http.Handler dispatching requests to one of several
+// named variants. Clients are bucketed deterministically by hashing
+// their ID with the experiment's name, so a client always receives the
+// same variant of an experiment while separate experiments bucket
+// clients independently.
+type Experiment struct {
+	name     string
+	variants []WeightedHandler
+	total    int
+	options  ExperimentOptions
+}
+
+// experimentContextKey is the context key the variants assigned to a
+// request are stored under.
+type experimentContextKey struct{}
+
+// NewExperiment creates an Experiment named `name` between `variants`,
+// each receiving a share of clients proportional to its Weight, i.e.
+//
+//	pricing := dispatcher.NewExperiment("pricing", dispatcher.ExperimentOptions{},
+//		dispatcher.WeightedHandler{Name: "control", Handler: PricingHandler, Weight: 50},
+//		dispatcher.WeightedHandler{Name: "annual", Handler: AnnualPricingHandler, Weight: 50},
+//	)
+//	router.Get("/pricing", pricing)
+func NewExperiment(name string, opts ExperimentOptions, variants ...WeightedHandler) *Experiment {
+	if "" == opts.Cookie {
+		opts.Cookie = DefaultExperimentCookie
+	}
+
+	experiment := &Experiment{name: name, options: opts}
+
+	for i, variant := range variants {
+		if "" == variant.Name {
+			variant.Name = strconv.Itoa(i)
+		}
+
+		if 0 < variant.Weight {
+			experiment.variants = append(experiment.variants, variant)
+			experiment.total += variant.Weight
+		}
+	}
+
+	return experiment
+}
+
+// Name returns the name of the experiment.
+func (e *Experiment) Name() string {
+	return e.name
+}
+
+// Assign returns the name of the variant the request's client is
+// assigned, or an empty string if the client can not be identified or
+// the experiment has no variants.
+func (e *Experiment) Assign(req *http.Request) string {
+	if variant, ok := e.assign(e.clientID(req)); ok {
+		return variant.Name
+	}
+
+	return ""
+}
+
+// ServeHTTP serves the request with the variant assigned to its
+// client, storing the variant's name in the request's context where it
+// can be retrieved with VariantFromContext.
+func (e *Experiment) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	id := e.clientID(req)
+
+	if "" == id && nil == e.options.ClientID {
+		id = newClientID()
+		http.SetCookie(res, &http.Cookie{Name: e.options.Cookie, Value: id, Path: "/", HttpOnly: true})
+	}
+
+	variant, ok := e.assign(id)
+
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+
+	assigned := map[string]string{e.name: variant.Name}
+
+	if previous, ok := req.Context().Value(experimentContextKey{}).(map[string]string); ok {
+		for experiment, name := range previous {
+			if _, found := assigned[experiment]; !found {
+				assigned[experiment] = name
+			}
+		}
+	}
+
+	variant.Handler.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), experimentContextKey{}, assigned)))
+}
+
+// assign returns the variant assigned to the client `id`.
+func (e *Experiment) assign(id string) (WeightedHandler, bool) {
+	if "" == id || 0 == e.total {
+		return WeightedHandler{}, false
+	}
+
+	return pickWeighted(e.variants, hashBucket(e.name+":"+id, e.total)), true
+}
+
+// clientID returns the ID identifying the request's client.
+func (e *Experiment) clientID(req *http.Request) string {
+	if nil != e.options.ClientID {
+		return e.options.ClientID(req)
+	}
+
+	if cookie, err := req.Cookie(e.options.Cookie); nil == err {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+// newClientID generates a random client ID.
+func newClientID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// VariantFromContext returns the name of the variant of the experiment
+// `experiment` assigned to the request whose context is `ctx`.
+func VariantFromContext(ctx context.Context, experiment string) (string, bool) {
+	assigned, _ := ctx.Value(experimentContextKey{}).(map[string]string)
+	variant, ok := assigned[experiment]
+	return variant, ok
+}
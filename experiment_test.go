@@ -0,0 +1,49 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestExperimentAssignment ensures clients are consistently assigned a
+// variant which is exposed through the request's context.
+func TestExperimentAssignment(t *testing.T) {
+	var assigned []string
+
+	record := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		variant, _ := VariantFromContext(req.Context(), "pricing")
+		assigned = append(assigned, variant)
+	})
+
+	experiment := NewExperiment("pricing", ExperimentOptions{},
+		WeightedHandler{Name: "control", Handler: record, Weight: 1},
+		WeightedHandler{Name: "annual", Handler: record, Weight: 1},
+	)
+
+	router := NewRouter().Get("/pricing", experiment)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/pricing"))
+	cookies := res.Result().Cookies()
+
+	if 1 != len(cookies) || DefaultExperimentCookie != cookies[0].Name {
+		t.Fatalf("Expected client ID cookie to be set, got %v.", cookies)
+	}
+
+	for i := 0; i < 5; i++ {
+		req := generateHttpRequest(GET, "/pricing")
+		req.AddCookie(cookies[0])
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if i == 0 && experiment.Assign(req) != assigned[0] {
+			t.Errorf("Expected Assign to return %q, got %q.", assigned[0], experiment.Assign(req))
+		}
+	}
+
+	for _, variant := range assigned {
+		if "" == variant || assigned[0] != variant {
+			t.Fatalf("Expected client to be consistently assigned a variant, got %v.", assigned)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dispatcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultAssetCacheControl is the `Cache-Control` header of responses
+// served by Favicon and Robots.
+const DefaultAssetCacheControl = "public, max-age=86400"
+
+// assetHandler serves a small asset held in memory, loading it on the
+// first request it is served for.
+type assetHandler struct {
+	mu sync.Mutex
+	// load returns the asset's content, and its content type if known.
+	load        func() ([]byte, string, error)
+	loaded      bool
+	content     []byte
+	contentType string
+	etag        string
+	modTime     time.Time
+}
+
+// Favicon registers Routes for HTTP GET and HEAD requests to
+// `/favicon.ico` serving the icon found at `filename`, i.e.
+//
+//	router.Favicon("./static/favicon.ico")
+//
+// The icon is read on the first request for it and kept in memory, and
+// served with its content type, an `ETag` and a `Cache-Control` header
+// so browsers don't request it on every page. If the icon can not be
+// read the requests are served 404 until it can.
+func (r *Router) Favicon(filename string) *Router {
+	return r.getAndHead("/favicon.ico", &assetHandler{load: func() ([]byte, string, error) {
+		data, err := os.ReadFile(filename)
+		return data, mime.TypeByExtension(filepath.Ext(filename)), err
+	}})
+}
+
+// FaviconData behaves as Favicon, serving the icon `data`, i.e. one
+// embedded with the embed package. Its content type is detected from
+// the data.
+func (r *Router) FaviconData(data []byte) *Router {
+	return r.getAndHead("/favicon.ico", newAsset(data, ""))
+}
+
+// Robots registers Routes for HTTP GET and HEAD requests to
+// `/robots.txt` serving `content` as plain text, i.e.
+//
+//	router.Robots("User-agent: *\nDisallow: /admin/\n")
+//
+// Responses carry an `ETag` and a `Cache-Control` header as those of
+// Favicon do.
+func (r *Router) Robots(content string) *Router {
+	return r.getAndHead("/robots.txt", newAsset([]byte(content), "text/plain; charset=utf-8"))
+}
+
+// newAsset creates an assetHandler serving `content` with
+// `contentType`, detected from the content if empty.
+func newAsset(content []byte, contentType string) *assetHandler {
+	return &assetHandler{load: func() ([]byte, string, error) {
+		return content, contentType, nil
+	}}
+}
+
+// ServeHTTP serves the asset, loading it first if it wasn't.
+func (a *assetHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	a.mu.Lock()
+
+	if !a.loaded {
+		content, contentType, err := a.load()
+
+		if nil != err {
+			a.mu.Unlock()
+			http.NotFound(res, req)
+			return
+		}
+
+		if "" == contentType {
+			contentType = http.DetectContentType(content)
+		}
+
+		sum := sha256.Sum256(content)
+		a.content, a.contentType, a.modTime = content, contentType, time.Now()
+		a.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+		a.loaded = true
+	}
+
+	a.mu.Unlock()
+
+	header := res.Header()
+	header.Set("Content-Type", a.contentType)
+	header.Set("Cache-Control", DefaultAssetCacheControl)
+	header.Set("ETag", a.etag)
+
+	http.ServeContent(res, req, "", a.modTime, bytes.NewReader(a.content))
+}
@@ -0,0 +1,68 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRobots ensures robots.txt is served as plain text, cached, and
+// answers conditional requests.
+func TestRobots(t *testing.T) {
+	router := NewRouter().Robots("User-agent: *\nDisallow: /admin/\n")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/robots.txt"))
+
+	if "User-agent: *\nDisallow: /admin/\n" != res.Body.String() || "text/plain; charset=utf-8" != res.Header().Get("Content-Type") {
+		t.Fatalf("Expected robots.txt to be served as text, got %q as %q.", res.Body.String(), res.Header().Get("Content-Type"))
+	}
+
+	if DefaultAssetCacheControl != res.Header().Get("Cache-Control") {
+		t.Errorf("Expected robots.txt to be cacheable, got %q.", res.Header().Get("Cache-Control"))
+	}
+
+	req := generateHttpRequest(HEAD, "/robots.txt")
+	req.Header.Set("If-None-Match", res.Header().Get("ETag"))
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if http.StatusNotModified != res.Code {
+		t.Errorf("Expected conditional HEAD request to be not modified, got %d.", res.Code)
+	}
+}
+
+// TestFavicon ensures icons are typed from their data or extension,
+// and missing icons are served 404 until they exist.
+func TestFavicon(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	res := httptest.NewRecorder()
+	NewRouter().FaviconData(png).ServeHTTP(res, generateHttpRequest(GET, "/favicon.ico"))
+
+	if "image/png" != res.Header().Get("Content-Type") || string(png) != res.Body.String() {
+		t.Errorf("Expected the icon to be served as image/png, got %q.", res.Header().Get("Content-Type"))
+	}
+
+	filename := filepath.Join(t.TempDir(), "favicon.png")
+	router := NewRouter().Favicon(filename)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/favicon.ico"))
+
+	if http.StatusNotFound != res.Code {
+		t.Errorf("Expected a missing icon to be served 404, got %d.", res.Code)
+	}
+
+	if err := os.WriteFile(filename, png, 0o644); nil != err {
+		t.Fatal(err)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/favicon.ico"))
+
+	if http.StatusOK != res.Code || "image/png" != res.Header().Get("Content-Type") {
+		t.Errorf("Expected the icon to be served once written, got %d as %q.", res.Code, res.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,95 @@
+package dispatcher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// File registers Routes matching the given path argument for HTTP GET
+// and HEAD requests which serve the single file found at `filename`,
+// i.e.
+//
+//	router.File("/favicon.ico", "./static/favicon.ico")
+//
+// Responses carry `ETag` and `Last-Modified` headers so clients can
+// revalidate the file with conditional requests, and Range requests
+// are supported.
+func (r *Router) File(path, filename string) *Router {
+	dir, name := filepath.Split(filename)
+
+	if "" == dir {
+		dir = "."
+	}
+
+	return r.FileFS(path, os.DirFS(dir), name)
+}
+
+// FileFS behaves as File, serving the file `name` found within the
+// file system `fsys`.
+func (r *Router) FileFS(path string, fsys fs.FS, name string) *Router {
+	return r.getAndHead(path, fileHandler{fsys, name})
+}
+
+// getAndHead registers Routes matching `path` for HTTP GET and HEAD
+// requests served by `handler`, both of which are current.
+func (r *Router) getAndHead(path string, handler http.Handler) *Router {
+	r.AddHandler(GET, path, handler)
+	created := r.current
+	r.AddHandler(HEAD, path, handler)
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.current = append(created, r.current...)
+	return r
+}
+
+// fileHandler serves a single file from a file system.
+type fileHandler struct {
+	fsys fs.FS
+	name string
+}
+
+// ServeHTTP writes the file to the response, responding with a 404 if
+// it can not be opened.
+func (f fileHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	file, err := f.fsys.Open(f.name)
+
+	if nil != err {
+		http.NotFound(res, req)
+		return
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+
+	if nil != err || stat.IsDir() {
+		http.NotFound(res, req)
+		return
+	}
+
+	content, ok := file.(io.ReadSeeker)
+
+	if !ok {
+		data, err := io.ReadAll(file)
+
+		if nil != err {
+			http.Error(res, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		content = bytes.NewReader(data)
+	}
+
+	if !stat.ModTime().IsZero() {
+		res.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, stat.Size(), stat.ModTime().UnixNano()))
+	}
+
+	http.ServeContent(res, req, stat.Name(), stat.ModTime(), content)
+}
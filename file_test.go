@@ -0,0 +1,39 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestFileFS ensures Routes registered with FileFS serve the file
+// for GET and HEAD requests and answer conditional requests.
+func TestFileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/robots.txt": &fstest.MapFile{Data: []byte("User-agent: *"), ModTime: time.Unix(1, 0)},
+	}
+
+	router := NewRouter().FileFS("/robots.txt", fsys, "static/robots.txt")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/robots.txt"))
+
+	if "User-agent: *" != res.Body.String() {
+		t.Fatalf("Expected file to be served, got %q.", res.Body.String())
+	}
+
+	req := generateHttpRequest(HEAD, "/robots.txt")
+	req.Header.Set("If-None-Match", res.Header().Get("ETag"))
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if http.StatusNotModified != res.Code {
+		t.Errorf("Expected conditional HEAD request to be not modified, got %d.", res.Code)
+	}
+
+	if 2 != len(router.Routes()) {
+		t.Errorf("Expected GET and HEAD Routes to be registered, found %d.", len(router.Routes()))
+	}
+}
@@ -0,0 +1,295 @@
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Forwarded is what the proxies a request passed through reported
+// about the client it came from.
+type Forwarded struct {
+	// For is the client's address, usually an IP address. Proxies may
+	// report an obfuscated identifier or `unknown` instead.
+	For string
+	// Proto is the scheme the client used, `http` or `https`.
+	Proto string
+	// Host is the Host the client requested.
+	Host string
+}
+
+// ForwardedOptions configures ResolveForwarded.
+type ForwardedOptions struct {
+	// TrustedProxies are the addresses and CIDR ranges of the proxies
+	// whose forwarding headers are trusted, i.e. "10.0.0.0/8".
+	TrustedProxies []string
+}
+
+// forwardedContextKey is the context key the Forwarded of a request is
+// stored under.
+type forwardedContextKey struct{}
+
+// trustedProxies matches the addresses of trusted proxies.
+type trustedProxies []netip.Prefix
+
+// ResolveForwarded returns a Plugin resolving the client of each
+// request from the RFC 7239 Forwarded header, or else the
+// X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Host and X-Real-IP
+// headers, i.e.
+//
+//	resolve, err := dispatcher.ResolveForwarded(dispatcher.ForwardedOptions{
+//		TrustedProxies: []string{"10.0.0.0/8"},
+//	})
+//	router.RegisterPlugin(resolve)
+//
+// Headers are only trusted when the request comes from a trusted
+// proxy, and the client is the last address they list that is not one.
+// The result is read with RealIP, Scheme and Host, and by
+// ForwardedFromContext. An error is returned if a trusted proxy is not
+// an address or CIDR range.
+func ResolveForwarded(opts ForwardedOptions) (PluginFunc, error) {
+	trusted := make(trustedProxies, 0, len(opts.TrustedProxies))
+
+	for _, proxy := range opts.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+
+		if nil != err {
+			addr, addrErr := netip.ParseAddr(proxy)
+
+			if nil != addrErr {
+				return nil, err
+			}
+
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		trusted = append(trusted, prefix.Masked())
+	}
+
+	return func(req *http.Request) *http.Request {
+		forwarded := trusted.resolve(req)
+		return req.WithContext(context.WithValue(req.Context(), forwardedContextKey{}, forwarded))
+	}, nil
+}
+
+// ForwardedFromContext returns the Forwarded ResolveForwarded resolved
+// for the request whose context is `ctx`, and whether it ran.
+func ForwardedFromContext(ctx context.Context) (Forwarded, bool) {
+	forwarded, ok := ctx.Value(forwardedContextKey{}).(Forwarded)
+	return forwarded, ok
+}
+
+// RealIP returns the address of the client `req` came from, as
+// resolved by ResolveForwarded, or else the host of its RemoteAddr.
+func RealIP(req *http.Request) string {
+	if forwarded, ok := ForwardedFromContext(req.Context()); ok && "" != forwarded.For {
+		return forwarded.For
+	}
+
+	return remoteHost(req)
+}
+
+// Scheme returns the scheme the client used for `req`, as resolved by
+// ResolveForwarded, or else `https` if it was received over TLS and
+// `http` otherwise.
+func Scheme(req *http.Request) string {
+	if forwarded, ok := ForwardedFromContext(req.Context()); ok && "" != forwarded.Proto {
+		return forwarded.Proto
+	}
+
+	if nil != req.TLS {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Host returns the Host the client requested, as resolved by
+// ResolveForwarded, or else the Host of `req`.
+func Host(req *http.Request) string {
+	if forwarded, ok := ForwardedFromContext(req.Context()); ok && "" != forwarded.Host {
+		return forwarded.Host
+	}
+
+	return req.Host
+}
+
+// AbsoluteURL returns `path` as an absolute URL on the scheme and Host
+// the client used for `req`, i.e. for Location headers behind a TLS
+// terminating proxy.
+func AbsoluteURL(req *http.Request, path string) string {
+	return Scheme(req) + "://" + Host(req) + path
+}
+
+// AbsoluteURL builds the URL of the Route named `name` as URL does, as
+// an absolute URL on the scheme and Host the client used for `req`.
+func (r *Router) AbsoluteURL(req *http.Request, name string, params ...string) (string, error) {
+	path, err := r.URL(name, params...)
+
+	if nil != err {
+		return "", err
+	}
+
+	return AbsoluteURL(req, path), nil
+}
+
+// resolve returns the Forwarded of `req`.
+func (t trustedProxies) resolve(req *http.Request) Forwarded {
+	peer := remoteHost(req)
+	resolved := Forwarded{For: peer}
+
+	if !t.trusts(peer) {
+		return resolved
+	}
+
+	var hops []Forwarded
+
+	if values := req.Header.Values("Forwarded"); 0 < len(values) {
+		hops = parseForwarded(strings.Join(values, ","))
+	} else if values := req.Header.Values("X-Forwarded-For"); 0 < len(values) {
+		for _, node := range strings.Split(strings.Join(values, ","), ",") {
+			hops = append(hops, Forwarded{For: forwardedNode(node)})
+		}
+
+		last := &hops[len(hops)-1]
+		last.Proto = lastHeaderValue(req.Header, "X-Forwarded-Proto")
+		last.Host = lastHeaderValue(req.Header, "X-Forwarded-Host")
+	} else if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); "" != ip {
+		hops = []Forwarded{{For: ip}}
+	}
+
+	// Each hop describes the request a proxy received; hops are
+	// trusted from the nearest proxy up to the first client that is
+	// not a trusted proxy.
+	for i := len(hops) - 1; 0 <= i; i-- {
+		hop := hops[i]
+
+		if "" != hop.For {
+			resolved.For = hop.For
+		}
+
+		if proto := strings.ToLower(hop.Proto); "http" == proto || "https" == proto {
+			resolved.Proto = proto
+		}
+
+		if validForwardedHost(hop.Host) {
+			resolved.Host = hop.Host
+		}
+
+		if !t.trusts(hop.For) {
+			break
+		}
+	}
+
+	return resolved
+}
+
+// trusts reports whether `address` is that of a trusted proxy.
+func (t trustedProxies) trusts(address string) bool {
+	addr, err := netip.ParseAddr(address)
+
+	if nil != err {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseForwarded parses the elements of a Forwarded header, one per
+// hop.
+func parseForwarded(header string) []Forwarded {
+	var hops []Forwarded
+
+	for _, element := range splitQuoted(header, ',') {
+		var hop Forwarded
+
+		for _, pair := range splitQuoted(element, ';') {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			value = strings.Trim(value, `"`)
+
+			switch strings.ToLower(key) {
+			case "for":
+				hop.For = forwardedNode(value)
+			case "proto":
+				hop.Proto = value
+			case "host":
+				hop.Host = value
+			}
+		}
+
+		hops = append(hops, hop)
+	}
+
+	return hops
+}
+
+// splitQuoted splits `s` around `separator`, ignoring separators
+// within quoted strings.
+func splitQuoted(s string, separator byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			i++
+		case separator:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// forwardedNode returns the address of the node `node`, without its
+// port or brackets, i.e. `2001:db8::1` for `[2001:db8::1]:4711`.
+func forwardedNode(node string) string {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+
+	if addrPort, err := netip.ParseAddrPort(node); nil == err {
+		return addrPort.Addr().String()
+	}
+
+	return strings.Trim(node, "[]")
+}
+
+// lastHeaderValue returns the last of the comma separated values of
+// the header `name`, set by the nearest proxy.
+func lastHeaderValue(header http.Header, name string) string {
+	values := strings.Split(strings.Join(header.Values(name), ","), ",")
+	return strings.TrimSpace(values[len(values)-1])
+}
+
+// validForwardedHost reports whether `host` may be used as a Host.
+func validForwardedHost(host string) bool {
+	return "" != host && !strings.ContainsAny(host, "/\\ \t@?#")
+}
+
+// remoteHost returns the host of the RemoteAddr of `req`.
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+	if nil != err {
+		return req.RemoteAddr
+	}
+
+	return host
+}
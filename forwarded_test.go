@@ -0,0 +1,75 @@
+package dispatcher
+
+import (
+	"testing"
+)
+
+// TestResolveForwarded ensures forwarding headers are only trusted
+// from trusted proxies, up to the first untrusted client.
+func TestResolveForwarded(t *testing.T) {
+	resolve, err := ResolveForwarded(ForwardedOptions{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote, header, value string
+		expected              Forwarded
+	}{
+		{"192.0.2.1:1234", "Forwarded", "for=198.51.100.7;proto=https", Forwarded{For: "192.0.2.1"}},
+		{"10.0.0.1:1234", "", "", Forwarded{For: "10.0.0.1"}},
+		{"10.0.0.1:1234", "Forwarded", `for=198.51.100.7;proto=https;host=example.com, for="[2001:db8::1]:4711";proto=http`, Forwarded{"198.51.100.7", "https", "example.com"}},
+		{"10.0.0.1:1234", "Forwarded", "for=203.0.113.9, for=198.51.100.7;proto=https, for=10.0.0.2", Forwarded{"198.51.100.7", "https", ""}},
+		{"10.0.0.1:1234", "Forwarded", `for=_hidden;host="a b"`, Forwarded{For: "_hidden"}},
+		{"10.0.0.1:1234", "X-Forwarded-For", "203.0.113.9, 198.51.100.7", Forwarded{For: "198.51.100.7"}},
+		{"10.0.0.1:1234", "X-Real-IP", "198.51.100.7", Forwarded{For: "198.51.100.7"}},
+	}
+
+	for _, test := range tests {
+		req := generateHttpRequest(GET, "/")
+		req.RemoteAddr = test.remote
+
+		if "" != test.header {
+			req.Header.Set(test.header, test.value)
+		}
+
+		forwarded, _ := ForwardedFromContext(resolve(req).Context())
+
+		if test.expected != forwarded {
+			t.Errorf("Expected %+v for %s: %s, got %+v.", test.expected, test.header, test.value, forwarded)
+		}
+	}
+
+	if _, err := ResolveForwarded(ForwardedOptions{TrustedProxies: []string{"proxy"}}); nil == err {
+		t.Error("Expected an invalid trusted proxy to be an error.")
+	}
+}
+
+// TestAbsoluteURL ensures URLs are built on the scheme and Host the
+// client used.
+func TestAbsoluteURL(t *testing.T) {
+	resolve, _ := ResolveForwarded(ForwardedOptions{TrustedProxies: []string{"10.0.0.1"}})
+	router := NewRouter()
+	router.Get("/articles/:id", nil).Name("article")
+
+	req := generateHttpRequest(GET, "/")
+	req.Host, req.RemoteAddr = "internal:8080", "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "example.com")
+
+	if url, _ := router.AbsoluteURL(req, "article", "1"); "http://internal:8080/articles/1" != url {
+		t.Errorf("Expected the request's own URL without ResolveForwarded, got %q.", url)
+	}
+
+	req = resolve(req)
+
+	if url, _ := router.AbsoluteURL(req, "article", "1"); "https://example.com/articles/1" != url {
+		t.Errorf("Expected the forwarded URL, got %q.", url)
+	}
+
+	if ip := RealIP(req); "198.51.100.7" != ip {
+		t.Errorf("Expected the forwarded client address, got %q.", ip)
+	}
+}
@@ -0,0 +1,69 @@
+package dispatcher
+
+import (
+	"net/http"
+)
+
+// DefaultHeaders sets headers added to every response the Router
+// serves, including responses to requests matching no Route, i.e.
+// security or caching policies:
+//
+//	router.DefaultHeaders(http.Header{"X-Content-Type-Options": {"nosniff"}})
+//
+// Headers are set before any Middleware runs, so Middleware, Route
+// headers and handlers can replace them. Calling DefaultHeaders again
+// replaces the headers set before.
+func (r *Router) DefaultHeaders(header http.Header) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.headers = canonicalHeader(header)
+	return r
+}
+
+// Headers sets headers added to the responses of the Routes created by
+// the most recent registration call, i.e. a `Cache-Control` policy:
+//
+//	router.Get("/catalog", CatalogHandler).Headers(http.Header{"Cache-Control": {"public, max-age=300"}})
+//
+// Headers are set once a request matched the Route, replacing the
+// Router's DefaultHeaders, before RouteMiddleware and the handler run.
+func (r *Router) Headers(header http.Header) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	header = canonicalHeader(header)
+
+	for _, route := range r.current {
+		route.headers = header
+	}
+
+	return r
+}
+
+// canonicalHeader copies `header`, canonicalizing its keys.
+func canonicalHeader(header http.Header) http.Header {
+	canonical := make(http.Header, len(header))
+
+	for key, values := range header {
+		for _, value := range values {
+			canonical.Add(key, value)
+		}
+	}
+
+	return canonical
+}
+
+// setHeaders sets `header` on the response `res`, replacing the values
+// of headers already set.
+func setHeaders(res http.ResponseWriter, header http.Header) {
+	if 0 == len(header) {
+		return
+	}
+
+	target := res.Header()
+
+	for key, values := range header {
+		target[key] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,36 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHeaders ensures default and Route headers are set on responses,
+// Route headers replacing defaults and handlers replacing both.
+func TestHeaders(t *testing.T) {
+	router := NewRouter().
+		DefaultHeaders(http.Header{"x-content-type-options": {"nosniff"}, "Cache-Control": {"no-store"}}).
+		Get("/catalog", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})).
+		Headers(http.Header{"Cache-Control": {"public", "max-age=300"}}).
+		Get("/private", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Cache-Control", "private")
+		}))
+
+	for path, expected := range map[string][]string{
+		"/catalog": {"public", "max-age=300"},
+		"/private": {"private"},
+		"/missing": {"no-store"},
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if cache := res.Header().Values("Cache-Control"); len(expected) != len(cache) || expected[0] != cache[0] {
+			t.Errorf("Expected %s to be served Cache-Control %v, got %v.", path, expected, cache)
+		}
+
+		if "nosniff" != res.Header().Get("X-Content-Type-Options") {
+			t.Errorf("Expected %s to be served default headers.", path)
+		}
+	}
+}
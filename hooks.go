@@ -0,0 +1,130 @@
+package dispatcher
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestHook is called with each request served by a Router, after
+// its Plugins have run.
+type RequestHook func(req *http.Request)
+
+// RouteMatchedHook is called when a request matches a Route, with the
+// parameters captured.
+type RouteMatchedHook func(req *http.Request, route *Route, params Params)
+
+// NotFoundHook is called when no Middleware or Route serves a request
+// and it is passed to the not found handler.
+type NotFoundHook func(req *http.Request)
+
+// PanicHook is called with the value recovered when serving a request
+// panics.
+type PanicHook func(req *http.Request, recovered any)
+
+// ResponseHook is called once a request has been served, with the
+// status written and the time taken.
+type ResponseHook func(req *http.Request, status int, duration time.Duration)
+
+// routerHooks holds the lifecycle hooks registered with a Router.
+type routerHooks struct {
+	request      []RequestHook
+	routeMatched []RouteMatchedHook
+	notFound     []NotFoundHook
+	panic        []PanicHook
+	response     []ResponseHook
+}
+
+// active reports whether any hook that observes a whole request is
+// registered.
+func (h *routerHooks) active() bool {
+	return 0 < len(h.request) || 0 < len(h.panic) || 0 < len(h.response)
+}
+
+// OnRequest registers a hook called with each request served.
+func (r *Router) OnRequest(hook RequestHook) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.hooks.request = append(r.hooks.request, hook)
+	return r
+}
+
+// OnRouteMatched registers a hook called when a request matches a
+// Route, i.e. to label metrics with the Route's path.
+func (r *Router) OnRouteMatched(hook RouteMatchedHook) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.hooks.routeMatched = append(r.hooks.routeMatched, hook)
+	return r
+}
+
+// OnNotFound registers a hook called when a request is passed to the
+// Router's not found handler.
+func (r *Router) OnNotFound(hook NotFoundHook) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.hooks.notFound = append(r.hooks.notFound, hook)
+	return r
+}
+
+// OnPanic registers a hook called when serving a request panics. The
+// panic continues once the hooks have run, so it is still handled (and
+// logged) by the http package. Panics with http.ErrAbortHandler, used
+// to abort responses deliberately, do not call the hooks.
+func (r *Router) OnPanic(hook PanicHook) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.hooks.panic = append(r.hooks.panic, hook)
+	return r
+}
+
+// OnResponse registers a hook called once each request has been
+// served, with the status code written (200 if the handler wrote
+// nothing) and the time taken.
+func (r *Router) OnResponse(hook ResponseHook) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.hooks.response = append(r.hooks.response, hook)
+	return r
+}
+
+// serveWithHooks serves `req` with `handler`, calling the request,
+// panic and response hooks around it.
+func serveWithHooks(hooks routerHooks, handler http.Handler, res http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	writer := NewResponseWriter(res)
+
+	for _, hook := range hooks.request {
+		hook(req)
+	}
+
+	if 0 < len(hooks.panic) {
+		defer func() {
+			if recovered := recover(); nil != recovered {
+				if http.ErrAbortHandler != recovered {
+					for _, hook := range hooks.panic {
+						hook(req, recovered)
+					}
+				}
+
+				panic(recovered)
+			}
+		}()
+	}
+
+	handler.ServeHTTP(writer, req)
+
+	status := writer.Status()
+
+	if 0 == status {
+		status = http.StatusOK
+	}
+
+	for _, hook := range hooks.response {
+		hook(req, status, time.Since(start))
+	}
+}
@@ -0,0 +1,63 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLifecycleHooks ensures hooks are called at each point of a
+// request's lifecycle.
+func TestLifecycleHooks(t *testing.T) {
+	var events []string
+
+	router := NewRouter().
+		OnRequest(func(req *http.Request) {
+			events = append(events, "request "+req.URL.Path)
+		}).
+		OnRouteMatched(func(req *http.Request, route *Route, params Params) {
+			events = append(events, "matched "+route.Info().Path+" "+params.Get("id"))
+		}).
+		OnNotFound(func(req *http.Request) {
+			events = append(events, "not found")
+		}).
+		OnResponse(func(req *http.Request, status int, duration time.Duration) {
+			events = append(events, "response "+http.StatusText(status))
+		}).
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusAccepted)
+		}))
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/7"))
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/missing"))
+
+	expected := "request /users/7|matched /users/:id 7|response Accepted|request /missing|not found|response Not Found"
+
+	if actual := strings.Join(events, "|"); expected != actual {
+		t.Errorf("Expected %q, got %q.", expected, actual)
+	}
+}
+
+// TestPanicHook ensures panic hooks are called before the panic
+// continues.
+func TestPanicHook(t *testing.T) {
+	var recovered any
+
+	router := NewRouter().
+		OnPanic(func(req *http.Request, value any) {
+			recovered = value
+		}).
+		Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}))
+
+	defer func() {
+		if value := recover(); "boom" != value || "boom" != recovered {
+			t.Errorf("Expected the hook to see the panic and the panic to continue, got %v and %v.", recovered, value)
+		}
+	}()
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+}
@@ -0,0 +1,157 @@
+package dispatcher
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+)
+
+// Deployment names a handler set of a BlueGreen.
+type Deployment string
+
+// Deployments of a BlueGreen.
+const (
+	Blue  Deployment = "blue"
+	Green Deployment = "green"
+)
+
+// ErrUnknownDeployment is returned when activating a Deployment other
+// than Blue or Green.
+var ErrUnknownDeployment = errors.New("dispatcher: unknown deployment")
+
+// HotSwap is an http.Handler delegating requests to a handler which can
+// be replaced atomically while serving, i.e. to reload a Router
+// without restarting the server. Requests in flight when the handler is
+// swapped complete with the previous handler.
+type HotSwap struct {
+	handler atomic.Pointer[http.Handler]
+}
+
+// NewHotSwap creates a HotSwap delegating to `handler`.
+func NewHotSwap(handler http.Handler) *HotSwap {
+	swap := new(HotSwap)
+	swap.handler.Store(&handler)
+	return swap
+}
+
+// Swap replaces the handler requests are delegated to, returning the
+// previous handler.
+func (s *HotSwap) Swap(handler http.Handler) http.Handler {
+	return *s.handler.Swap(&handler)
+}
+
+// Handler returns the handler requests are currently delegated to.
+func (s *HotSwap) Handler() http.Handler {
+	return *s.handler.Load()
+}
+
+// ServeHTTP serves the request with the current handler.
+func (s *HotSwap) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	s.Handler().ServeHTTP(res, req)
+}
+
+// BlueGreen is an http.Handler serving requests with one of two handler
+// sets, i.e. two Routers registering the same routes with different
+// implementations, allowing traffic to be cut over between them
+// instantly. Blue is active initially.
+type BlueGreen struct {
+	sync.Mutex
+	swap     *HotSwap
+	handlers map[Deployment]http.Handler
+	active   Deployment
+}
+
+// NewBlueGreen creates a BlueGreen serving requests with `blue` until
+// switched to `green`.
+func NewBlueGreen(blue, green http.Handler) *BlueGreen {
+	return &BlueGreen{
+		swap:     NewHotSwap(blue),
+		handlers: map[Deployment]http.Handler{Blue: blue, Green: green},
+		active:   Blue,
+	}
+}
+
+// Active returns the Deployment serving requests.
+func (b *BlueGreen) Active() Deployment {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.active
+}
+
+// Activate switches traffic to the Deployment `deployment`.
+func (b *BlueGreen) Activate(deployment Deployment) error {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.activate(deployment)
+}
+
+// Switch switches traffic to the inactive Deployment, returning it.
+// Concurrent calls each switch once, so two calls restore the
+// Deployment active before them.
+func (b *BlueGreen) Switch() Deployment {
+	b.Lock()
+	defer b.Unlock()
+
+	next := Green
+
+	if Green == b.active {
+		next = Blue
+	}
+
+	b.activate(next)
+	return next
+}
+
+// activate switches traffic to `deployment`. The BlueGreen must be
+// locked.
+func (b *BlueGreen) activate(deployment Deployment) error {
+	handler, ok := b.handlers[deployment]
+
+	if !ok {
+		return ErrUnknownDeployment
+	}
+
+	b.swap.Swap(handler)
+	b.active = deployment
+
+	return nil
+}
+
+// SwitchOnSignal switches traffic to the inactive Deployment whenever
+// the process receives one of `signals`, i.e. syscall.SIGUSR2. The
+// function returned stops listening for the signals.
+func (b *BlueGreen) SwitchOnSignal(signals ...os.Signal) (stop func()) {
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(received, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-received:
+				b.Switch()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(done)
+		})
+	}
+}
+
+// ServeHTTP serves the request with the active Deployment.
+func (b *BlueGreen) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	b.swap.ServeHTTP(res, req)
+}
@@ -0,0 +1,57 @@
+package dispatcher
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestBlueGreenSwitch ensures requests are served by the active
+// Deployment's handler set.
+func TestBlueGreenSwitch(t *testing.T) {
+	blue, green := 0, 0
+
+	deployments := NewBlueGreen(
+		NewRouter().Get("/", generateCountableHandler(&blue)),
+		NewRouter().Get("/", generateCountableHandler(&green)),
+	)
+
+	deployments.ServeHTTP(nil, generateHttpRequest(GET, "/"))
+
+	if Green != deployments.Switch() || Green != deployments.Active() {
+		t.Fatalf("Expected green Deployment to be active, was %q.", deployments.Active())
+	}
+
+	deployments.ServeHTTP(nil, generateHttpRequest(GET, "/"))
+
+	if 1 != blue || 1 != green {
+		t.Errorf("Expected each Deployment to serve 1 request, got %d and %d.", blue, green)
+	}
+
+	if ErrUnknownDeployment != deployments.Activate("red") {
+		t.Error("Expected activating an unknown Deployment to fail.")
+	}
+}
+
+// TestBlueGreenConcurrentSwitch ensures concurrent switches each switch
+// traffic once.
+func TestBlueGreenConcurrentSwitch(t *testing.T) {
+	deployments := NewBlueGreen(http.NotFoundHandler(), http.NotFoundHandler())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			deployments.Switch()
+		}()
+	}
+
+	wg.Wait()
+
+	if Blue != deployments.Active() {
+		t.Errorf("Expected blue Deployment to be active after an even number of switches, was %q.", deployments.Active())
+	}
+}
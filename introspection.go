@@ -0,0 +1,118 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a Route registered with a Router and is
+// used when inspecting the Router's route table.
+type RouteInfo struct {
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Name       string              `json:"name,omitempty"`
+	Doc        string              `json:"doc,omitempty"`
+	Deprecated bool                `json:"deprecated,omitempty"`
+	Meta       map[string][]string `json:"meta,omitempty"`
+}
+
+// Info returns a RouteInfo describing the Route.
+func (route *Route) Info() RouteInfo {
+	return RouteInfo{
+		Method:     route.method,
+		Path:       route.path,
+		Name:       route.name,
+		Doc:        route.doc,
+		Deprecated: nil != route.deprecation,
+		Meta:       route.meta,
+	}
+}
+
+// Doc sets a human readable description on the Routes created by
+// the most recent registration call, i.e.
+//
+//	router.Post("/users", CreateUserHandler).Doc("Creates a new user; requires admin scope")
+//
+// The description is carried through Routes, PrintRoutes and the
+// `routes` endpoint of MountPprof so the route table documents itself.
+func (r *Router) Doc(doc string) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, route := range r.current {
+		route.doc = doc
+	}
+
+	return r
+}
+
+// Routes returns a RouteInfo for each Route registered with the
+// Router, ordered by path and then by method.
+func (r *Router) Routes() (infos []RouteInfo) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, routes := range r.dispatcher {
+		for _, registered := range routes {
+			infos = append(infos, registered.Route.Info())
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+
+		return infos[i].Method < infos[j].Method
+	})
+
+	return
+}
+
+// Handler returns the first handler registered for `method` requests
+// with the path `path`, exactly as it was registered (i.e.
+// "/users/:id"), and whether there is one.
+func (r *Router) Handler(method, path string) (http.Handler, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, registered := range r.dispatcher[strings.ToUpper(method)] {
+		if path == registered.Route.path {
+			return registered.Handler, true
+		}
+	}
+
+	return nil, false
+}
+
+// PrintRoutes writes the Router's route table to `w` as aligned
+// columns of method, path, and description.
+func (r *Router) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, info := range r.Routes() {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", info.Method, info.Path, info.Doc); nil != err {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// DumpRoutesJSON returns the Router's route table encoded as an
+// indented JSON array. The output is stable between runs so it can
+// be diffed between versions or fed to gateway configuration.
+func DumpRoutesJSON(router *Router) ([]byte, error) {
+	routes := router.Routes()
+
+	if nil == routes {
+		routes = []RouteInfo{}
+	}
+
+	return json.MarshalIndent(routes, "", "  ")
+}
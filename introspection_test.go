@@ -0,0 +1,81 @@
+package dispatcher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestRouteDoc ensures documentation set with the Router's Doc
+// method is attached to the most recently registered Route.
+func TestRouteDoc(t *testing.T) {
+	routes := NewRouter().
+		Get("/users", generateCountableHandler(new(int))).
+		Post("/users", generateCountableHandler(new(int))).Doc("Creates a new user").
+		Routes()
+
+	if 2 != len(routes) {
+		t.Fatalf("Expected 2 Routes, found %d.", len(routes))
+	}
+
+	if routes[0].Method != GET || "" != routes[0].Doc {
+		t.Errorf("Expected undocumented GET Route, found %+v.", routes[0])
+	}
+
+	if routes[1].Method != POST || "Creates a new user" != routes[1].Doc {
+		t.Errorf("Expected documented POST Route, found %+v.", routes[1])
+	}
+}
+
+// TestMatchRouteDoc ensures documentation set after the Router's
+// Match method applies to the Routes of every method.
+func TestMatchRouteDoc(t *testing.T) {
+	routes := NewRouter().
+		AllowTraceMethod().
+		Match("/any", generateCountableHandler(new(int))).Doc("Any method").
+		Routes()
+
+	if len(httpMethods) != len(routes) {
+		t.Fatalf("Expected %d Routes, found %d.", len(httpMethods), len(routes))
+	}
+
+	for _, route := range routes {
+		if "Any method" != route.Doc {
+			t.Errorf("Expected %s Route to be documented.", route.Method)
+		}
+	}
+}
+
+// TestPrintRoutes ensures the route printer includes each Route's
+// method, path, and documentation.
+func TestPrintRoutes(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := NewRouter().
+		Get("/users/:id", generateCountableHandler(new(int))).Doc("Shows a user").
+		PrintRoutes(&buffer)
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if fields := strings.Fields(buffer.String()); 5 != len(fields) || GET != fields[0] || "/users/:id" != fields[1] {
+		t.Errorf("Unexpected route table %q.", buffer.String())
+	}
+}
+
+// TestHandler ensures handlers are looked up by the path they were
+// registered with.
+func TestHandler(t *testing.T) {
+	var count int
+
+	router := NewRouter().Get("/users/:id", generateCountableHandler(&count))
+
+	if handler, ok := router.Handler("get", "/users/:id"); !ok || nil == handler {
+		t.Error("Expected handler registered for /users/:id.")
+	}
+
+	if _, ok := router.Handler(GET, "/users/1"); ok {
+		t.Error("Expected no handler registered for /users/1.")
+	}
+}
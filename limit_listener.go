@@ -0,0 +1,128 @@
+package dispatcher
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// unavailableResponse is written to plaintext connections rejected by a
+// limitListener.
+const unavailableResponse = "HTTP/1.1 503 Service Unavailable\r\nConnection: close\r\nContent-Length: 0\r\nRetry-After: 1\r\n\r\n"
+
+// rejectWriteTimeout bounds how long writing unavailableResponse to a
+// rejected connection may block.
+const rejectWriteTimeout = 100 * time.Millisecond
+
+// limitListener is a net.Listener limiting the number of connections
+// open at once, in total and from each remote host. Connections over
+// either limit are closed as soon as they are accepted.
+type limitListener struct {
+	net.Listener
+	mu sync.Mutex
+	// max is the maximum number of open connections, or 0 if unlimited.
+	max int
+	// perHost is the maximum number of open connections from each remote
+	// host, or 0 if unlimited.
+	perHost int
+	// respond, if set, makes rejected connections be answered with 503
+	// Service Unavailable before being closed, rather than reset.
+	respond bool
+	open    int
+	hosts   map[string]int
+}
+
+// limitConn is a connection accepted by a limitListener, released once
+// closed.
+type limitConn struct {
+	net.Conn
+	once     sync.Once
+	listener *limitListener
+	host     string
+}
+
+// limitConnections wraps `listener` to enforce the connection limits of
+// `opts`, if any. See limitListener.
+func limitConnections(listener net.Listener, opts ServeOptions, respond bool) net.Listener {
+	if 0 >= opts.MaxConnections && 0 >= opts.MaxConnectionsPerIP {
+		return listener
+	}
+
+	return &limitListener{
+		Listener: listener,
+		max:      opts.MaxConnections,
+		perHost:  opts.MaxConnectionsPerIP,
+		respond:  respond,
+		hosts:    make(map[string]int),
+	}
+}
+
+// Accept waits for and returns the next connection within the
+// listener's limits, rejecting those over them.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+
+		if nil != err {
+			return nil, err
+		}
+
+		host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+
+		if nil != err {
+			host = conn.RemoteAddr().String()
+		}
+
+		if l.acquire(host) {
+			return &limitConn{Conn: conn, listener: l, host: host}, nil
+		}
+
+		go l.reject(conn)
+	}
+}
+
+// acquire reserves a connection from `host`, reporting whether it is
+// within the listener's limits.
+func (l *limitListener) acquire(host string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if (0 < l.max && l.max <= l.open) || (0 < l.perHost && l.perHost <= l.hosts[host]) {
+		return false
+	}
+
+	l.open++
+	l.hosts[host]++
+	return true
+}
+
+// release frees a connection from `host` reserved with acquire.
+func (l *limitListener) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open--
+
+	if l.hosts[host]--; 0 >= l.hosts[host] {
+		delete(l.hosts, host)
+	}
+}
+
+// reject closes `conn`, answering it with 503 Service Unavailable if
+// the listener responds to rejected connections, or resetting it.
+func (l *limitListener) reject(conn net.Conn) {
+	if l.respond {
+		conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+		conn.Write([]byte(unavailableResponse))
+	} else if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+
+	conn.Close()
+}
+
+// Close closes the connection, releasing it from its listener's limits.
+func (c *limitConn) Close() error {
+	c.once.Do(func() { c.listener.release(c.host) })
+	return c.Conn.Close()
+}
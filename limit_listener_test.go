@@ -0,0 +1,87 @@
+package dispatcher
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServerMaxConnectionsPerIP ensures connections over the per-IP
+// limit are answered with 503 Service Unavailable, and that closed
+// connections free their slot.
+func TestServerMaxConnectionsPerIP(t *testing.T) {
+	router := NewRouter().Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	server, err := router.Serve("127.0.0.1:0", ServeOptions{MaxConnectionsPerIP: 1})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer server.Shutdown(context.Background())
+
+	get := func(conn net.Conn) int {
+		io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	first, err := net.Dial("tcp", server.Addr().String())
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if status := get(first); http.StatusOK != status {
+		t.Fatalf("Expected the first connection to be served, got %d.", status)
+	}
+
+	second, err := net.Dial("tcp", server.Addr().String())
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	second.SetReadDeadline(time.Now().Add(time.Second))
+	rejected, _ := io.ReadAll(second)
+	second.Close()
+
+	if !strings.HasPrefix(string(rejected), "HTTP/1.1 503") {
+		t.Errorf("Expected the second connection to be rejected, got %q.", rejected)
+	}
+
+	first.Close()
+
+	// The first connection's slot is freed once the server notices it
+	// closed.
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		third, err := net.Dial("tcp", server.Addr().String())
+
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		third.SetReadDeadline(time.Now().Add(time.Second))
+		io.WriteString(third, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+		served, _ := io.ReadAll(third)
+		third.Close()
+
+		if strings.HasPrefix(string(served), "HTTP/1.1 200") {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a connection to be served once the first closed, got %q.", served)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultLocaleParam is the query parameter and cookie name Localize
+// reads the requested locale from when LocaleOptions leaves them unset.
+const DefaultLocaleParam = "lang"
+
+// Translator translates messages into a locale.
+type Translator interface {
+	// Translate returns the message `key` in the Translator's locale,
+	// formatted with `args` as by fmt.Sprintf, or `key` itself if it
+	// has no such message.
+	Translate(key string, args ...any) string
+}
+
+// Catalog is a set of messages by locale and then by key, whose
+// values are fmt.Sprintf formats.
+type Catalog map[string]map[string]string
+
+// LocaleOptions configures Localize.
+type LocaleOptions struct {
+	// Supported are the locales requests are resolved to, i.e. "en",
+	// "de". The first is used if none of them were requested.
+	Supported []string
+	// Query is the query parameter the requested locale is read from
+	// first. Set it to "-" to ignore queries.
+	Query string
+	// Cookie is the cookie the requested locale is read from if the
+	// query has none. Set it to "-" to ignore cookies.
+	Cookie string
+	// Translators, if set, returns the Translator for a locale, i.e.
+	// a Catalog's Translator method. By default messages are left
+	// untranslated.
+	Translators func(locale string) Translator
+}
+
+// localeContext is the locale and Translator stored in a request's
+// context by Localize.
+type localeContext struct {
+	locale     string
+	translator Translator
+}
+
+// localeContextKey is the context key a request's localeContext is
+// stored under.
+type localeContextKey struct{}
+
+// catalogTranslator is the Translator of a Catalog for one locale.
+type catalogTranslator map[string]string
+
+// Localize returns a HandlerWrapper resolving the locale of each
+// request among `opts.Supported`, from its query, then its cookie and
+// then its Accept-Language header, i.e.
+//
+//	catalog := dispatcher.Catalog{"de": {"greeting": "Hallo %s"}}
+//	router.RegisterWrapper(dispatcher.Localize(dispatcher.LocaleOptions{
+//		Supported:   []string{"en", "de"},
+//		Translators: catalog.Translator,
+//	}))
+//
+// The locale and its Translator are stored in the request's context,
+// where handlers retrieve them with LocaleFromContext and Translate.
+// Responses are sent with the locale as their Content-Language, and
+// vary by Accept-Language and, if read, the cookie.
+func Localize(opts LocaleOptions) HandlerWrapper {
+	if "" == opts.Query {
+		opts.Query = DefaultLocaleParam
+	}
+
+	if "" == opts.Cookie {
+		opts.Cookie = DefaultLocaleParam
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if 0 == len(opts.Supported) {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			locale := resolveRequestLocale(req, opts)
+
+			localized := localeContext{locale: locale, translator: untranslated{}}
+
+			if nil != opts.Translators {
+				if translator := opts.Translators(locale); nil != translator {
+					localized.translator = translator
+				}
+			}
+
+			res.Header().Set("Content-Language", locale)
+			AddVary(res.Header(), VaryLanguage)
+
+			if "-" != opts.Cookie {
+				AddVary(res.Header(), VaryCookie)
+			}
+
+			next.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), localeContextKey{}, localized)))
+		})
+	}
+}
+
+// LocaleFromContext returns the locale Localize resolved for the
+// request whose context is `ctx`, and whether it ran.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	localized, ok := ctx.Value(localeContextKey{}).(localeContext)
+	return localized.locale, ok
+}
+
+// TranslatorFromContext returns the Translator for the locale Localize
+// resolved for the request whose context is `ctx`. If Localize did not
+// run, the Translator returned leaves messages untranslated.
+func TranslatorFromContext(ctx context.Context) Translator {
+	if localized, ok := ctx.Value(localeContextKey{}).(localeContext); ok {
+		return localized.translator
+	}
+
+	return untranslated{}
+}
+
+// Translate translates the message `key` with the Translator stored in
+// `ctx`, formatting it with `args`.
+func Translate(ctx context.Context, key string, args ...any) string {
+	return TranslatorFromContext(ctx).Translate(key, args...)
+}
+
+// Translator returns the Translator for `locale`. Messages missing
+// from the Catalog are left untranslated.
+func (c Catalog) Translator(locale string) Translator {
+	return catalogTranslator(c[locale])
+}
+
+// Translate returns the message `key` formatted with `args`, or `key`
+// itself if there is no such message.
+func (t catalogTranslator) Translate(key string, args ...any) string {
+	message, ok := t[key]
+
+	if !ok {
+		return key
+	}
+
+	if 0 == len(args) {
+		return message
+	}
+
+	return fmt.Sprintf(message, args...)
+}
+
+// untranslated is the Translator leaving messages untranslated.
+type untranslated struct{}
+
+// Translate returns `key`.
+func (untranslated) Translate(key string, args ...any) string {
+	return key
+}
+
+// resolveRequestLocale resolves the locale of `req` among the
+// supported locales of `opts`.
+func resolveRequestLocale(req *http.Request, opts LocaleOptions) string {
+	if "-" != opts.Query {
+		if locale := resolveLocale(req.URL.Query().Get(opts.Query), opts.Supported); "" != locale {
+			return locale
+		}
+	}
+
+	if "-" != opts.Cookie {
+		if cookie, err := req.Cookie(opts.Cookie); nil == err {
+			if locale := resolveLocale(cookie.Value, opts.Supported); "" != locale {
+				return locale
+			}
+		}
+	}
+
+	for _, accepted := range ParseAccept(strings.Join(req.Header.Values("Accept-Language"), ",")) {
+		if 0 == accepted.Quality {
+			continue
+		}
+
+		if locale := resolveLocale(accepted.Value, opts.Supported); "" != locale {
+			return locale
+		}
+	}
+
+	return opts.Supported[0]
+}
+
+// resolveLocale returns the locale among `supported` matching the
+// language tag `tag`, trying less specific tags in turn (i.e. "de-AT"
+// then "de"), or an empty string.
+func resolveLocale(tag string, supported []string) string {
+	for "" != tag {
+		if locale := negotiate([]string{tag}, supported, matchLanguageRange); "" != locale {
+			return locale
+		}
+
+		cut := strings.LastIndexByte(tag, '-')
+
+		if -1 == cut {
+			break
+		}
+
+		tag = tag[:cut]
+	}
+
+	return ""
+}
@@ -0,0 +1,94 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestLocalize ensures locales are resolved from queries, cookies and
+// Accept-Language headers, in that order.
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		query, cookie, language string
+		expected                string
+	}{
+		{"", "", "", "en"},
+		{"", "", "fr, de-AT;q=0.8, en;q=0.5", "de"},
+		{"", "", "fr, de;q=0", "en"},
+		{"", "de", "en", "de"},
+		{"de-CH", "en", "en", "de"},
+		{"fr", "", "de", "de"},
+	}
+
+	for _, test := range tests {
+		var locale string
+
+		handler := Localize(LocaleOptions{Supported: []string{"en", "de"}})(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			locale, _ = LocaleFromContext(req.Context())
+		}))
+
+		req := generateHttpRequest(GET, "/")
+
+		if "" != test.query {
+			req.URL.RawQuery = "lang=" + test.query
+		}
+
+		if "" != test.cookie {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+
+		if "" != test.language {
+			req.Header.Set("Accept-Language", test.language)
+		}
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if test.expected != locale {
+			t.Errorf("Expected locale %q for %+v, got %q.", test.expected, test, locale)
+		}
+
+		if test.expected != res.Header().Get("Content-Language") {
+			t.Errorf("Expected Content-Language %q, got %q.", test.expected, res.Header().Get("Content-Language"))
+		}
+
+		if "Accept-Language, Cookie" != res.Header().Get("Vary") {
+			t.Errorf("Expected Vary to list Accept-Language and Cookie, got %q.", res.Header().Get("Vary"))
+		}
+	}
+}
+
+// TestTranslate ensures messages are translated with the Translator
+// of the resolved locale, and left untranslated otherwise.
+func TestTranslate(t *testing.T) {
+	catalog := Catalog{"de": {"greeting": "Hallo %s"}}
+
+	var translated string
+
+	handler := Localize(LocaleOptions{Supported: []string{"en", "de"}, Cookie: "-", Translators: catalog.Translator})(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		translated = Translate(req.Context(), "greeting", "Welt")
+	}))
+
+	req := generateHttpRequest(GET, "/?lang=de")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if "Hallo Welt" != translated {
+		t.Errorf("Expected a translated message, got %q.", translated)
+	}
+
+	if "Accept-Language" != res.Header().Get("Vary") {
+		t.Errorf("Expected Vary to omit Cookie, got %q.", res.Header().Get("Vary"))
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/?lang=en"))
+
+	if "greeting" != translated {
+		t.Errorf("Expected an untranslated message, got %q.", translated)
+	}
+
+	if message := Translate(req.Context(), "greeting", "Welt"); "greeting" != message {
+		t.Errorf("Expected an untranslated message without Localize, got %q.", message)
+	}
+}
@@ -0,0 +1,77 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LocaleParam is the name of the parameter the locale of a
+// locale-prefixed Route is captured as.
+const LocaleParam = "locale"
+
+// LocalizeRoutes makes Routes registered afterwards also match their
+// paths prefixed with each of `locales`, i.e.
+//
+//	router.LocalizeRoutes("en", "de")
+//	router.Get("/about", AboutHandler).Name("about")
+//
+// serves `/about`, `/en/about` and `/de/about` with AboutHandler, the
+// locale being captured as the LocaleParam parameter. Calling it
+// without locales stops localizing Routes. See LocalizedURL.
+func (r *Router) LocalizeRoutes(locales ...string) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.locales = append([]string(nil), locales...)
+	return r
+}
+
+// LocalizedURL builds the path of the Route named `name` as URL does,
+// prefixed with the current locale of the request whose context is
+// `ctx`: the LocaleParam parameter of its Route, or else the locale
+// Localize resolved. The path is left unprefixed if there is no
+// current locale or the Route was not localized for it, i.e.
+//
+//	path, err := router.LocalizedURL(req.Context(), "about") // "/de/about"
+func (r *Router) LocalizedURL(ctx context.Context, name string, params ...string) (string, error) {
+	r.Lock()
+	route, ok := r.names[name]
+	r.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownRoute, name)
+	}
+
+	path, err := route.url(params)
+
+	if nil != err {
+		return "", err
+	}
+
+	locale := ParamsFromContext(ctx).Get(LocaleParam)
+
+	if "" == locale {
+		locale, _ = LocaleFromContext(ctx)
+	}
+
+	if "" == locale || !contains(route.locales, locale) {
+		return path, nil
+	}
+
+	if "/" == path {
+		path = ""
+	}
+
+	return "/" + locale + path, nil
+}
+
+// localizePath returns `path` prefixed with a LocaleParam parameter
+// matching any of `locales`, which are language tags.
+func localizePath(path string, locales []string) string {
+	if "/" == path {
+		path = ""
+	}
+
+	return fmt.Sprintf("/:%s(%s)%s", LocaleParam, strings.Join(locales, "|"), path)
+}
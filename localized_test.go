@@ -0,0 +1,104 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestLocalizeRoutes ensures Routes registered after LocalizeRoutes
+// match their locale-prefixed paths, capturing the locale.
+func TestLocalizeRoutes(t *testing.T) {
+	var locale string
+
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		locale = ParamsFromContext(req.Context()).Get(LocaleParam)
+	})
+
+	router := NewRouter().Get("/plain", handler)
+	router.LocalizeRoutes("en", "de").Get("/about", handler).Get("/", handler).Get("/*", handler)
+
+	tests := []struct {
+		path, locale string
+		status       int
+	}{
+		{"/about", "", http.StatusOK},
+		{"/en/about", "en", http.StatusOK},
+		{"/de/about", "de", http.StatusOK},
+		{"/de", "de", http.StatusOK},
+		{"/", "", http.StatusOK},
+		{"/de/plain", "de", http.StatusOK}, // Served by the wildcard.
+	}
+
+	for _, test := range tests {
+		locale = "unset"
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, test.path))
+
+		if test.status != res.Code {
+			t.Errorf("Expected status %d for %q, got %d.", test.status, test.path, res.Code)
+		}
+
+		if test.locale != locale {
+			t.Errorf("Expected locale %q for %q, got %q.", test.locale, test.path, locale)
+		}
+	}
+
+	router = NewRouter().LocalizeRoutes("en").LocalizeRoutes()
+	router.Get("/contact", handler)
+
+	if _, _, ok := router.Lookup(GET, "/en/contact"); ok {
+		t.Error("Expected Routes registered after LocalizeRoutes() not to be localized.")
+	}
+}
+
+// TestLocalizedURL ensures reverse URLs are prefixed with the current
+// locale of Routes localized for it.
+func TestLocalizedURL(t *testing.T) {
+	handler := http.NotFoundHandler()
+	router := NewRouter()
+	router.Get("/plain", handler).Name("plain")
+	router.LocalizeRoutes("en", "de").Get("/articles/:id", handler).Name("article").Get("/", handler).Name("home")
+
+	route, params, ok := router.Lookup(GET, "/de/articles/1")
+
+	if !ok || "de" != params.Get(LocaleParam) {
+		t.Fatalf("Expected the localized Route to match, got %v %v.", route, params)
+	}
+
+	localized := WithRoute(generateHttpRequest(GET, "/").Context(), route, params)
+
+	tests := []struct {
+		name     string
+		params   []string
+		expected string
+	}{
+		{"article", []string{"42"}, "/de/articles/42"},
+		{"home", nil, "/de"},
+		{"plain", nil, "/plain"},
+	}
+
+	for _, test := range tests {
+		if path, err := router.LocalizedURL(localized, test.name, test.params...); nil != err || test.expected != path {
+			t.Errorf("Expected %q for %q, got %q (%v).", test.expected, test.name, path, err)
+		}
+	}
+
+	if path, _ := router.URL("article", "42"); "/articles/42" != path {
+		t.Errorf("Expected URL to build the unprefixed path, got %q.", path)
+	}
+
+	var path string
+
+	handler = Localize(LocaleOptions{Supported: []string{"en", "fr"}})(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		path, _ = router.LocalizedURL(req.Context(), "article", "7")
+	}))
+
+	for locale, expected := range map[string]string{"en": "/en/articles/7", "fr": "/articles/7"} {
+		handler.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/?lang="+locale))
+
+		if expected != path {
+			t.Errorf("Expected %q for the resolved locale %q, got %q.", expected, locale, path)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package dispatcher
+
+// Meta tags the Routes created by the most recent registration call
+// with `values` under `key`, i.e.
+//
+//	router.Delete("/users/:id", DeleteUserHandler).Meta("roles", "admin")
+//
+// Tags are read by RouteMiddleware through Route.Meta, i.e. to enforce
+// access control centrally. Tagging a key again replaces its values.
+func (r *Router) Meta(key string, values ...string) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, route := range r.current {
+		// Routes copied by Clone and Merge share their tags, so they are
+		// replaced rather than modified.
+		meta := make(map[string][]string, len(route.meta)+1)
+
+		for k, v := range route.meta {
+			meta[k] = v
+		}
+
+		meta[key] = values
+		route.meta = meta
+	}
+
+	return r
+}
+
+// Meta returns the values the Route was tagged with under `key`. The
+// slice returned must not be modified.
+func (route *Route) Meta(key string) []string {
+	return route.meta[key]
+}
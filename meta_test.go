@@ -0,0 +1,29 @@
+package dispatcher
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestMeta ensures Routes carry their tags without sharing them with
+// merged copies.
+func TestMeta(t *testing.T) {
+	router := NewRouter().
+		Delete("/users/:id", http.NotFoundHandler()).Meta("roles", "admin", "owner").Meta("audit", "true")
+
+	route, _ := router.findMatchingRouteAndHandler(generateHttpRequest(DELETE, "/users/1"))
+
+	if roles := route.Meta("roles"); 2 != len(roles) || "owner" != roles[1] {
+		t.Errorf("Expected route to be tagged with roles, got %v.", roles)
+	}
+
+	if info := router.Routes()[0]; "true" != info.Meta["audit"][0] {
+		t.Errorf("Expected route info to include tags, got %v.", info.Meta)
+	}
+
+	NewRouter().Merge(router, "/admin").Meta("roles", "support")
+
+	if roles := route.Meta("roles"); 2 != len(roles) {
+		t.Errorf("Expected tags of merged copies to be separate, got %v.", roles)
+	}
+}
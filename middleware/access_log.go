@@ -0,0 +1,292 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// RequestIDHeader is the request or response header access logs read
+// request IDs from.
+const RequestIDHeader = "X-Request-ID"
+
+// AccessLogField names a field of a JSON access log entry.
+type AccessLogField string
+
+// Fields JSONAccessLog can write.
+const (
+	LogTime      AccessLogField = "time"
+	LogRequestID AccessLogField = "request_id"
+	LogRemote    AccessLogField = "remote"
+	LogMethod    AccessLogField = "method"
+	LogURL       AccessLogField = "url"
+	LogRoute     AccessLogField = "route"
+	LogStatus    AccessLogField = "status"
+	LogBytes     AccessLogField = "bytes"
+	LogLatency   AccessLogField = "latency_ms"
+	LogUser      AccessLogField = "user"
+	LogUserAgent AccessLogField = "user_agent"
+	LogHeaders   AccessLogField = "headers"
+)
+
+// DefaultAccessLogFields are the fields written by JSONAccessLog when
+// none are given.
+var DefaultAccessLogFields = []AccessLogField{LogTime, LogRequestID, LogRemote, LogMethod, LogURL, LogRoute, LogStatus, LogBytes, LogLatency, LogUser}
+
+// AccessLogEntry describes a request served, as written to an access
+// log. Secrets in its URL and Header are already redacted.
+type AccessLogEntry struct {
+	Time      time.Time
+	RequestID string
+	Remote    string
+	Method    string
+	URL       string
+	Proto     string
+	// Route is the path of the Route serving the request, i.e.
+	// `/users/:id`, or empty if none matched.
+	Route     string
+	Status    int
+	Bytes     int64
+	Latency   time.Duration
+	User      string
+	UserAgent string
+	// Header holds the request headers listed in
+	// AccessLogOptions.Headers.
+	Header http.Header
+}
+
+// AccessLogFormatter writes an AccessLogEntry to an access log, as a
+// single line.
+type AccessLogFormatter func(w io.Writer, entry *AccessLogEntry) error
+
+// AccessLogOptions configures AccessLog.
+type AccessLogOptions struct {
+	// Output is where entries are written. By default os.Stderr.
+	Output io.Writer
+	// Format writes each entry. By default CommonAccessLog.
+	Format AccessLogFormatter
+	// Headers are the request headers logged, i.e. for LogHeaders.
+	Headers []string
+	// RedactHeaders are the logged headers whose values are replaced
+	// with Redacted. By default DefaultRedactedHeaders.
+	RedactHeaders []string
+	// RedactFields are the query parameters whose values are replaced
+	// with Redacted. By default DefaultRedactedFields.
+	RedactFields []string
+	// User returns the user a request was made by. By default the name
+	// of a dispatcher.BasicPrincipal or fmt.Stringer Principal.
+	User func(req *http.Request) string
+}
+
+// AccessLog returns a HandlerWrapper writing an entry to an access log
+// for each request served, i.e.
+//
+//	router.RegisterWrapper(middleware.AccessLog(middleware.AccessLogOptions{
+//		Format: middleware.JSONAccessLog(),
+//	}))
+//
+// Entries name the Route serving each request by its path, i.e.
+// `/users/:id`, so they can be aggregated. Write errors are ignored.
+func AccessLog(opts AccessLogOptions) dispatcher.HandlerWrapper {
+	if nil == opts.Output {
+		opts.Output = os.Stderr
+	}
+
+	if nil == opts.Format {
+		opts.Format = CommonAccessLog
+	}
+
+	if nil == opts.RedactHeaders {
+		opts.RedactHeaders = DefaultRedactedHeaders
+	}
+
+	if nil == opts.RedactFields {
+		opts.RedactFields = DefaultRedactedFields
+	}
+
+	if nil == opts.User {
+		opts.User = principalName
+	}
+
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			writer := dispatcher.NewResponseWriter(res)
+			next.ServeHTTP(writer, req)
+
+			target := *req.URL
+			target.RawQuery = redactValues(target.Query(), opts.RedactFields).Encode()
+
+			entry := &AccessLogEntry{
+				Time:      start,
+				RequestID: req.Header.Get(RequestIDHeader),
+				Remote:    dispatcher.RealIP(req),
+				Method:    req.Method,
+				URL:       target.RequestURI(),
+				Proto:     req.Proto,
+				Status:    writer.Status(),
+				Bytes:     writer.BytesWritten(),
+				Latency:   time.Since(start),
+				User:      opts.User(req),
+				UserAgent: req.UserAgent(),
+			}
+
+			if "" == entry.RequestID {
+				entry.RequestID = writer.Header().Get(RequestIDHeader)
+			}
+
+			if 0 == entry.Status {
+				entry.Status = http.StatusOK
+			}
+
+			if route, ok := dispatcher.RouteFromContext(req.Context()); ok {
+				entry.Route = route.Path
+			}
+
+			for _, name := range opts.Headers {
+				if values := req.Header.Values(name); 0 < len(values) {
+					if nil == entry.Header {
+						entry.Header = make(http.Header)
+					}
+
+					entry.Header[http.CanonicalHeaderKey(name)] = values
+				}
+			}
+
+			entry.Header = redactHeader(entry.Header, opts.RedactHeaders)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			opts.Format(opts.Output, entry)
+		})
+	}
+}
+
+// CommonAccessLog writes `entry` in the Common Log Format, i.e.
+//
+//	192.0.2.1 - jane [16/Oct/2026:10:00:00 +0000] "GET /users/1 HTTP/1.1" 200 42
+func CommonAccessLog(w io.Writer, entry *AccessLogEntry) error {
+	user := entry.User
+
+	if "" == user {
+		user = "-"
+	}
+
+	_, err := fmt.Fprintf(w, "%s - %s [%s] %q %d %d\n", entry.Remote, user, entry.Time.Format("02/Jan/2006:15:04:05 -0700"), entry.Method+" "+entry.URL+" "+entry.Proto, entry.Status, entry.Bytes)
+	return err
+}
+
+// JSONAccessLog returns an AccessLogFormatter writing entries as JSON
+// objects, one per line, holding `fields` in order, or the
+// DefaultAccessLogFields. Empty string fields are left out.
+func JSONAccessLog(fields ...AccessLogField) AccessLogFormatter {
+	if 0 == len(fields) {
+		fields = DefaultAccessLogFields
+	}
+
+	return func(w io.Writer, entry *AccessLogEntry) error {
+		var line bytes.Buffer
+
+		line.WriteByte('{')
+
+		for _, field := range fields {
+			var value any
+
+			switch field {
+			case LogTime:
+				value = entry.Time.UTC().Format(time.RFC3339Nano)
+			case LogRequestID:
+				value = entry.RequestID
+			case LogRemote:
+				value = entry.Remote
+			case LogMethod:
+				value = entry.Method
+			case LogURL:
+				value = entry.URL
+			case LogRoute:
+				value = entry.Route
+			case LogStatus:
+				value = entry.Status
+			case LogBytes:
+				value = entry.Bytes
+			case LogLatency:
+				value = json.Number(strconv.FormatFloat(float64(entry.Latency.Microseconds())/1000, 'f', -1, 64))
+			case LogUser:
+				value = entry.User
+			case LogUserAgent:
+				value = entry.UserAgent
+			case LogHeaders:
+				if 0 == len(entry.Header) {
+					continue
+				}
+
+				value = entry.Header
+			}
+
+			if "" == value {
+				continue
+			}
+
+			if 1 < line.Len() {
+				line.WriteByte(',')
+			}
+
+			if err := encodeLogValue(&line, string(field)); nil != err {
+				return err
+			}
+
+			line.WriteByte(':')
+
+			if err := encodeLogValue(&line, value); nil != err {
+				return err
+			}
+		}
+
+		line.WriteString("}\n")
+		_, err := line.WriteTo(w)
+		return err
+	}
+}
+
+// encodeLogValue writes `value` to `line` as JSON, without escaping
+// HTML characters.
+func encodeLogValue(line *bytes.Buffer, value any) error {
+	encoder := json.NewEncoder(line)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); nil != err {
+		return err
+	}
+
+	// Encode terminates values with a newline.
+	line.Truncate(line.Len() - 1)
+	return nil
+}
+
+// principalName returns the name of the Principal of `req`, if it is a
+// dispatcher.BasicPrincipal or fmt.Stringer.
+func principalName(req *http.Request) string {
+	switch principal, _ := dispatcher.PrincipalFromContext(req.Context()); named := principal.(type) {
+	case dispatcher.BasicPrincipal:
+		return named.Name
+	case *dispatcher.BasicPrincipal:
+		return named.Name
+	case fmt.Stringer:
+		return named.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// generateLoggedRouter returns a Router logging with `format` to
+// `output`.
+func generateLoggedRouter(output *bytes.Buffer, format AccessLogFormatter) *dispatcher.Router {
+	router := dispatcher.NewRouter()
+	router.RegisterPlugin(dispatcher.PluginFunc(func(req *http.Request) *http.Request {
+		return req.WithContext(dispatcher.WithPrincipal(req.Context(), dispatcher.BasicPrincipal{Name: "jane"}))
+	}))
+	router.RegisterWrapper(AccessLog(AccessLogOptions{Output: output, Format: format, Headers: []string{"Authorization", "Accept"}}))
+	router.Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte("hello"))
+	}))
+
+	return router
+}
+
+// TestJSONAccessLog ensures entries are written as JSON with the route
+// template and secrets redacted.
+func TestJSONAccessLog(t *testing.T) {
+	var output bytes.Buffer
+
+	router := generateLoggedRouter(&output, JSONAccessLog(LogRequestID, LogMethod, LogURL, LogRoute, LogStatus, LogBytes, LogUser, LogHeaders, LogLatency))
+
+	req := httptest.NewRequest("GET", "/users/1?token=abc&page=2", nil)
+	req.Header.Set(RequestIDHeader, "r-1")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := regexp.MustCompile(`^\{"request_id":"r-1","method":"GET","url":"/users/1\?page=2&token=%5BREDACTED%5D","route":"/users/:id","status":201,"bytes":5,"user":"jane","headers":\{"Accept":\["application/json"\],"Authorization":\["\[REDACTED\]"\]\},"latency_ms":[0-9.]+\}\n$`)
+
+	if !expected.Match(output.Bytes()) {
+		t.Errorf("Expected a JSON entry, got %s", output.String())
+	}
+
+	output.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	var entry map[string]any
+
+	if err := json.Unmarshal(output.Bytes(), &entry); nil != err {
+		t.Fatalf("Expected a JSON entry, got %s (%v)", output.String(), err)
+	}
+
+	if _, ok := entry["route"]; ok || 404.0 != entry["status"] {
+		t.Errorf("Expected an unmatched request without a route, got %v.", entry)
+	}
+}
+
+// TestCommonAccessLog ensures entries are written in the Common Log
+// Format by default.
+func TestCommonAccessLog(t *testing.T) {
+	var output bytes.Buffer
+
+	router := generateLoggedRouter(&output, nil)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	expected := regexp.MustCompile(`^192\.0\.2\.1 - jane \[[^\]]+\] "GET /users/1 HTTP/1\.1" 201 5\n$`)
+
+	if !expected.Match(output.Bytes()) {
+		t.Errorf("Expected a Common Log Format entry, got %q.", output.String())
+	}
+}
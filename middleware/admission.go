@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultAdmissionQueueTimeout is how long requests wait in the queue
+// of the Admission wrapper when AdmissionOptions.QueueTimeout is unset.
+const DefaultAdmissionQueueTimeout = time.Second
+
+// QueuePolicy is the order the Admission wrapper admits queued requests
+// in.
+type QueuePolicy int
+
+// Policies for admitting queued requests.
+const (
+	// QueueFIFO admits the requests queued longest first. Requests
+	// arriving while the queue is full are shed.
+	QueueFIFO QueuePolicy = iota
+	// QueueLIFO admits the requests queued most recently first, so
+	// under sustained overload fresh requests are served while their
+	// clients are still waiting. Requests arriving while the queue is
+	// full displace the one queued longest, which is shed.
+	QueueLIFO
+)
+
+// AdmissionOptions configures the Admission wrapper.
+type AdmissionOptions struct {
+	// MaxConcurrent is the number of requests served at once.
+	MaxConcurrent int
+	// MaxQueue is the number of requests waiting to be served while
+	// MaxConcurrent requests are. Without a queue, requests arriving
+	// while the limit is reached are shed.
+	MaxQueue int
+	// QueueTimeout is how long requests wait in the queue before being
+	// shed.
+	QueueTimeout time.Duration
+	// Policy is the order queued requests are admitted in.
+	Policy QueuePolicy
+	// RetryAfter, if positive, is sent as the `Retry-After` header of
+	// shed requests.
+	RetryAfter time.Duration
+}
+
+// admission tracks the requests served and queued by an Admission
+// wrapper.
+type admission struct {
+	sync.Mutex
+	options AdmissionOptions
+	active  int
+	queue   []chan bool
+}
+
+// Admission returns a HandlerWrapper limiting the requests served at
+// once to AdmissionOptions.MaxConcurrent. Requests arriving while the
+// limit is reached are queued, up to AdmissionOptions.MaxQueue of them,
+// and served as others complete. Requests that overflow the queue, or
+// wait longer than the QueueTimeout, are shed with 503 Service
+// Unavailable, i.e.
+//
+//	router.Wrap(middleware.Admission(middleware.AdmissionOptions{
+//		MaxConcurrent: 64,
+//		MaxQueue:      256,
+//		QueueTimeout:  500 * time.Millisecond,
+//		Policy:        middleware.QueueLIFO,
+//	}))
+func Admission(opts AdmissionOptions) dispatcher.HandlerWrapper {
+	return newAdmission(opts).wrap
+}
+
+// newAdmission creates an admission with the defaults applied to
+// `opts`.
+func newAdmission(opts AdmissionOptions) *admission {
+	if 0 >= opts.MaxConcurrent {
+		opts.MaxConcurrent = 1
+	}
+
+	if 0 >= opts.QueueTimeout {
+		opts.QueueTimeout = DefaultAdmissionQueueTimeout
+	}
+
+	return &admission{options: opts}
+}
+
+// wrap is the admission's HandlerWrapper.
+func (a *admission) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !a.admit(req) {
+			if 0 < a.options.RetryAfter {
+				res.Header().Set("Retry-After", strconv.Itoa(int((a.options.RetryAfter+time.Second-1)/time.Second)))
+			}
+
+			http.Error(res, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		defer a.release()
+		next.ServeHTTP(res, req)
+	})
+}
+
+// admit blocks until `req` may be served, reporting whether it was
+// admitted or shed.
+func (a *admission) admit(req *http.Request) bool {
+	a.Lock()
+
+	if a.active < a.options.MaxConcurrent {
+		a.active++
+		a.Unlock()
+		return true
+	}
+
+	if len(a.queue) >= a.options.MaxQueue {
+		if QueueLIFO != a.options.Policy || 0 == len(a.queue) {
+			a.Unlock()
+			return false
+		}
+
+		// Displace the request queued longest.
+		a.queue[0] <- false
+		a.queue = a.queue[1:]
+	}
+
+	ready := make(chan bool, 1)
+	a.queue = append(a.queue, ready)
+	a.Unlock()
+
+	timer := time.NewTimer(a.options.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case admitted := <-ready:
+		return admitted
+	case <-timer.C:
+	case <-req.Context().Done():
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	for i, queued := range a.queue {
+		if queued == ready {
+			a.queue = append(a.queue[:i:i], a.queue[i+1:]...)
+			return false
+		}
+	}
+
+	// The request was admitted or displaced while giving up.
+	return <-ready
+}
+
+// release hands the slot of a served request to the next queued
+// request, if any.
+func (a *admission) release() {
+	a.Lock()
+	defer a.Unlock()
+
+	if 0 == len(a.queue) {
+		a.active--
+		return
+	}
+
+	var next chan bool
+
+	if QueueLIFO == a.options.Policy {
+		next, a.queue = a.queue[len(a.queue)-1], a.queue[:len(a.queue)-1]
+	} else {
+		next, a.queue = a.queue[0], a.queue[1:]
+	}
+
+	next <- true
+}
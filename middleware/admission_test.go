@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// admissionHarness serves requests through an admission whose handler
+// blocks until released.
+type admissionHarness struct {
+	*admission
+	handler http.Handler
+	release chan struct{}
+	mu      sync.Mutex
+	served  []string
+	codes   sync.Map
+	wg      sync.WaitGroup
+}
+
+// newAdmissionHarness creates an admissionHarness for `opts`.
+func newAdmissionHarness(opts AdmissionOptions) *admissionHarness {
+	h := &admissionHarness{admission: newAdmission(opts), release: make(chan struct{})}
+
+	h.handler = h.wrap(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		h.mu.Lock()
+		h.served = append(h.served, req.URL.Path)
+		h.mu.Unlock()
+		<-h.release
+	}))
+
+	return h
+}
+
+// serve serves a request for `path` in the background, waiting until
+// `ready` reports true.
+func (h *admissionHarness) serve(path string, ready func() bool) {
+	h.wg.Add(1)
+
+	go func() {
+		defer h.wg.Done()
+		res := httptest.NewRecorder()
+		h.handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		h.codes.Store(path, res.Code)
+	}()
+
+	for !ready() {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// queued returns a func reporting whether a request is served and `n`
+// are queued.
+func (h *admissionHarness) queued(n int) func() bool {
+	return func() bool {
+		h.Lock()
+		defer h.Unlock()
+
+		return 0 < h.active && n == len(h.queue)
+	}
+}
+
+// code returns the status code the request for `path` was answered
+// with.
+func (h *admissionHarness) code(path string) int {
+	code, _ := h.codes.Load(path)
+	status, _ := code.(int)
+	return status
+}
+
+// TestAdmissionPolicies ensures queued requests are admitted in the
+// order of the queue's policy, and overflowing requests are shed.
+func TestAdmissionPolicies(t *testing.T) {
+	for policy, expected := range map[QueuePolicy][]string{
+		QueueFIFO: {"/active", "/a", "/b"},
+		QueueLIFO: {"/active", "/c", "/b"},
+	} {
+		h := newAdmissionHarness(AdmissionOptions{MaxConcurrent: 1, MaxQueue: 2, QueueTimeout: time.Minute, Policy: policy})
+
+		h.serve("/active", h.queued(0))
+		h.serve("/a", h.queued(1))
+		h.serve("/b", h.queued(2))
+
+		if QueueFIFO == policy {
+			res := httptest.NewRecorder()
+			h.handler.ServeHTTP(res, httptest.NewRequest("GET", "/c", nil))
+			h.codes.Store("/c", res.Code)
+		} else {
+			h.serve("/c", func() bool { return 0 != h.code("/a") })
+		}
+
+		close(h.release)
+		h.wg.Wait()
+
+		if len(expected) != len(h.served) || expected[0] != h.served[0] || expected[1] != h.served[1] || expected[2] != h.served[2] {
+			t.Errorf("Expected policy %d to serve %v, got %v.", policy, expected, h.served)
+		}
+
+		shed := map[QueuePolicy]string{QueueFIFO: "/c", QueueLIFO: "/a"}[policy]
+
+		if http.StatusServiceUnavailable != h.code(shed) {
+			t.Errorf("Expected policy %d to shed %s, got %d.", policy, shed, h.code(shed))
+		}
+	}
+}
+
+// TestAdmissionQueueTimeout ensures requests waiting longer than the
+// queue timeout are shed.
+func TestAdmissionQueueTimeout(t *testing.T) {
+	h := newAdmissionHarness(AdmissionOptions{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond, RetryAfter: 1500 * time.Millisecond})
+	h.serve("/active", h.queued(0))
+
+	res := httptest.NewRecorder()
+	h.handler.ServeHTTP(res, httptest.NewRequest("GET", "/queued", nil))
+
+	if http.StatusServiceUnavailable != res.Code || "2" != res.Header().Get("Retry-After") {
+		t.Errorf("Expected the queued request to be shed with Retry-After 2, got %d %q.", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	close(h.release)
+	h.wg.Wait()
+
+	if 0 != h.active || 0 != len(h.queue) {
+		t.Errorf("Expected no requests to be active or queued, got %d and %d.", h.active, len(h.queue))
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// AssetManifest maps the names of static assets to fingerprinted
+// names containing a hash of their contents, i.e. `app.js` to
+// `app-3b1f09c2d4e5a6f7.js`. Because a fingerprinted name changes
+// whenever its asset does, fingerprinted assets can be cached by
+// clients indefinitely.
+type AssetManifest struct {
+	// prefix is the URL path prefix fingerprinted assets are served at.
+	prefix string
+	// fsys is the file system assets are read from.
+	fsys fs.FS
+	// paths maps asset names to fingerprinted names.
+	paths map[string]string
+	// assets maps fingerprinted names to asset names.
+	assets map[string]string
+}
+
+// FingerprintAssets hashes each file found within `fsys`, returning an
+// AssetManifest for assets served under the URL path `prefix`, i.e.
+// `/assets`. It is intended to be called once at startup, or at build
+// time with the resulting manifest written out as JSON.
+func FingerprintAssets(fsys fs.FS, prefix string) (*AssetManifest, error) {
+	manifest := &AssetManifest{
+		prefix: strings.TrimSuffix(prefix, "/"),
+		fsys:   fsys,
+		paths:  make(map[string]string),
+		assets: make(map[string]string),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if nil != err || entry.IsDir() || isPrecompressedSidecar(name) {
+			return err
+		}
+
+		file, err := fsys.Open(name)
+
+		if nil != err {
+			return err
+		}
+
+		defer file.Close()
+
+		hash := sha256.New()
+
+		if _, err := io.Copy(hash, file); nil != err {
+			return err
+		}
+
+		fingerprinted := fingerprintName(name, fmt.Sprintf("%x", hash.Sum(nil)[:8]))
+		manifest.paths[name] = fingerprinted
+		manifest.assets[fingerprinted] = name
+
+		return nil
+	})
+
+	if nil != err {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// AssetPath returns the URL path of the fingerprinted asset `name`,
+// i.e. `/assets/app-3b1f09c2d4e5a6f7.js` for `app.js`. Names missing
+// from the manifest are returned under the manifest's prefix as is.
+func (m *AssetManifest) AssetPath(name string) string {
+	name = strings.TrimPrefix(name, "/")
+
+	if fingerprinted, ok := m.paths[name]; ok {
+		name = fingerprinted
+	}
+
+	return m.prefix + "/" + name
+}
+
+// FuncMap returns template functions exposing AssetPath as `asset`,
+// i.e. `<script src="{{asset "app.js"}}"></script>`.
+func (m *AssetManifest) FuncMap() template.FuncMap {
+	return template.FuncMap{"asset": m.AssetPath}
+}
+
+// MarshalJSON encodes the manifest as an object mapping asset names to
+// their fingerprinted names.
+func (m *AssetManifest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.paths)
+}
+
+// ServeAssets returns a middleware function serving fingerprinted
+// assets under the manifest's prefix with immutable caching headers,
+// unless one of `opts.CacheRules` matches. Requests for assets by their
+// original names are not served.
+func (m *AssetManifest) ServeAssets(opts PublicFileOptions) dispatcher.MiddlewareHandler {
+	opts.Prefix = m.prefix
+	opts.IndexFiles = []string{}
+	opts.ListDirectories = nil
+	opts.CacheRules = append(append([]CacheRule(nil), opts.CacheRules...), CacheRule{CacheControl: ImmutableCacheControl})
+
+	return ServePublicFS(fingerprintedFS{m}, opts)
+}
+
+// fingerprintedFS is a file system exposing the assets of an
+// AssetManifest by their fingerprinted names.
+type fingerprintedFS struct {
+	manifest *AssetManifest
+}
+
+// Open opens the asset whose fingerprinted name is `name`, or the
+// precompressed sidecar of one.
+func (f fingerprintedFS) Open(name string) (fs.File, error) {
+	if "." == name {
+		return f.manifest.fsys.Open(name)
+	}
+
+	if asset, ok := f.manifest.assets[name]; ok {
+		return f.manifest.fsys.Open(asset)
+	}
+
+	for _, ext := range precompressedExtensions {
+		if asset, ok := f.manifest.assets[strings.TrimSuffix(name, ext)]; ok && strings.HasSuffix(name, ext) {
+			return f.manifest.fsys.Open(asset + ext)
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// fingerprintName inserts `hash` into the file name `name` before its
+// extension.
+func fingerprintName(name, hash string) string {
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), hash, ext)
+}
+
+// isPrecompressedSidecar reports whether `name` is a precompressed
+// sidecar file.
+func isPrecompressedSidecar(name string) bool {
+	for _, ext := range precompressedExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
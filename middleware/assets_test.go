@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestFingerprintAssets ensures fingerprinted assets are served at the
+// path returned by AssetPath with immutable caching headers.
+func TestFingerprintAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"js/app.js": &fstest.MapFile{Data: []byte("app()")},
+	}
+
+	manifest, err := FingerprintAssets(fsys, "/assets")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	assetPath := manifest.AssetPath("js/app.js")
+
+	if !strings.HasPrefix(assetPath, "/assets/js/app-") || !strings.HasSuffix(assetPath, ".js") {
+		t.Fatalf("Unexpected fingerprinted asset path %q.", assetPath)
+	}
+
+	middleware := manifest.ServeAssets(PublicFileOptions{})
+	res := httptest.NewRecorder()
+
+	if !middleware(res, httptest.NewRequest("GET", assetPath, nil)) || "app()" != res.Body.String() {
+		t.Fatalf("Expected fingerprinted asset to be served from %q.", assetPath)
+	}
+
+	if ImmutableCacheControl != res.Header().Get("Cache-Control") {
+		t.Errorf("Expected immutable Cache-Control, got %q.", res.Header().Get("Cache-Control"))
+	}
+
+	if middleware(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/js/app.js", nil)) {
+		t.Error("Expected asset not to be served by its original name.")
+	}
+}
+
+// TestServeAssetsCacheRules ensures user supplied cache rules take
+// precedence over the immutable default.
+func TestServeAssetsCacheRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":    &fstest.MapFile{Data: []byte("app()")},
+		"style.css": &fstest.MapFile{Data: []byte("body{}")},
+	}
+
+	manifest, err := FingerprintAssets(fsys, "/assets")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	middleware := manifest.ServeAssets(PublicFileOptions{
+		CacheRules: []CacheRule{{Pattern: ".css", CacheControl: "no-cache"}},
+	})
+
+	for name, expected := range map[string]string{"style.css": "no-cache", "app.js": ImmutableCacheControl} {
+		res := httptest.NewRecorder()
+		middleware(res, httptest.NewRequest("GET", manifest.AssetPath(name), nil))
+
+		if expected != res.Header().Get("Cache-Control") {
+			t.Errorf("Expected %s to be served with Cache-Control %q, got %q.", name, expected, res.Header().Get("Cache-Control"))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// requestPattern matches requests by method and path.
+type requestPattern struct {
+	methods []string
+	route   *dispatcher.Route
+}
+
+// compileRequestPattern compiles a pattern made of an optional, comma
+// separated list of methods and a route path, i.e. `/health`,
+// `GET /static/*` or `POST,PUT /webhooks/:provider`. Paths use the same
+// syntax as Routes.
+func compileRequestPattern(pattern string) requestPattern {
+	var compiled requestPattern
+
+	if methods, path, found := strings.Cut(strings.TrimSpace(pattern), " "); found {
+		for _, method := range strings.Split(methods, ",") {
+			compiled.methods = append(compiled.methods, strings.ToUpper(strings.TrimSpace(method)))
+		}
+
+		pattern = strings.TrimSpace(path)
+	}
+
+	compiled.route = dispatcher.NewRoute(pattern, false)
+	return compiled
+}
+
+// matches reports whether `req` matches the pattern.
+func (p requestPattern) matches(req *http.Request) bool {
+	if 0 < len(p.methods) {
+		found := false
+
+		for _, method := range p.methods {
+			found = found || method == req.Method
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	_, ok := p.route.Match(req.URL.Path)
+	return ok
+}
+
+// Only returns middleware running `middleware` only for requests
+// matching `pattern`, i.e.
+//
+//	router.RegisterMiddleware(middleware.Only("/admin/*", requireAdmin))
+//
+// Patterns are route paths optionally preceded by a comma separated
+// list of methods, i.e. `POST /webhooks/:provider`.
+func Only(pattern string, middleware dispatcher.Middleware) dispatcher.MiddlewareHandler {
+	compiled := compileRequestPattern(pattern)
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		if !compiled.matches(req) {
+			return false
+		}
+
+		return middleware.ServeHTTP(res, req)
+	}
+}
+
+// Except returns middleware running `middleware` for all requests but
+// those matching one of `patterns`, i.e. to skip health checks and
+// static assets:
+//
+//	router.RegisterMiddleware(middleware.Except([]string{"GET /health", "/static/*"}, logger))
+//
+// Patterns take the same form as for Only.
+func Except(patterns []string, middleware dispatcher.Middleware) dispatcher.MiddlewareHandler {
+	compiled := make([]requestPattern, len(patterns))
+
+	for i, pattern := range patterns {
+		compiled[i] = compileRequestPattern(pattern)
+	}
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		for _, pattern := range compiled {
+			if pattern.matches(req) {
+				return false
+			}
+		}
+
+		return middleware.ServeHTTP(res, req)
+	}
+}
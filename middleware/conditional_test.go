@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestOnlyAndExcept ensures middleware runs only for matching, or all
+// but matching, requests.
+func TestOnlyAndExcept(t *testing.T) {
+	var calls int
+
+	counter := dispatcher.MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+		calls++
+		return false
+	})
+
+	tests := []struct {
+		middleware dispatcher.MiddlewareHandler
+		method     string
+		path       string
+		expected   bool
+	}{
+		{Only("/admin/*", counter), "GET", "/admin/users", true},
+		{Only("/admin/*", counter), "GET", "/users", false},
+		{Only("POST,PUT /webhooks/:provider", counter), "POST", "/webhooks/github", true},
+		{Only("POST,PUT /webhooks/:provider", counter), "GET", "/webhooks/github", false},
+		{Except([]string{"GET /health", "/static/*"}, counter), "GET", "/health", false},
+		{Except([]string{"GET /health", "/static/*"}, counter), "POST", "/health", true},
+		{Except([]string{"GET /health", "/static/*"}, counter), "GET", "/static/app.js", false},
+		{Except([]string{"GET /health", "/static/*"}, counter), "GET", "/users", true},
+	}
+
+	for _, test := range tests {
+		calls = 0
+		test.middleware(httptest.NewRecorder(), httptest.NewRequest(test.method, test.path, nil))
+
+		if ran := 1 == calls; test.expected != ran {
+			t.Errorf("Expected middleware to run %v for %v %v, got %v.", test.expected, test.method, test.path, ran)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// CORSPolicyMeta is the Route tag naming the CORS policy of a Route,
+// i.e.
+//
+//	router.Post("/webhooks", WebhookHandler).Meta(middleware.CORSPolicyMeta, "partners")
+const CORSPolicyMeta = "cors"
+
+// MaxCORSMaxAge is the longest time preflight responses are cached by
+// browsers for. Longer MaxAges are capped to it, as browsers would.
+const MaxCORSMaxAge = 2 * time.Hour
+
+// ErrCORSWildcardCredentials is the value NewCORS and Policy panic
+// with for a policy allowing any origin with credentials, which would
+// let any website make credentialed requests.
+var ErrCORSWildcardCredentials = errors.New("middleware: a CORS policy allowing any origin can not allow credentials")
+
+// maxCORSPreflights is the number of preflight decisions a CORS caches
+// before forgetting them.
+const maxCORSPreflights = 1024
+
+// CORSOptions is a policy allowing cross-origin requests.
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed, i.e.
+	// `https://app.example.com`, or `*` for any.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed. By default GET, HEAD and
+	// POST are.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed, or `*` for any.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials allows requests carrying cookies or credentials.
+	// It can not be combined with an AllowedOrigins of `*`.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses for, at
+	// most MaxCORSMaxAge.
+	MaxAge time.Duration
+}
+
+// CORS answers cross-origin requests to the Routes of a Router with a
+// default policy, which Routes tagged with CORSPolicyMeta override
+// with named policies, i.e.
+//
+//	cors := middleware.NewCORS(router, middleware.CORSOptions{AllowedOrigins: []string{"https://app.example.com"}}).
+//		Policy("public", middleware.CORSOptions{AllowedOrigins: []string{"*"}, MaxAge: time.Hour})
+//
+//	router.Get("/status", StatusHandler).Meta(middleware.CORSPolicyMeta, "public")
+//	router.RegisterWrapper(cors.Wrap)
+//
+// Preflight requests are answered for the policy of the Route the
+// request they precede would be served by, and the decisions are
+// cached.
+type CORS struct {
+	sync.Mutex
+	router   *dispatcher.Router
+	defaults CORSOptions
+	policies map[string]CORSOptions
+	// preflights caches the headers answering preflight requests, nil
+	// if they are denied, by policy, origin, method and headers.
+	preflights map[string]http.Header
+}
+
+// NewCORS creates a CORS answering requests to the Routes of `router`
+// with the policy `defaults`. NewCORS panics with
+// ErrCORSWildcardCredentials if `defaults` allows any origin with
+// credentials.
+func NewCORS(router *dispatcher.Router, defaults CORSOptions) *CORS {
+	defaults.validate()
+
+	return &CORS{
+		router:     router,
+		defaults:   defaults,
+		policies:   make(map[string]CORSOptions),
+		preflights: make(map[string]http.Header),
+	}
+}
+
+// Policy registers the policy `name`, applied to Routes tagged with it
+// under CORSPolicyMeta. Policies may be stricter or looser than the
+// default. Policy panics with ErrCORSWildcardCredentials if `opts`
+// allows any origin with credentials.
+func (c *CORS) Policy(name string, opts CORSOptions) *CORS {
+	opts.validate()
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.policies[name] = opts
+	clear(c.preflights)
+	return c
+}
+
+// Wrap returns a handler answering preflight requests and adding the
+// CORS headers of their policy to the responses to cross-origin
+// requests passed to `next`.
+func (c *CORS) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+
+		if "" == origin {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		header := res.Header()
+		header.Add("Vary", "Origin")
+		requested := req.Header.Get("Access-Control-Request-Method")
+
+		if http.MethodOptions == req.Method && "" != requested {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+
+			for key, values := range c.preflight(req, origin, requested) {
+				header[key] = values
+			}
+
+			res.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if _, opts := c.policy(req.Method, req.URL.Path); allowsOrigin(opts, origin) {
+			setAllowOrigin(header, opts, origin)
+
+			if 0 < len(opts.ExposedHeaders) {
+				header.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+			}
+		}
+
+		next.ServeHTTP(res, req)
+	})
+}
+
+// policy returns the name and options of the policy of the Route
+// serving `method` requests to `path`. The default policy is unnamed.
+func (c *CORS) policy(method, path string) (string, CORSOptions) {
+	route, _, ok := c.router.Lookup(method, path)
+
+	c.Lock()
+	defer c.Unlock()
+
+	if ok {
+		for _, name := range route.Meta(CORSPolicyMeta) {
+			if opts, ok := c.policies[name]; ok {
+				return name, opts
+			}
+		}
+	}
+
+	return "", c.defaults
+}
+
+// preflight returns the headers answering the preflight of a `method`
+// request from `origin`, which are nil if it is denied.
+func (c *CORS) preflight(req *http.Request, origin, method string) http.Header {
+	name, opts := c.policy(method, req.URL.Path)
+	headers := strings.ToLower(req.Header.Get("Access-Control-Request-Headers"))
+	key := name + "\n" + origin + "\n" + method + "\n" + headers
+
+	c.Lock()
+	cached, ok := c.preflights[key]
+	c.Unlock()
+
+	if ok {
+		return cached
+	}
+
+	var allowed http.Header
+
+	if allowsOrigin(opts, origin) && allowsMethod(opts, method) && allowsHeaders(opts, headers) {
+		allowed = make(http.Header)
+		setAllowOrigin(allowed, opts, origin)
+		allowed.Set("Access-Control-Allow-Methods", method)
+
+		if "" != headers {
+			allowed.Set("Access-Control-Allow-Headers", headers)
+		}
+
+		if maxAge := min(opts.MaxAge, MaxCORSMaxAge); 0 < maxAge {
+			allowed.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if maxCORSPreflights <= len(c.preflights) {
+		clear(c.preflights)
+	}
+
+	c.preflights[key] = allowed
+	return allowed
+}
+
+// validate panics if the policy allows any origin with credentials.
+func (opts CORSOptions) validate() {
+	if opts.AllowCredentials && containsFold(opts.AllowedOrigins, "*") {
+		panic(ErrCORSWildcardCredentials)
+	}
+}
+
+// allowsOrigin reports whether `opts` allows requests from `origin`.
+func allowsOrigin(opts CORSOptions, origin string) bool {
+	for _, allowed := range opts.AllowedOrigins {
+		if "*" == allowed || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowsMethod reports whether `opts` allows `method` requests.
+func allowsMethod(opts CORSOptions, method string) bool {
+	if 0 == len(opts.AllowedMethods) {
+		return http.MethodGet == method || http.MethodHead == method || http.MethodPost == method
+	}
+
+	return containsFold(opts.AllowedMethods, method)
+}
+
+// allowsHeaders reports whether `opts` allows the comma separated
+// request `headers`.
+func allowsHeaders(opts CORSOptions, headers string) bool {
+	if "" == headers || containsFold(opts.AllowedHeaders, "*") {
+		return true
+	}
+
+	for _, header := range strings.Split(headers, ",") {
+		if !containsFold(opts.AllowedHeaders, strings.TrimSpace(header)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setAllowOrigin sets the headers allowing `origin` by `opts`.
+func setAllowOrigin(header http.Header, opts CORSOptions, origin string) {
+	if containsFold(opts.AllowedOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if opts.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// generateCORSRequest returns a request from `origin`, a preflight of
+// a `requested` request if set.
+func generateCORSRequest(method, target, origin, requested string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Origin", origin)
+
+	if "" != requested {
+		req.Header.Set("Access-Control-Request-Method", requested)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	}
+
+	return req
+}
+
+// TestCORS ensures Routes are answered with the default policy or the
+// policy they are tagged with.
+func TestCORS(t *testing.T) {
+	router := dispatcher.NewRouter()
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	router.Get("/account", ok)
+	router.Put("/account", ok)
+	router.Get("/status", ok).Meta(CORSPolicyMeta, "public")
+
+	cors := NewCORS(router, CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           24 * time.Hour,
+	}).Policy("public", CORSOptions{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Build"}})
+
+	router.RegisterWrapper(cors.Wrap)
+
+	for _, test := range []struct {
+		req                   *http.Request
+		origin, maxAge, build string
+	}{
+		{generateCORSRequest("GET", "/account", "https://app.example.com", ""), "https://app.example.com", "", ""},
+		{generateCORSRequest("GET", "/account", "https://evil.example.com", ""), "", "", ""},
+		{generateCORSRequest("GET", "/status", "https://evil.example.com", ""), "*", "", "X-Build"},
+		{generateCORSRequest("OPTIONS", "/account", "https://app.example.com", "PUT"), "https://app.example.com", "7200", ""},
+		{generateCORSRequest("OPTIONS", "/status", "https://app.example.com", "GET"), "", "", ""},
+	} {
+		for i := 0; i < 2; i++ {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, test.req)
+			header := res.Header()
+
+			if test.origin != header.Get("Access-Control-Allow-Origin") || test.maxAge != header.Get("Access-Control-Max-Age") || test.build != header.Get("Access-Control-Expose-Headers") {
+				t.Errorf("Expected %s %s to be allowed for %q, got %v.", test.req.Method, test.req.URL.Path, test.origin, header)
+			}
+		}
+	}
+
+	if 2 != len(cors.preflights) {
+		t.Errorf("Expected the preflight decisions to be cached, found %d.", len(cors.preflights))
+	}
+}
+
+// TestCORSWildcardCredentials ensures policies allowing any origin with
+// credentials are rejected.
+func TestCORSWildcardCredentials(t *testing.T) {
+	wildcard := CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+
+	for name, register := range map[string]func(){
+		"default": func() { NewCORS(dispatcher.NewRouter(), wildcard) },
+		"policy":  func() { NewCORS(dispatcher.NewRouter(), CORSOptions{}).Policy("public", wildcard) },
+	} {
+		func() {
+			defer func() {
+				if ErrCORSWildcardCredentials != recover() {
+					t.Errorf("Expected the %s policy to be rejected.", name)
+				}
+			}()
+
+			register()
+		}()
+	}
+}
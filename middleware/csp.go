@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Headers a CSP is sent in.
+const (
+	CSPHeader           = "Content-Security-Policy"
+	CSPReportOnlyHeader = "Content-Security-Policy-Report-Only"
+)
+
+// CSPDirective is a directive of a Content-Security-Policy.
+type CSPDirective string
+
+// Directives of a Content-Security-Policy.
+const (
+	DefaultSrc     CSPDirective = "default-src"
+	ScriptSrc      CSPDirective = "script-src"
+	StyleSrc       CSPDirective = "style-src"
+	ImgSrc         CSPDirective = "img-src"
+	ConnectSrc     CSPDirective = "connect-src"
+	FontSrc        CSPDirective = "font-src"
+	ObjectSrc      CSPDirective = "object-src"
+	MediaSrc       CSPDirective = "media-src"
+	FrameSrc       CSPDirective = "frame-src"
+	WorkerSrc      CSPDirective = "worker-src"
+	ManifestSrc    CSPDirective = "manifest-src"
+	FrameAncestors CSPDirective = "frame-ancestors"
+	BaseURI        CSPDirective = "base-uri"
+	FormAction     CSPDirective = "form-action"
+	Sandbox        CSPDirective = "sandbox"
+	ReportURI      CSPDirective = "report-uri"
+)
+
+// Source keywords of a Content-Security-Policy.
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+)
+
+// CSP builds a Content-Security-Policy, sent on the responses to the
+// requests it wraps, i.e.
+//
+//	policy := middleware.NewCSP().
+//		Add(middleware.DefaultSrc, middleware.CSPSelf).
+//		Add(middleware.ImgSrc, middleware.CSPSelf, "https://cdn.example.com").
+//		Add(middleware.ObjectSrc, middleware.CSPNone).
+//		Nonce(middleware.ScriptSrc, middleware.StyleSrc).
+//		RegisterReports(router, "/csp-reports", logViolation)
+//
+//	router.RegisterWrapper(policy.Wrap)
+//
+// A CSP must not be changed once it wraps requests.
+type CSP struct {
+	directives []CSPDirective
+	sources    map[CSPDirective][]string
+	nonced     []CSPDirective
+	reportOnly bool
+	upgrade    bool
+}
+
+// CSPReport is a violation of a CSP reported by a browser.
+type CSPReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	BlockedURI         string `json:"blocked-uri"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+}
+
+// maxCSPReportSize is the size of the largest CSPReport body read.
+const maxCSPReportSize = 64 << 10
+
+// cspNonceContextKey is the context key the CSP nonce of a request is
+// stored under.
+type cspNonceContextKey struct{}
+
+// NewCSP creates an empty CSP.
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[CSPDirective][]string)}
+}
+
+// Add adds `sources` to the `directive` of the CSP, i.e. CSPSelf or
+// `https://cdn.example.com`. Directives are sent in the order they were
+// first added.
+func (p *CSP) Add(directive CSPDirective, sources ...string) *CSP {
+	if _, ok := p.sources[directive]; !ok {
+		p.directives = append(p.directives, directive)
+	}
+
+	p.sources[directive] = append(p.sources[directive], sources...)
+	return p
+}
+
+// Nonce adds a nonce generated for each request to the `directives` of
+// the CSP, allowing the inline scripts and styles carrying it. The
+// nonce is read with CSPNonce, i.e. to render templates with
+// `<script nonce="{{.Nonce}}">`.
+func (p *CSP) Nonce(directives ...CSPDirective) *CSP {
+	for _, directive := range directives {
+		p.Add(directive)
+		p.nonced = append(p.nonced, directive)
+	}
+
+	return p
+}
+
+// UpgradeInsecureRequests makes browsers request the `http` resources
+// of pages over `https`.
+func (p *CSP) UpgradeInsecureRequests() *CSP {
+	p.upgrade = true
+	return p
+}
+
+// ReportOnly sends the CSP in the CSPReportOnlyHeader, so violations
+// are reported but not blocked, i.e. while trialling a policy.
+func (p *CSP) ReportOnly() *CSP {
+	p.reportOnly = true
+	return p
+}
+
+// RegisterReports registers a Route for POST requests to `path` with
+// `router` which passes the violations browsers report to `fn`, and
+// has the CSP report them there.
+func (p *CSP) RegisterReports(router *dispatcher.Router, path string, fn func(req *http.Request, report CSPReport)) *CSP {
+	router.Post(path, p.ReportHandler(fn))
+	return p.Add(ReportURI, path)
+}
+
+// ReportHandler returns a handler passing the violations browsers
+// report to `fn`, answering with 204 No Content. Malformed reports are
+// answered with 400 Bad Request.
+func (p *CSP) ReportHandler(fn func(req *http.Request, report CSPReport)) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Report CSPReport `json:"csp-report"`
+		}
+
+		if err := json.NewDecoder(io.LimitReader(req.Body, maxCSPReportSize)).Decode(&body); nil != err {
+			http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		fn(req, body.Report)
+		res.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Wrap returns a handler sending the CSP on the response to each
+// request before passing it to `next`, with a fresh nonce in its
+// context if the CSP uses one.
+func (p *CSP) Wrap(next http.Handler) http.Handler {
+	header := CSPHeader
+
+	if p.reportOnly {
+		header = CSPReportOnlyHeader
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		nonce := ""
+
+		if 0 < len(p.nonced) {
+			nonce = generateNonce()
+			req = req.WithContext(context.WithValue(req.Context(), cspNonceContextKey{}, nonce))
+		}
+
+		res.Header().Set(header, p.Policy(nonce))
+		next.ServeHTTP(res, req)
+	})
+}
+
+// Policy formats the CSP as the value of its header, with `nonce`
+// added to the directives using one.
+func (p *CSP) Policy(nonce string) string {
+	var directives []string
+
+	for _, directive := range p.directives {
+		sources := p.sources[directive]
+
+		if "" != nonce && containsDirective(p.nonced, directive) {
+			sources = append(sources[:len(sources):len(sources)], "'nonce-"+nonce+"'")
+		}
+
+		directives = append(directives, strings.TrimSpace(string(directive)+" "+strings.Join(sources, " ")))
+	}
+
+	if p.upgrade {
+		directives = append(directives, "upgrade-insecure-requests")
+	}
+
+	return strings.Join(directives, "; ")
+}
+
+// CSPNonce returns the nonce of the CSP of the request whose context is
+// `ctx`, or an empty string if its CSP uses none.
+func CSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceContextKey{}).(string)
+	return nonce
+}
+
+// generateNonce returns a random, base64 encoded nonce.
+func generateNonce() string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(nonce)
+}
+
+// containsDirective reports whether `directives` contains `directive`.
+func containsDirective(directives []CSPDirective, directive CSPDirective) bool {
+	for _, candidate := range directives {
+		if candidate == directive {
+			return true
+		}
+	}
+
+	return false
+}
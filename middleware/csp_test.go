@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestCSP ensures policies are sent with a fresh nonce per request,
+// exposed to handlers.
+func TestCSP(t *testing.T) {
+	policy := NewCSP().
+		Add(DefaultSrc, CSPSelf).
+		Add(ImgSrc, CSPSelf, "https://cdn.example.com").
+		Nonce(ScriptSrc).
+		UpgradeInsecureRequests()
+
+	var nonces []string
+
+	handler := policy.Wrap(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		nonces = append(nonces, CSPNonce(req.Context()))
+	}))
+
+	var headers []string
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+		headers = append(headers, res.Header().Get(CSPHeader))
+	}
+
+	expected := "default-src 'self'; img-src 'self' https://cdn.example.com; script-src 'nonce-" + nonces[0] + "'; upgrade-insecure-requests"
+
+	if expected != headers[0] {
+		t.Errorf("Expected the policy %q, got %q.", expected, headers[0])
+	}
+
+	if "" == nonces[0] || nonces[0] == nonces[1] || !strings.Contains(headers[1], nonces[1]) {
+		t.Errorf("Expected a fresh nonce per request, got %v.", nonces)
+	}
+}
+
+// TestCSPReports ensures report-only policies point browsers at the
+// registered report endpoint, which passes violations on.
+func TestCSPReports(t *testing.T) {
+	var reported CSPReport
+
+	router := dispatcher.NewRouter()
+	policy := NewCSP().Add(DefaultSrc, CSPSelf).ReportOnly().RegisterReports(router, "/csp-reports", func(req *http.Request, report CSPReport) {
+		reported = report
+	})
+
+	res := httptest.NewRecorder()
+	policy.Wrap(router).ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+	if "default-src 'self'; report-uri /csp-reports" != res.Header().Get(CSPReportOnlyHeader) || "" != res.Header().Get(CSPHeader) {
+		t.Errorf("Expected a report-only policy, got %q.", res.Header().Get(CSPReportOnlyHeader))
+	}
+
+	body := `{"csp-report": {"document-uri": "https://example.com/", "violated-directive": "default-src", "blocked-uri": "https://evil.example.com/x.js", "line-number": 3}}`
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/csp-reports", strings.NewReader(body)))
+
+	if http.StatusNoContent != res.Code || "https://evil.example.com/x.js" != reported.BlockedURI || 3 != reported.LineNumber {
+		t.Errorf("Expected the violation to be reported, got %d %+v.", res.Code, reported)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/csp-reports", strings.NewReader("{")))
+
+	if http.StatusBadRequest != res.Code {
+		t.Errorf("Expected a malformed report to be rejected, got %d.", res.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DirectoryListing is the data passed to the template rendering a
+// directory's contents.
+type DirectoryListing struct {
+	Path    string
+	Entries []DirectoryEntry
+}
+
+// DirectoryEntry describes a file or directory within a
+// DirectoryListing.
+type DirectoryEntry struct {
+	Name    string
+	Href    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// DefaultListingTemplate is used to render directory listings when
+// PublicFileOptions.ListingTemplate is nil.
+var DefaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{range .Entries}}<tr><td><a href="{{.Href}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{.Size}}{{end}}</td><td>{{if not .ModTime.IsZero}}{{.ModTime.Format "2006-01-02 15:04:05"}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// serveDirectoryListing renders the contents of the directory `name`
+// found in `fsys` using `tmpl`, returning false if the directory
+// could not be read. Hidden entries are only listed if `dotFiles` is
+// AllowDotFiles.
+func serveDirectoryListing(res http.ResponseWriter, req *http.Request, fsys fs.FS, name string, tmpl *template.Template, dotFiles DotFilePolicy) bool {
+	entries, err := fs.ReadDir(fsys, name)
+
+	if nil != err {
+		return false
+	}
+
+	listing := DirectoryListing{Path: req.URL.Path}
+
+	for _, entry := range entries {
+		if AllowDotFiles != dotFiles && isHiddenPath(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if nil != err {
+			continue
+		}
+
+		href := (&url.URL{Path: path.Join(req.URL.Path, entry.Name())}).EscapedPath()
+
+		if entry.IsDir() {
+			href += "/"
+		}
+
+		listing.Entries = append(listing.Entries, DirectoryEntry{
+			Name:    entry.Name(),
+			Href:    href,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   entry.IsDir(),
+		})
+	}
+
+	var buffer bytes.Buffer
+
+	if err := tmpl.Execute(&buffer, listing); nil != err {
+		return false
+	}
+
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if dispatcher.HEAD != req.Method {
+		buffer.WriteTo(res)
+	}
+
+	return true
+}
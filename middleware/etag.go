@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultETagMaxBodySize is the size of the largest response body the
+// ETag wrapper buffers when ETagOptions.MaxBodySize is unset.
+const DefaultETagMaxBodySize = 1 << 20
+
+// ETagOptions configures the ETag wrapper.
+type ETagOptions struct {
+	// MaxBodySize is the size of the largest response body buffered
+	// to be hashed. Larger responses are streamed without an ETag.
+	MaxBodySize int64
+	// Weak marks generated entity tags as weak, for responses that are
+	// semantically but not byte-for-byte equivalent (i.e. when a
+	// compression layer sits in front of the wrapper).
+	Weak bool
+}
+
+// ETag returns a HandlerWrapper generating entity tags for successful
+// responses to GET and HEAD requests by hashing their bodies, and
+// answering requests whose If-None-Match header matches with 304 Not
+// Modified, so polling clients do not download unchanged responses.
+// Responses are buffered up to ETagOptions.MaxBodySize; larger or
+// flushed responses are passed through. Entity tags set by handlers
+// are kept and still honored.
+func ETag(opts ...ETagOptions) dispatcher.HandlerWrapper {
+	var options ETagOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	if 0 == options.MaxBodySize {
+		options.MaxBodySize = DefaultETagMaxBodySize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if http.MethodGet != req.Method && http.MethodHead != req.Method {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			buffer := &etagBuffer{ResponseWriter: res, limit: options.MaxBodySize}
+			next.ServeHTTP(buffer, req)
+
+			if buffer.passthrough {
+				return
+			}
+
+			if 0 == buffer.status {
+				buffer.status = http.StatusOK
+			}
+
+			header := res.Header()
+
+			if http.StatusOK == buffer.status && "" == header.Get("ETag") {
+				sum := sha256.Sum256(buffer.body.Bytes())
+				etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+				if options.Weak {
+					etag = "W/" + etag
+				}
+
+				header.Set("ETag", etag)
+			}
+
+			if http.StatusOK == buffer.status && isNotModified(req, header.Get("ETag"), time.Time{}) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				res.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			if http.MethodGet == req.Method && "" == header.Get("Content-Length") {
+				header.Set("Content-Length", strconv.Itoa(buffer.body.Len()))
+			}
+
+			res.WriteHeader(buffer.status)
+			res.Write(buffer.body.Bytes())
+		})
+	}
+}
+
+// etagBuffer holds back a response so its body can be hashed, passing
+// it through instead once it grows past `limit` or is flushed.
+type etagBuffer struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	limit       int64
+	passthrough bool
+}
+
+// WriteHeader records the response's status.
+func (e *etagBuffer) WriteHeader(status int) {
+	if e.passthrough {
+		e.ResponseWriter.WriteHeader(status)
+	} else if 0 == e.status {
+		e.status = status
+	}
+}
+
+// Write buffers `data`, passing the response through if it grows past
+// the limit.
+func (e *etagBuffer) Write(data []byte) (int, error) {
+	if !e.passthrough && int64(e.body.Len()+len(data)) > e.limit {
+		e.release()
+	}
+
+	if e.passthrough {
+		return e.ResponseWriter.Write(data)
+	}
+
+	return e.body.Write(data)
+}
+
+// Flush passes the response through, as the handler is streaming it.
+func (e *etagBuffer) Flush() {
+	e.release()
+	http.NewResponseController(e.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter.
+func (e *etagBuffer) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// release writes the buffered response and passes what follows
+// through.
+func (e *etagBuffer) release() {
+	if e.passthrough {
+		return
+	}
+
+	e.passthrough = true
+
+	if 0 == e.status {
+		e.status = http.StatusOK
+	}
+
+	e.ResponseWriter.WriteHeader(e.status)
+	e.ResponseWriter.Write(e.body.Bytes())
+	e.body = bytes.Buffer{}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestETag ensures entity tags are generated for dynamic responses and
+// matching requests are answered with 304 Not Modified.
+func TestETag(t *testing.T) {
+	body := "hello world"
+
+	handler := ETag()(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "text/plain")
+		res.Write([]byte(body))
+	}))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+	etag := res.Header().Get("ETag")
+
+	if "" == etag || body != res.Body.String() {
+		t.Fatalf("Expected an ETag and the body, got %q %q.", etag, res.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if http.StatusNotModified != res.Code || 0 != res.Body.Len() {
+		t.Errorf("Expected 304 without a body, got %d %q.", res.Code, res.Body.String())
+	}
+
+	body = "goodbye world"
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if http.StatusOK != res.Code || etag == res.Header().Get("ETag") {
+		t.Errorf("Expected a changed body to get a new ETag, got %d %q.", res.Code, res.Header().Get("ETag"))
+	}
+}
+
+// TestETagPassthrough ensures large, flushed and unsuccessful
+// responses are passed through without entity tags.
+func TestETagPassthrough(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"large": func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(strings.Repeat("a", 64)))
+			res.Write([]byte(strings.Repeat("b", 64)))
+		},
+		"flushed": func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("event"))
+			http.NewResponseController(res).Flush()
+		},
+		"error": func(res http.ResponseWriter, req *http.Request) {
+			http.Error(res, "failed", http.StatusInternalServerError)
+		},
+	}
+
+	for name, handler := range handlers {
+		res := httptest.NewRecorder()
+		ETag(ETagOptions{MaxBodySize: 100})(handler).ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		if "" != res.Header().Get("ETag") || 0 == res.Body.Len() {
+			t.Errorf("Expected %v response to be passed through without an ETag, got %q %q.", name, res.Header().Get("ETag"), res.Body.String())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"container/list"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// FileCache keeps the contents of small, frequently requested public
+// files in memory, evicting the least recently used files once its
+// total size is exceeded. Cached files are invalidated when their size
+// or modification time changes. A FileCache is safe for concurrent use
+// and may be shared between middleware serving the same file system.
+type FileCache struct {
+	sync.Mutex
+	// maxEntrySize is the size of the largest file that will be cached.
+	maxEntrySize int64
+	// maxBytes is the total size of the files the cache may hold.
+	maxBytes int64
+	// size is the total size of the files currently cached.
+	size int64
+	// entries maps file names to elements of `order`.
+	entries map[string]*list.Element
+	// order holds cached files, most recently used first.
+	order *list.List
+}
+
+// fileCacheEntry is a file held by a FileCache.
+type fileCacheEntry struct {
+	name    string
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+// NewFileCache creates a new FileCache holding files no larger than
+// `maxEntrySize` bytes, up to a total of `maxBytes` bytes.
+func NewFileCache(maxEntrySize, maxBytes int64) *FileCache {
+	return &FileCache{
+		maxEntrySize: maxEntrySize,
+		maxBytes:     maxBytes,
+		entries:      make(map[string]*list.Element),
+		order:        list.New(),
+	}
+}
+
+// Len returns the number of files cached.
+func (c *FileCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.order.Len()
+}
+
+// Size returns the total size of the files cached.
+func (c *FileCache) Size() int64 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.size
+}
+
+// contents returns the contents of the file `name`, reading them from
+// `file` and caching them if the cached copy is missing or stale. The
+// boolean returned is false if the file is too large to be cached.
+func (c *FileCache) contents(name string, file io.Reader, stat fs.FileInfo) ([]byte, bool, error) {
+	if stat.Size() > c.maxEntrySize || stat.Size() > c.maxBytes {
+		return nil, false, nil
+	}
+
+	c.Lock()
+
+	if element, ok := c.entries[name]; ok {
+		entry := element.Value.(*fileCacheEntry)
+
+		if entry.size == stat.Size() && entry.modTime.Equal(stat.ModTime()) {
+			c.order.MoveToFront(element)
+			c.Unlock()
+			return entry.data, true, nil
+		}
+
+		c.remove(element)
+	}
+
+	c.Unlock()
+
+	data, err := io.ReadAll(file)
+
+	if nil != err {
+		return nil, false, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if element, ok := c.entries[name]; ok {
+		c.remove(element)
+	}
+
+	c.entries[name] = c.order.PushFront(&fileCacheEntry{name, data, stat.Size(), stat.ModTime()})
+	c.size += stat.Size()
+
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+
+	return data, true, nil
+}
+
+// remove evicts the cached file held by `element`. The cache must be
+// locked by the caller.
+func (c *FileCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*fileCacheEntry)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+}
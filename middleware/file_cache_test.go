@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestFileCacheEviction ensures the least recently used files are
+// evicted once the cache's total size is exceeded, and files larger
+// than the maximum entry size are never cached.
+func TestFileCacheEviction(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     &fstest.MapFile{Data: []byte("aaaa")},
+		"b.txt":     &fstest.MapFile{Data: []byte("bbbb")},
+		"c.txt":     &fstest.MapFile{Data: []byte("cccc")},
+		"large.txt": &fstest.MapFile{Data: []byte("llllllllll")},
+	}
+
+	cache := NewFileCache(8, 8)
+	middleware := ServePublicFS(fsys, PublicFileOptions{Cache: cache})
+
+	for _, name := range []string{"/a.txt", "/b.txt", "/a.txt", "/c.txt", "/large.txt"} {
+		middleware(httptest.NewRecorder(), httptest.NewRequest("GET", name, nil))
+	}
+
+	if 2 != cache.Len() || 8 != cache.Size() {
+		t.Fatalf("Expected 2 files totalling 8 bytes to be cached, found %d totalling %d.", cache.Len(), cache.Size())
+	}
+
+	if _, ok := cache.entries["b.txt"]; ok {
+		t.Error("Expected least recently used file to be evicted.")
+	}
+}
+
+// TestFileCacheInvalidation ensures cached files are refreshed when
+// their modification time changes.
+func TestFileCacheInvalidation(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("old"), ModTime: time.Unix(1, 0)}
+	middleware := ServePublicFS(fstest.MapFS{"file.txt": file}, PublicFileOptions{Cache: NewFileCache(16, 16)})
+
+	middleware(httptest.NewRecorder(), httptest.NewRequest("GET", "/file.txt", nil))
+	file.Data, file.ModTime = []byte("new"), time.Unix(2, 0)
+
+	res := httptest.NewRecorder()
+	middleware(res, httptest.NewRequest("GET", "/file.txt", nil))
+
+	if "new" != res.Body.String() {
+		t.Errorf("Expected stale cached file to be refreshed, got %q.", res.Body.String())
+	}
+}
@@ -0,0 +1,280 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Defaults used for IdempotencyOptions left unset.
+const (
+	DefaultIdempotencyTTL         = 24 * time.Hour
+	DefaultIdempotencyMaxBodySize = 1 << 20
+)
+
+// Headers read and written by the Idempotency wrapper.
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set to `true` on replayed responses.
+	IdempotentReplayHeader = "Idempotent-Replayed"
+)
+
+// memoryStoreSweepInterval is how often the in-memory stores drop all
+// expired entries, which are otherwise only dropped when looked up.
+const memoryStoreSweepInterval = time.Minute
+
+// IdempotencyStore is implemented by the stores the Idempotency
+// wrapper keeps responses in, allowing them to be shared between
+// processes. Reserving a key must be atomic, so only one of several
+// concurrent requests with the same key is served.
+type IdempotencyStore interface {
+	// Reserve reserves `key` for a request being served, whose payload
+	// has the fingerprint `fingerprint`, for up to `ttl`. If `key` is
+	// held already, the fingerprint it was reserved with is returned
+	// along with the response stored under it, if any, and the key is
+	// not reserved.
+	Reserve(key, fingerprint string, ttl time.Duration) (response *CachedResponse, stored string, reserved bool)
+	// Complete stores `response` under the reserved `key` until it
+	// expires.
+	Complete(key string, response *CachedResponse)
+	// Release releases the reserved `key` without storing a response,
+	// so the request can be retried.
+	Release(key string)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+type MemoryIdempotencyStore struct {
+	sync.Mutex
+	// entries maps keys to their responses, which are nil while the
+	// keys are reserved.
+	entries map[string]*idempotencyEntry
+	// swept is when expired entries were last dropped.
+	swept time.Time
+}
+
+// idempotencyEntry is a key held by a MemoryIdempotencyStore.
+type idempotencyEntry struct {
+	response    *CachedResponse
+	fingerprint string
+	expires     time.Time
+}
+
+// IdempotencyOptions configures the Idempotency wrapper.
+type IdempotencyOptions struct {
+	// TTL is how long responses are replayed for.
+	TTL time.Duration
+	// Methods are the methods of the requests made idempotent. By
+	// default POST and PATCH requests are.
+	Methods []string
+	// MaxBodySize is the size of the largest response body stored.
+	// Requests with larger responses may be retried.
+	MaxBodySize int64
+	// Key returns the key `req` is stored under, i.e. to scope keys
+	// by client. By default keys are scoped by method and URL.
+	Key func(req *http.Request) string
+}
+
+// NewMemoryIdempotencyStore creates a new MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+// Reserve reserves `key`, unless it holds an unexpired response or
+// reservation. Expired entries are dropped when looked up, and all of
+// them at most once every memoryStoreSweepInterval.
+func (s *MemoryIdempotencyStore) Reserve(key, fingerprint string, ttl time.Duration) (*CachedResponse, string, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+
+	if memoryStoreSweepInterval <= now.Sub(s.swept) {
+		for stored, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, stored)
+			}
+		}
+
+		s.swept = now
+	}
+
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expires) {
+		return entry.response, entry.fingerprint, false
+	}
+
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expires: now.Add(ttl)}
+	return nil, "", true
+}
+
+// Complete stores `response` under `key`.
+func (s *MemoryIdempotencyStore) Complete(key string, response *CachedResponse) {
+	s.Lock()
+	defer s.Unlock()
+
+	entry := &idempotencyEntry{response: response, expires: response.Expires}
+
+	if reserved, ok := s.entries[key]; ok {
+		entry.fingerprint = reserved.fingerprint
+	}
+
+	s.entries[key] = entry
+}
+
+// Release removes the reservation of `key`.
+func (s *MemoryIdempotencyStore) Release(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if entry, ok := s.entries[key]; ok && nil == entry.response {
+		delete(s.entries, key)
+	}
+}
+
+// Idempotency returns a HandlerWrapper making POST and PATCH requests
+// carrying an Idempotency-Key header safe to retry: the response to
+// the first request with a key is stored in `store` and replayed to
+// retries for IdempotencyOptions.TTL, without invoking the handler
+// again. Retries arriving while the first request is being served are
+// answered with 409 Conflict, and requests reusing a key with a
+// different body with 422 Unprocessable Entity, so bodies are read
+// into memory to fingerprint them. Responses with 5xx status codes are
+// not stored, so the request can be retried, i.e.
+//
+//	router.RegisterWrapper(middleware.Idempotency(middleware.NewMemoryIdempotencyStore()))
+func Idempotency(store IdempotencyStore, opts ...IdempotencyOptions) dispatcher.HandlerWrapper {
+	var options IdempotencyOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	if 0 == options.TTL {
+		options.TTL = DefaultIdempotencyTTL
+	}
+
+	if 0 == len(options.Methods) {
+		options.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	if 0 == options.MaxBodySize {
+		options.MaxBodySize = DefaultIdempotencyMaxBodySize
+	}
+
+	if nil == options.Key {
+		options.Key = idempotencyKey
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if "" == req.Header.Get(IdempotencyKeyHeader) || !containsFold(options.Methods, req.Method) {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			fingerprint, err := fingerprintBody(req)
+
+			if nil != err {
+				http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			key := options.Key(req)
+			stored, storedFingerprint, reserved := store.Reserve(key, fingerprint, options.TTL)
+
+			if !reserved && fingerprint != storedFingerprint {
+				http.Error(res, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+				return
+			}
+
+			if nil != stored {
+				replayIdempotentResponse(res, stored)
+				return
+			}
+
+			if !reserved {
+				http.Error(res, http.StatusText(http.StatusConflict), http.StatusConflict)
+				return
+			}
+
+			completed := false
+
+			defer func() {
+				// The handler panicked or its response was not stored.
+				if !completed {
+					store.Release(key)
+				}
+			}()
+
+			recorder := &cacheRecorder{ResponseWriter: dispatcher.NewResponseWriter(res), limit: options.MaxBodySize}
+			next.ServeHTTP(recorder, req)
+
+			if !recorder.Written() {
+				// The handler wrote nothing, leaving an empty 200 response.
+				recorder.WriteHeader(http.StatusOK)
+			}
+
+			if recorder.overflowed || http.StatusInternalServerError <= recorder.Status() {
+				return
+			}
+
+			now := time.Now()
+
+			store.Complete(key, &CachedResponse{
+				Status:  recorder.Status(),
+				Header:  recorder.header,
+				Body:    recorder.body.Bytes(),
+				Created: now,
+				Expires: now.Add(options.TTL),
+			})
+
+			completed = true
+		})
+	}
+}
+
+// idempotencyKey returns the key of `req`, made of its method, URL
+// and Idempotency-Key header.
+func idempotencyKey(req *http.Request) string {
+	return req.Method + " " + req.Host + req.URL.RequestURI() + "\n" + req.Header.Get(IdempotencyKeyHeader)
+}
+
+// fingerprintBody returns the SHA-256 digest of the body of `req`,
+// replacing the body with a copy of what was read.
+func fingerprintBody(req *http.Request) (string, error) {
+	hash := sha256.New()
+
+	if nil != req.Body && http.NoBody != req.Body {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if nil != err {
+			return "", err
+		}
+
+		hash.Write(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replayIdempotentResponse writes the stored response `stored`.
+func replayIdempotentResponse(res http.ResponseWriter, stored *CachedResponse) {
+	header := res.Header()
+
+	for name, values := range stored.Header {
+		header[name] = append([]string(nil), values...)
+	}
+
+	header.Set(IdempotentReplayHeader, "true")
+	res.WriteHeader(stored.Status)
+	res.Write(stored.Body)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateIdempotentRequest returns a request with the Idempotency-Key
+// header `key`.
+func generateIdempotentRequest(method, target, key string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+
+	if "" != key {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	return req
+}
+
+// TestIdempotency ensures responses are replayed to requests retried
+// with the same key.
+func TestIdempotency(t *testing.T) {
+	var calls int
+
+	handler := Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.Header().Set("Location", fmt.Sprintf("/payments/%d", calls))
+		res.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(res, "payment %d", calls)
+	}))
+
+	for i, replayed := range []string{"", "true"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, generateIdempotentRequest("POST", "/payments", "a"))
+
+		if http.StatusCreated != res.Code || "payment 1" != res.Body.String() || "/payments/1" != res.Header().Get("Location") {
+			t.Errorf("Expected request %d to get the first response, got %d %q.", i, res.Code, res.Body.String())
+		}
+
+		if replayed != res.Header().Get(IdempotentReplayHeader) {
+			t.Errorf("Expected request %d to be replayed %q, got %q.", i, replayed, res.Header().Get(IdempotentReplayHeader))
+		}
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), generateIdempotentRequest("POST", "/payments", "b"))
+	handler.ServeHTTP(httptest.NewRecorder(), generateIdempotentRequest("POST", "/refunds", "a"))
+	handler.ServeHTTP(httptest.NewRecorder(), generateIdempotentRequest("POST", "/payments", ""))
+	handler.ServeHTTP(httptest.NewRecorder(), generateIdempotentRequest("PUT", "/payments", "a"))
+
+	if 5 != calls {
+		t.Errorf("Expected other keys, URLs, methods and unkeyed requests to invoke the handler, got %d calls.", calls)
+	}
+}
+
+// TestIdempotencyConflict ensures concurrent duplicates are rejected
+// and failed requests can be retried.
+func TestIdempotencyConflict(t *testing.T) {
+	var (
+		handler http.Handler
+		nested  *httptest.ResponseRecorder
+		status  = http.StatusServiceUnavailable
+	)
+
+	handler = Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if nil == nested {
+			nested = httptest.NewRecorder()
+			handler.ServeHTTP(nested, generateIdempotentRequest("POST", "/payments", "a"))
+		}
+
+		res.WriteHeader(status)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), generateIdempotentRequest("POST", "/payments", "a"))
+
+	if http.StatusConflict != nested.Code {
+		t.Errorf("Expected a concurrent duplicate to conflict, got %d.", nested.Code)
+	}
+
+	status = http.StatusOK
+
+	for i, replayed := range []string{"", "true"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, generateIdempotentRequest("POST", "/payments", "a"))
+
+		if http.StatusOK != res.Code || replayed != res.Header().Get(IdempotentReplayHeader) {
+			t.Errorf("Expected retry %d to be served (replayed %q), got %d %q.", i, replayed, res.Code, res.Header().Get(IdempotentReplayHeader))
+		}
+	}
+}
+
+// TestIdempotencyPayloadMismatch ensures keys reused with a different
+// body are rejected, while retries with the same body are replayed.
+func TestIdempotencyPayloadMismatch(t *testing.T) {
+	handler := Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		res.Write(body)
+	}))
+
+	for _, test := range []struct {
+		body     string
+		code     int
+		expected string
+	}{
+		{`{"amount":10}`, http.StatusOK, `{"amount":10}`},
+		{`{"amount":10}`, http.StatusOK, `{"amount":10}`},
+		{`{"amount":99}`, http.StatusUnprocessableEntity, ""},
+	} {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader(test.body))
+		req.Header.Set(IdempotencyKeyHeader, "a")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if test.code != res.Code || ("" != test.expected && test.expected != res.Body.String()) {
+			t.Errorf("Expected %s to be answered with %d %q, got %d %q.", test.body, test.code, test.expected, res.Code, res.Body.String())
+		}
+	}
+}
+
+// TestMemoryIdempotencyStoreExpiry ensures expired keys are dropped
+// when looked up.
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+
+	if _, _, reserved := store.Reserve("a", "x", time.Nanosecond); !reserved {
+		t.Fatal("Expected key to be reserved.")
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if _, _, reserved := store.Reserve("a", "y", time.Hour); !reserved {
+		t.Error("Expected expired key to be reserved again.")
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Defaults used for MirrorOptions left unset.
+const (
+	DefaultMirrorMaxBodySize = 1 << 20
+	DefaultMirrorTimeout     = 10 * time.Second
+)
+
+// MirrorOptions configures the Mirror middleware.
+type MirrorOptions struct {
+	// Match, if set, reports whether a request should be mirrored. By
+	// default all requests are mirrored.
+	Match func(req *http.Request) bool
+	// MaxBodySize is the largest request body that will be buffered
+	// for mirroring. Requests with larger bodies are not mirrored.
+	MaxBodySize int64
+	// Timeout bounds how long a mirrored request may take.
+	Timeout time.Duration
+	// MaxConcurrent, if positive, limits the number of mirrored requests
+	// in flight. Requests arriving while the limit is reached are not
+	// mirrored.
+	MaxConcurrent int
+}
+
+// Mirror returns a middleware function duplicating requests to the
+// `shadow` handler, i.e. a proxy created with dispatcher.NewProxy for a
+// new implementation of a service. Mirrored requests are served
+// asynchronously and their responses discarded, so the shadow can not
+// affect the response sent to the client. The middleware never serves
+// the request itself.
+func Mirror(shadow http.Handler, opts MirrorOptions) dispatcher.MiddlewareHandler {
+	if 0 >= opts.MaxBodySize {
+		opts.MaxBodySize = DefaultMirrorMaxBodySize
+	}
+
+	if 0 >= opts.Timeout {
+		opts.Timeout = DefaultMirrorTimeout
+	}
+
+	var slots chan struct{}
+
+	if 0 < opts.MaxConcurrent {
+		slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		if nil != opts.Match && !opts.Match(req) {
+			return false
+		}
+
+		body, ok := bufferBody(req, opts.MaxBodySize)
+
+		if !ok {
+			return false
+		}
+
+		if nil != slots {
+			select {
+			case slots <- struct{}{}:
+			default:
+				return false
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), opts.Timeout)
+		mirrored := req.Clone(ctx)
+		mirrored.Body = io.NopCloser(bytes.NewReader(body))
+		mirrored.RequestURI = ""
+
+		go func() {
+			defer cancel()
+
+			if nil != slots {
+				defer func() { <-slots }()
+			}
+
+			shadow.ServeHTTP(discardResponseWriter{make(http.Header)}, mirrored)
+		}()
+
+		return false
+	}
+}
+
+// bufferBody reads up to `limit` bytes of the request's body, replacing
+// the body so it can still be read in full by other handlers. The
+// boolean returned is false if the body exceeds `limit`.
+func bufferBody(req *http.Request, limit int64) ([]byte, bool) {
+	if nil == req.Body || http.NoBody == req.Body {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+
+	return body, nil == err && int64(len(body)) <= limit
+}
+
+// readCloser combines a Reader with the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// discardResponseWriter is a ResponseWriter discarding all that is
+// written to it.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+// Header returns the response's headers.
+func (w discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// Write discards `data`.
+func (w discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader discards `status`.
+func (w discardResponseWriter) WriteHeader(status int) {}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMirror ensures requests are duplicated to the shadow handler
+// while their bodies remain readable by other handlers.
+func TestMirror(t *testing.T) {
+	mirrored := make(chan string, 1)
+
+	shadow := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		mirrored <- string(body)
+	})
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader("order"))
+
+	if Mirror(shadow, MirrorOptions{})(httptest.NewRecorder(), req) {
+		t.Fatal("Expected Mirror not to serve the request.")
+	}
+
+	if body, _ := io.ReadAll(req.Body); "order" != string(body) {
+		t.Errorf("Expected original body to remain readable, got %q.", body)
+	}
+
+	if body := <-mirrored; "order" != body {
+		t.Errorf("Expected shadow to receive body, got %q.", body)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+const (
+	MethodOverrideHeader = "X-HTTP-Method-Override"
+	MethodOverrideParam  = "_method"
+)
+
+// NormalizePath returns a Plugin that cleans the request's URL path,
+// collapsing duplicate slashes and resolving `.` and `..` elements,
+// while preserving a trailing slash if one was present.
+func NormalizePath() dispatcher.PluginFunc {
+	return func(req *http.Request) *http.Request {
+		original := req.URL.Path
+
+		if "" == original {
+			original = "/"
+		}
+
+		cleaned := path.Clean("/" + original)
+
+		if strings.HasSuffix(original, "/") && "/" != cleaned {
+			cleaned += "/"
+		}
+
+		req.URL.Path = cleaned
+		return req
+	}
+}
+
+// MethodOverride returns a Plugin allowing POST requests to override
+// their method using the `X-HTTP-Method-Override` header or the
+// `_method` query parameter, for clients unable to send methods
+// other than GET and POST.
+func MethodOverride() dispatcher.PluginFunc {
+	return func(req *http.Request) *http.Request {
+		if dispatcher.POST != req.Method {
+			return req
+		}
+
+		method := req.Header.Get(MethodOverrideHeader)
+
+		if "" == method {
+			method = req.URL.Query().Get(MethodOverrideParam)
+		}
+
+		switch method = strings.ToUpper(method); method {
+		case dispatcher.PUT, dispatcher.PATCH, dispatcher.DELETE:
+			req.Method = method
+		}
+
+		return req
+	}
+}
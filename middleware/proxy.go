@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Proxy returns a middleware function forwarding requests whose path
+// begins with `opts.Prefix` to the URL `target`, removing the prefix
+// first. If no prefix is set, all requests are forwarded. Proxy panics
+// if `target` is not a valid URL.
+func Proxy(target string, opts dispatcher.ProxyOptions) dispatcher.MiddlewareHandler {
+	proxy, err := dispatcher.NewProxy(target, opts)
+
+	if nil != err {
+		panic(err)
+	}
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		if !hasPathPrefix(req.URL.Path, opts.Prefix) {
+			return false
+		}
+
+		proxy.ServeHTTP(res, req)
+		return true
+	}
+}
+
+// hasPathPrefix reports whether `urlPath` is `prefix` or lies beneath
+// it.
+func hasPathPrefix(urlPath, prefix string) bool {
+	if !strings.HasPrefix(urlPath, prefix) {
+		return false
+	}
+
+	rest := urlPath[len(prefix):]
+	return "" == rest || strings.HasPrefix(rest, "/") || strings.HasSuffix(prefix, "/")
+}
+
+// ProxyPool behaves as Proxy, forwarding requests to the upstreams of
+// `pool`.
+func ProxyPool(pool *dispatcher.UpstreamPool, opts dispatcher.ProxyOptions) dispatcher.MiddlewareHandler {
+	proxy := dispatcher.NewPoolProxy(pool, opts)
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		if !hasPathPrefix(req.URL.Path, opts.Prefix) {
+			return false
+		}
+
+		proxy.ServeHTTP(res, req)
+		return true
+	}
+}
@@ -3,12 +3,20 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
+	"html/template"
+	"io"
+	"io/fs"
 	"mime"
 	"net/http"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 import (
@@ -19,6 +27,205 @@ const (
 	PlainText = "text/plain"
 )
 
+// Common Cache-Control directives for use with CacheRules.
+const (
+	ImmutableCacheControl = "public, max-age=31536000, immutable"
+	NoCacheControl        = "no-cache"
+)
+
+// PublicFileOptions configures the middleware returned by
+// ServePublicFS.
+type PublicFileOptions struct {
+	// Prefix is a URL path prefix stripped from requests before they
+	// are mapped onto the file system, i.e. `/static`. Requests not
+	// beginning with Prefix are left for other handlers.
+	Prefix string
+	// ETag selects how entity tags are generated for public files. By
+	// default tags are derived from the file's size and modification
+	// time.
+	ETag ETagStrategy
+	// CacheRules determine the caching headers sent with public files.
+	// The first rule matching a file is applied; files matching no rule
+	// are sent without caching headers.
+	CacheRules []CacheRule
+	// IndexFiles are the names of files served when a request maps to a
+	// directory, tried in order. If nil, `index.html` is used; an empty
+	// slice disables index files.
+	IndexFiles []string
+	// RedirectDirectories, if true, redirects requests for directories
+	// lacking a trailing slash (i.e. `/docs`) to the same path with a
+	// trailing slash (i.e. `/docs/`) so relative links resolve.
+	RedirectDirectories bool
+	// ListDirectories, if set, is called with the name of directories
+	// lacking an index file and reports whether their contents may be
+	// listed. Directory listings are disabled by default.
+	ListDirectories func(name string) bool
+	// ListingTemplate renders directory listings, and is passed a
+	// DirectoryListing. If nil, DefaultListingTemplate is used.
+	ListingTemplate *template.Template
+	// Precompressed, if true, serves a `.br` or `.gz` sidecar file
+	// found next to the requested file (i.e. `app.js.br` for `app.js`)
+	// when the client accepts that encoding, rather than the file
+	// itself.
+	Precompressed bool
+	// MIMETypes maps file extensions, including the leading `.`, to
+	// the content type served for them, taking precedence over the
+	// process-wide mime package registry.
+	MIMETypes map[string]string
+	// DefaultMIMEType is served for files whose extension has no known
+	// content type. If empty, PlainText is used.
+	DefaultMIMEType string
+	// Charset, if set, is appended as a `charset` parameter to textual
+	// content types lacking one.
+	Charset string
+	// Cache, if set, holds the contents of small public files in memory
+	// so they are not read from `fsys` with every request.
+	Cache *FileCache
+	// DotFiles determines how requests for paths containing a segment
+	// beginning with a `.` (i.e. `/.git/config` or `/.env`) are
+	// treated. By default they are ignored.
+	DotFiles DotFilePolicy
+}
+
+// DotFilePolicy determines how ServePublicFS treats requests for
+// hidden files and directories.
+type DotFilePolicy int
+
+const (
+	// IgnoreDotFiles leaves requests for hidden paths to other
+	// middleware and Routes, as if they did not exist.
+	IgnoreDotFiles DotFilePolicy = iota
+	// DenyDotFiles responds to requests for hidden paths with
+	// `403 Forbidden`.
+	DenyDotFiles
+	// AllowDotFiles serves hidden paths as any other.
+	AllowDotFiles
+)
+
+// contentType determines the content type served for the file `name`.
+func (opts PublicFileOptions) contentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	typ, ok := opts.MIMETypes[ext]
+
+	if !ok {
+		typ = mime.TypeByExtension(ext)
+	}
+
+	// If `mime` package fails to determine type by file extention
+	// fall back to the default type.
+	if "" == typ {
+		typ = opts.DefaultMIMEType
+	}
+
+	if "" == typ {
+		typ = PlainText
+	}
+
+	if "" != opts.Charset && isTextual(typ) && !strings.Contains(typ, "charset=") {
+		typ = fmt.Sprintf("%s; charset=%s", typ, opts.Charset)
+	}
+
+	return typ
+}
+
+// isTextual reports whether the content type `typ` describes text.
+func isTextual(typ string) bool {
+	typ, _, _ = strings.Cut(typ, ";")
+
+	switch typ = strings.TrimSpace(typ); {
+	case strings.HasPrefix(typ, "text/"):
+		return true
+	case strings.HasSuffix(typ, "+xml"), strings.HasSuffix(typ, "+json"):
+		return true
+	}
+
+	switch typ {
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+
+	return false
+}
+
+// precompressedEncodings lists the content encodings of sidecar files
+// in order of preference.
+var precompressedEncodings = []string{"br", "gzip"}
+
+// precompressedExtensions maps content encodings to the extension of
+// their sidecar files.
+var precompressedExtensions = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+}
+
+// DefaultIndexFiles are the index files used when
+// PublicFileOptions.IndexFiles is nil.
+var DefaultIndexFiles = []string{"index.html"}
+
+// CacheRule sets caching headers on public files matching Pattern.
+// Patterns beginning with a `.` match file extensions (i.e. `.html`),
+// while other patterns are matched against the file's path relative
+// to the public directory using path.Match (i.e. `assets/*.js`). A
+// rule with an empty Pattern matches all files.
+type CacheRule struct {
+	Pattern string
+	// CacheControl is the value of the `Cache-Control` header.
+	CacheControl string
+	// Expires, if positive, sets the `Expires` header to the time of
+	// the request plus Expires.
+	Expires time.Duration
+}
+
+// matches reports whether the rule applies to the file `name`.
+func (rule CacheRule) matches(name string) bool {
+	if "" == rule.Pattern {
+		return true
+	}
+
+	if strings.HasPrefix(rule.Pattern, ".") {
+		return strings.EqualFold(path.Ext(name), rule.Pattern)
+	}
+
+	matched, _ := path.Match(rule.Pattern, name)
+	return matched
+}
+
+// apply sets the rule's caching headers on `header`.
+func (rule CacheRule) apply(header http.Header) {
+	if "" != rule.CacheControl {
+		header.Set("Cache-Control", rule.CacheControl)
+	}
+
+	if 0 < rule.Expires {
+		header.Set("Expires", time.Now().Add(rule.Expires).UTC().Format(http.TimeFormat))
+	}
+}
+
+// ETagStrategy determines how ServePublicFS generates the `ETag`
+// header for public files.
+type ETagStrategy int
+
+const (
+	// ModTimeETag derives tags from the file's size and modification
+	// time, falling back to ContentHashETag for files without a
+	// modification time (i.e. files embedded with `//go:embed`).
+	ModTimeETag ETagStrategy = iota
+	// ContentHashETag derives tags from a SHA-256 hash of the file's
+	// contents. Hashes are cached until the file's size or
+	// modification time changes.
+	ContentHashETag
+	// NoETag disables the `ETag` header.
+	NoETag
+)
+
+// contentHashKey identifies a version of a file whose content hash
+// has been computed.
+type contentHashKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
 // ServePublicFilesFrom accepts a `directory` argument where public
 // files (i.e. javascript, css, and image files) can be found
 // and returns a function to serve files stored in that `directory`.
@@ -28,42 +235,315 @@ const (
 // is written and the function returns true to halt further attempts
 // to serve the request. If no file is found, the function returns false
 // to allow other middleware or a potential dispatcher Route handler
-// to serve the request.
-func ServePublicFilesFrom(directory string) dispatcher.MiddlewareHandler {
+// to serve the request. An optional PublicFileOptions argument
+// configures the middleware as with ServePublicFS.
+func ServePublicFilesFrom(directory string, opts ...PublicFileOptions) dispatcher.MiddlewareHandler {
+	var options PublicFileOptions
 
-	return func(res http.ResponseWriter, req *http.Request) bool {
-		location := path.Join(directory, req.URL.Path)
-		file, err := os.Open(location)
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	return ServePublicFS(os.DirFS(directory), options)
+}
+
+// ServePublicFS behaves as ServePublicFilesFrom, serving public files
+// from the file system `fsys` instead of an OS directory. This allows
+// assets embedded with `//go:embed`, or stored in any other fs.FS such
+// as a zip archive or in-memory file system, to be served.
+func ServePublicFS(fsys fs.FS, opts PublicFileOptions) dispatcher.MiddlewareHandler {
+	hashes := new(sync.Map)
+	indexFiles := opts.IndexFiles
+
+	if nil == indexFiles {
+		indexFiles = DefaultIndexFiles
+	}
+
+	listingTemplate := opts.ListingTemplate
+
+	if nil == listingTemplate {
+		listingTemplate = DefaultListingTemplate
+	}
+
+	// serve writes the file `name` to the response, returning false if
+	// it does not exist or is a directory.
+	serve := func(res http.ResponseWriter, req *http.Request, name string) bool {
+		file, err := fsys.Open(name)
 
 		if nil != err {
 			return false
-		} else if stat, err := file.Stat(); nil != err || stat.IsDir() {
+		}
+
+		defer file.Close()
+
+		stat, err := file.Stat()
+
+		if nil != err || stat.IsDir() {
+			return false
+		}
+
+		var content io.Reader = file
+		etagName := name
+
+		if opts.Precompressed {
+			dispatcher.AddVary(res.Header(), dispatcher.VaryEncoding)
+
+			if sidecar, sidecarStat, encoding := openPrecompressed(fsys, req, name); nil != sidecar {
+				defer sidecar.Close()
+
+				content, stat, etagName = sidecar, sidecarStat, name+precompressedExtensions[encoding]
+				res.Header().Set("Content-Encoding", encoding)
+			}
+		}
+
+		if nil != opts.Cache {
+			if data, cached, err := opts.Cache.contents(etagName, content, stat); nil != err {
+				return false
+			} else if cached {
+				content = bytes.NewReader(data)
+			}
+		}
+
+		if etag := publicFileETag(opts.ETag, hashes, etagName, content, stat); "" != etag {
+			res.Header().Set("ETag", etag)
+		}
+
+		for _, rule := range opts.CacheRules {
+			if rule.matches(name) {
+				rule.apply(res.Header())
+				break
+			}
+		}
+
+		servePublicFile(res, req, opts.contentType(name), content, stat)
+		return true
+	}
+
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		name, ok := publicFileName(req.URL.Path, opts.Prefix)
+
+		if !ok {
+			return false
+		}
+
+		if AllowDotFiles != opts.DotFiles && isHiddenPath(name) {
+			if DenyDotFiles == opts.DotFiles {
+				http.Error(res, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return true
+			}
+
 			return false
 		}
 
-		data, err := ioutil.ReadFile(location)
+		stat, err := fs.Stat(fsys, name)
 
 		if nil != err {
 			return false
+		} else if !stat.IsDir() {
+			return serve(res, req, name)
+		}
+
+		for _, index := range indexFiles {
+			if _, err := fs.Stat(fsys, path.Join(name, index)); nil != err {
+				continue
+			}
+
+			if opts.RedirectDirectories && !strings.HasSuffix(req.URL.Path, "/") {
+				redirectToDirectory(res, req)
+				return true
+			}
+
+			if serve(res, req, path.Join(name, index)) {
+				return true
+			}
+		}
+
+		if nil == opts.ListDirectories || !opts.ListDirectories(name) {
+			return false
+		}
+
+		if opts.RedirectDirectories && !strings.HasSuffix(req.URL.Path, "/") {
+			redirectToDirectory(res, req)
+			return true
+		}
+
+		return serveDirectoryListing(res, req, fsys, name, listingTemplate, opts.DotFiles)
+	}
+}
+
+// redirectToDirectory permanently redirects the request to its path
+// with a trailing slash appended, preserving the query string.
+func redirectToDirectory(res http.ResponseWriter, req *http.Request) {
+	location := req.URL.Path + "/"
+
+	if "" != req.URL.RawQuery {
+		location += "?" + req.URL.RawQuery
+	}
+
+	http.Redirect(res, req, location, http.StatusMovedPermanently)
+}
+
+// servePublicFile writes the contents of `file` to the response with
+// the content type `typ`. Files implementing io.ReadSeeker are served
+// with http.ServeContent so they are streamed and Range, HEAD, and
+// conditional requests are supported; other files are streamed with
+// io.Copy.
+func servePublicFile(res http.ResponseWriter, req *http.Request, typ string, file io.Reader, stat fs.FileInfo) {
+	// Write the Content-Type header of the public file.
+	header := res.Header()
+	header.Set("Content-Type", typ)
+
+	if content, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(res, req, stat.Name(), stat.ModTime(), content)
+		return
+	}
+
+	if !stat.ModTime().IsZero() {
+		header.Set("Last-Modified", stat.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	if isNotModified(req, header.Get("ETag"), stat.ModTime()) {
+		header.Del("Content-Type")
+		res.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Length", strconv.FormatInt(stat.Size(), 10))
+
+	if dispatcher.HEAD == req.Method {
+		return
+	}
+
+	io.Copy(res, file)
+}
+
+// openPrecompressed opens the preferred sidecar file of `name` whose
+// encoding is accepted by the request. The file returned is nil if no
+// acceptable sidecar exists.
+func openPrecompressed(fsys fs.FS, req *http.Request, name string) (fs.File, fs.FileInfo, string) {
+	for _, encoding := range precompressedEncodings {
+		if !acceptsEncoding(req, encoding) {
+			continue
+		}
+
+		file, err := fsys.Open(name + precompressedExtensions[encoding])
+
+		if nil != err {
+			continue
+		}
+
+		if stat, err := file.Stat(); nil == err && !stat.IsDir() {
+			return file, stat, encoding
 		}
 
-		// Determing the MIME type of the file located at `location`.
-		typ := mime.TypeByExtension(path.Ext(location))
+		file.Close()
+	}
+
+	return nil, nil, ""
+}
+
+// acceptsEncoding reports whether the request's `Accept-Encoding`
+// header lists `encoding` with a non-zero quality.
+func acceptsEncoding(req *http.Request, encoding string) bool {
+	for _, accepted := range dispatcher.ParseAccept(strings.Join(req.Header.Values("Accept-Encoding"), ",")) {
+		if strings.EqualFold(accepted.Value, encoding) {
+			return 0 < accepted.Quality
+		}
+	}
+
+	return false
+}
 
-		// If `mime` package fails to determine type by file extention
-		// set to PlainText constant.
-		if "" == typ {
-			typ = PlainText
+// publicFileETag generates an entity tag for `file` using `strategy`.
+// An empty string is returned if no tag could be generated.
+func publicFileETag(strategy ETagStrategy, hashes *sync.Map, name string, file io.Reader, stat fs.FileInfo) string {
+	switch strategy {
+	case NoETag:
+		return ""
+	case ModTimeETag:
+		if !stat.ModTime().IsZero() {
+			return fmt.Sprintf(`"%x-%x"`, stat.Size(), stat.ModTime().UnixNano())
 		}
+	}
+
+	key := contentHashKey{name, stat.Size(), stat.ModTime()}
+
+	if etag, ok := hashes.Load(key); ok {
+		return etag.(string)
+	}
+
+	// Hashing consumes the file, so only files that can be rewound
+	// afterwards are hashed.
+	content, ok := file.(io.ReadSeeker)
+
+	if !ok {
+		return ""
+	}
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, content); nil != err {
+		return ""
+	} else if _, err := content.Seek(0, io.SeekStart); nil != err {
+		return ""
+	}
 
-		// Write the Content-Type header of the public file.
-		header := res.Header()
-		header.Add("Content-Type", typ)
+	etag := fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16])
+	hashes.Store(key, etag)
 
-		if _, err := fmt.Fprintf(res, "%s", data); nil != err {
+	return etag
+}
+
+// isNotModified reports whether the request's conditional headers
+// match the current entity tag or modification time of a resource.
+func isNotModified(req *http.Request, etag string, modTime time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); "" != match {
+		if "" == etag {
 			return false
 		}
 
-		return true
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+			if "*" == candidate || strings.TrimPrefix(etag, "W/") == candidate {
+				return true
+			}
+		}
+
+		return false
 	}
+
+	if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); nil == err && !modTime.IsZero() {
+		return !modTime.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// isHiddenPath reports whether any segment of `name` begins with a `.`.
+func isHiddenPath(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") && "." != segment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publicFileName maps a request's URL path onto a name valid for
+// use with an fs.FS, stripping `prefix` from the path. The boolean
+// returned is false if the path does not begin with `prefix`.
+func publicFileName(urlPath, prefix string) (string, bool) {
+	if !hasPathPrefix(urlPath, prefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(urlPath, prefix)), "/")
+
+	if "" == name {
+		name = "."
+	}
+
+	return name, fs.ValidPath(name)
 }
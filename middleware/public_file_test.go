@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestServePublicFS ensures files found within an fs.FS are served
+// with a Content-Type matching their extension.
+func TestServePublicFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/app.css": &fstest.MapFile{Data: []byte("body {}")},
+	}
+
+	res := httptest.NewRecorder()
+	served := ServePublicFS(fsys, PublicFileOptions{Prefix: "/static"})(res, httptest.NewRequest("GET", "/static/css/app.css", nil))
+
+	if !served {
+		t.Fatal("Expected public file to be served.")
+	}
+
+	if "body {}" != res.Body.String() {
+		t.Errorf("Expected file contents to be written, got %q.", res.Body.String())
+	}
+
+	if typ := res.Header().Get("Content-Type"); "text/css; charset=utf-8" != typ {
+		t.Errorf("Expected CSS Content-Type, got %q.", typ)
+	}
+}
+
+// TestServePublicFSFallsThrough ensures missing files, directories,
+// and requests outside the prefix are left for other handlers.
+func TestServePublicFSFallsThrough(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/app.css": &fstest.MapFile{Data: []byte("body {}")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{Prefix: "/static"})
+
+	for _, path := range []string{"/static/missing.css", "/static/css", "/staticcss/app.css", "/css/app.css"} {
+		if middleware(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil)) {
+			t.Errorf("Expected %q to fall through.", path)
+		}
+	}
+}
+
+// TestServePublicFSRange ensures Range requests are answered with
+// partial content.
+func TestServePublicFSRange(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.txt": &fstest.MapFile{Data: []byte("0123456789")},
+	}
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/file.txt", nil)
+	req.Header.Set("Range", "bytes=2-4")
+
+	ServePublicFS(fsys, PublicFileOptions{})(res, req)
+
+	if http.StatusPartialContent != res.Code || "234" != res.Body.String() {
+		t.Errorf("Expected partial content %q, got %d %q.", "234", res.Code, res.Body.String())
+	}
+}
+
+// TestServePublicFSConditionalGet ensures requests carrying a matching
+// `If-None-Match` or `If-Modified-Since` header receive a 304.
+func TestServePublicFSConditionalGet(t *testing.T) {
+	modTime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"file.txt":     &fstest.MapFile{Data: []byte("contents"), ModTime: modTime},
+		"embedded.txt": &fstest.MapFile{Data: []byte("contents")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{})
+
+	for _, name := range []string{"/file.txt", "/embedded.txt"} {
+		res := httptest.NewRecorder()
+		middleware(res, httptest.NewRequest("GET", name, nil))
+		etag := res.Header().Get("ETag")
+
+		if "" == etag {
+			t.Fatalf("Expected %s to be served with an ETag.", name)
+		}
+
+		req := httptest.NewRequest("GET", name, nil)
+		req.Header.Set("If-None-Match", etag)
+		res = httptest.NewRecorder()
+		middleware(res, req)
+
+		if http.StatusNotModified != res.Code {
+			t.Errorf("Expected %s to be not modified, got %d.", name, res.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/file.txt", nil)
+	req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+	res := httptest.NewRecorder()
+	middleware(res, req)
+
+	if http.StatusNotModified != res.Code {
+		t.Errorf("Expected file to be not modified since %s, got %d.", modTime, res.Code)
+	}
+}
+
+// TestServePublicFSCacheRules ensures the first CacheRule matching a
+// file sets its caching headers.
+func TestServePublicFSCacheRules(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      &fstest.MapFile{Data: []byte("<html>")},
+		"assets/app.js":   &fstest.MapFile{Data: []byte("app()")},
+		"assets/logo.png": &fstest.MapFile{Data: []byte("png")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{
+		CacheRules: []CacheRule{
+			{Pattern: ".html", CacheControl: NoCacheControl},
+			{Pattern: "assets/*.js", CacheControl: ImmutableCacheControl, Expires: time.Hour},
+		},
+	})
+
+	expectations := map[string]string{
+		"/index.html":      NoCacheControl,
+		"/assets/app.js":   ImmutableCacheControl,
+		"/assets/logo.png": "",
+	}
+
+	for name, expected := range expectations {
+		res := httptest.NewRecorder()
+		middleware(res, httptest.NewRequest("GET", name, nil))
+
+		if actual := res.Header().Get("Cache-Control"); expected != actual {
+			t.Errorf("Expected %s to have Cache-Control %q, got %q.", name, expected, actual)
+		}
+	}
+}
+
+// TestServePublicFSIndexFiles ensures requests mapping to a directory
+// are served its index file, optionally redirecting to add a trailing
+// slash.
+func TestServePublicFSIndexFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/index.html": &fstest.MapFile{Data: []byte("<h1>Docs</h1>")},
+		"empty/file.txt":  &fstest.MapFile{Data: []byte("file")},
+	}
+
+	res := httptest.NewRecorder()
+
+	if !ServePublicFS(fsys, PublicFileOptions{})(res, httptest.NewRequest("GET", "/docs/", nil)) {
+		t.Fatal("Expected directory index to be served.")
+	}
+
+	if typ := res.Header().Get("Content-Type"); "text/html; charset=utf-8" != typ {
+		t.Errorf("Expected HTML Content-Type, got %q.", typ)
+	}
+
+	res = httptest.NewRecorder()
+	ServePublicFS(fsys, PublicFileOptions{RedirectDirectories: true})(res, httptest.NewRequest("GET", "/docs?page=2", nil))
+
+	if location := res.Header().Get("Location"); http.StatusMovedPermanently != res.Code || "/docs/?page=2" != location {
+		t.Errorf("Expected redirect to %q, got %d %q.", "/docs/?page=2", res.Code, location)
+	}
+
+	if ServePublicFS(fsys, PublicFileOptions{})(httptest.NewRecorder(), httptest.NewRequest("GET", "/empty/", nil)) {
+		t.Error("Expected directory without index to fall through.")
+	}
+}
+
+// TestServePublicFSDirectoryListing ensures directory listings are
+// only rendered for directories allowed by ListDirectories.
+func TestServePublicFSDirectoryListing(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/report.pdf":  &fstest.MapFile{Data: []byte("pdf")},
+		"private/keys.txt": &fstest.MapFile{Data: []byte("secret")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{
+		ListDirectories: func(name string) bool { return "drop" == name },
+	})
+
+	res := httptest.NewRecorder()
+
+	if !middleware(res, httptest.NewRequest("GET", "/drop/", nil)) {
+		t.Fatal("Expected directory listing to be served.")
+	}
+
+	if !strings.Contains(res.Body.String(), `<a href="/drop/report.pdf">report.pdf</a>`) {
+		t.Errorf("Expected listing to link to report.pdf, got %q.", res.Body.String())
+	}
+
+	if middleware(httptest.NewRecorder(), httptest.NewRequest("GET", "/private/", nil)) {
+		t.Error("Expected directory listing to be disabled for private directory.")
+	}
+}
+
+// TestServePublicFSPrecompressed ensures sidecar files are served to
+// clients accepting their encoding.
+func TestServePublicFSPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":    &fstest.MapFile{Data: []byte("app()")},
+		"app.js.br": &fstest.MapFile{Data: []byte("brotli")},
+		"app.js.gz": &fstest.MapFile{Data: []byte("gzip")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{Precompressed: true})
+
+	expectations := map[string]string{
+		"gzip, deflate, br": "brotli",
+		"gzip, br;q=0":      "gzip",
+		"identity":          "app()",
+	}
+
+	for accept, expected := range expectations {
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		res := httptest.NewRecorder()
+		middleware(res, req)
+
+		if expected != res.Body.String() {
+			t.Errorf("Expected %q to be served for %q, got %q.", expected, accept, res.Body.String())
+		}
+
+		if typ := res.Header().Get("Content-Type"); "text/javascript; charset=utf-8" != typ {
+			t.Errorf("Expected JavaScript Content-Type for %q, got %q.", accept, typ)
+		}
+
+		if "Accept-Encoding" != res.Header().Get("Vary") {
+			t.Errorf("Expected Vary header for %q.", accept)
+		}
+	}
+}
+
+// TestServePublicFSMIMETypes ensures per-middleware MIME overrides,
+// default types, and charsets are applied.
+func TestServePublicFSMIMETypes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.webmanifest": &fstest.MapFile{Data: []byte("{}")},
+		"data.unknown":    &fstest.MapFile{Data: []byte("?")},
+		"notes.md":        &fstest.MapFile{Data: []byte("# Notes")},
+	}
+
+	middleware := ServePublicFS(fsys, PublicFileOptions{
+		MIMETypes:       map[string]string{".webmanifest": "application/manifest+json", ".md": "text/markdown"},
+		DefaultMIMEType: "application/octet-stream",
+		Charset:         "utf-8",
+	})
+
+	expectations := map[string]string{
+		"/app.webmanifest": "application/manifest+json; charset=utf-8",
+		"/data.unknown":    "application/octet-stream",
+		"/notes.md":        "text/markdown; charset=utf-8",
+	}
+
+	for name, expected := range expectations {
+		res := httptest.NewRecorder()
+		middleware(res, httptest.NewRequest("GET", name, nil))
+
+		if typ := res.Header().Get("Content-Type"); expected != typ {
+			t.Errorf("Expected %s to have Content-Type %q, got %q.", name, expected, typ)
+		}
+	}
+}
+
+// TestServePublicFSDotFiles ensures hidden paths are ignored, denied,
+// or served according to the DotFiles policy.
+func TestServePublicFSDotFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":        &fstest.MapFile{Data: []byte("SECRET=1")},
+		".git/config": &fstest.MapFile{Data: []byte("[core]")},
+	}
+
+	for _, name := range []string{"/.env", "/.git/config"} {
+		if ServePublicFS(fsys, PublicFileOptions{})(httptest.NewRecorder(), httptest.NewRequest("GET", name, nil)) {
+			t.Errorf("Expected %s to be ignored by default.", name)
+		}
+
+		res := httptest.NewRecorder()
+		ServePublicFS(fsys, PublicFileOptions{DotFiles: DenyDotFiles})(res, httptest.NewRequest("GET", name, nil))
+
+		if http.StatusForbidden != res.Code {
+			t.Errorf("Expected %s to be denied, got %d.", name, res.Code)
+		}
+
+		if !ServePublicFS(fsys, PublicFileOptions{DotFiles: AllowDotFiles})(httptest.NewRecorder(), httptest.NewRequest("GET", name, nil)) {
+			t.Errorf("Expected %s to be allowed.", name)
+		}
+	}
+
+	listings := map[DotFilePolicy]bool{IgnoreDotFiles: false, DenyDotFiles: false, AllowDotFiles: true}
+
+	for policy, listed := range listings {
+		res := httptest.NewRecorder()
+
+		ServePublicFS(fsys, PublicFileOptions{
+			DotFiles:        policy,
+			ListDirectories: func(name string) bool { return true },
+		})(res, httptest.NewRequest("GET", "/", nil))
+
+		for _, name := range []string{".env", ".git"} {
+			if listed != strings.Contains(res.Body.String(), ">"+name) {
+				t.Errorf("Expected %s to be listed under policy %d: %t, got %q.", name, policy, listed, res.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DuplicatePolicy is how NormalizeQuery treats query parameters given
+// more than once.
+type DuplicatePolicy int
+
+// Policies for query parameters given more than once.
+const (
+	// KeepDuplicates keeps every value, in the order they were given.
+	KeepDuplicates DuplicatePolicy = iota
+	// KeepFirst keeps the first value given.
+	KeepFirst
+	// KeepLast keeps the last value given.
+	KeepLast
+	// SortDuplicates keeps every value, sorted.
+	SortDuplicates
+)
+
+// QueryOptions configures the NormalizeQuery Plugin.
+type QueryOptions struct {
+	// Duplicates is the policy for parameters given more than once.
+	Duplicates DuplicatePolicy
+	// TrimSpace trims leading and trailing white space from values.
+	TrimSpace bool
+	// DropEmpty removes parameters whose values are empty, once
+	// trimmed.
+	DropEmpty bool
+	// LowerKeys lower cases the names of parameters.
+	LowerKeys bool
+	// Drop are the names of parameters removed, i.e. tracking
+	// parameters such as `utm_source` that would fragment caches.
+	Drop []string
+}
+
+// NormalizeQuery returns a Plugin rewriting the query string of
+// requests to a canonical form: parameters sorted by name, encoded
+// consistently and reduced as configured by `opts`. Requests for the
+// same resource then carry the same query however clients ordered or
+// encoded it, so cache keys and signatures computed from the URL are
+// deterministic, i.e.
+//
+//	router.RegisterPlugin(middleware.NormalizeQuery(middleware.QueryOptions{
+//		Duplicates: middleware.KeepLast,
+//		TrimSpace:  true,
+//		Drop:       []string{"utm_source", "utm_medium"},
+//	}))
+//
+// Query strings that can not be parsed are left as they are.
+func NormalizeQuery(opts QueryOptions) dispatcher.PluginFunc {
+	return func(req *http.Request) *http.Request {
+		if "" == req.URL.RawQuery {
+			return req
+		}
+
+		values, err := url.ParseQuery(req.URL.RawQuery)
+
+		if nil != err {
+			return req
+		}
+
+		normalized := make(url.Values, len(values))
+		keys := make([]string, 0, len(values))
+
+		for key := range values {
+			keys = append(keys, key)
+		}
+
+		// Keys merged by lower casing keep their values in a stable
+		// order.
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			given := values[key]
+
+			if opts.LowerKeys {
+				key = strings.ToLower(key)
+			}
+
+			if containsFold(opts.Drop, key) {
+				continue
+			}
+
+			for _, value := range given {
+				if opts.TrimSpace {
+					value = strings.TrimSpace(value)
+				}
+
+				if opts.DropEmpty && "" == value {
+					continue
+				}
+
+				normalized[key] = append(normalized[key], value)
+			}
+		}
+
+		for key, given := range normalized {
+			if 0 == len(given) {
+				delete(normalized, key)
+				continue
+			}
+
+			switch opts.Duplicates {
+			case KeepFirst:
+				normalized[key] = given[:1]
+			case KeepLast:
+				normalized[key] = given[len(given)-1:]
+			case SortDuplicates:
+				sort.Strings(given)
+			}
+		}
+
+		// Encode sorts parameters by name.
+		req.URL.RawQuery = normalized.Encode()
+		return req
+	}
+}
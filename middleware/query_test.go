@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNormalizeQuery ensures query strings are rewritten to a canonical
+// form.
+func TestNormalizeQuery(t *testing.T) {
+	for _, test := range []struct {
+		options  QueryOptions
+		query    string
+		expected string
+	}{
+		{QueryOptions{}, "b=2&a=1&b=1", "a=1&b=2&b=1"},
+		{QueryOptions{Duplicates: KeepFirst}, "b=2&a=1&b=1", "a=1&b=2"},
+		{QueryOptions{Duplicates: KeepLast}, "b=2&a=1&b=1", "a=1&b=1"},
+		{QueryOptions{Duplicates: SortDuplicates}, "b=2&a=1&b=1", "a=1&b=1&b=2"},
+		{QueryOptions{TrimSpace: true, DropEmpty: true}, "q=+go+&page=&x=%20", "q=go"},
+		{QueryOptions{LowerKeys: true, Drop: []string{"UTM_source"}}, "Q=a&q=b&utm_source=feed", "q=a&q=b"},
+		{QueryOptions{}, "bad=%zz&b=1", "bad=%zz&b=1"},
+	} {
+		req := NormalizeQuery(test.options)(httptest.NewRequest("GET", "/search?"+test.query, nil))
+
+		if test.expected != req.URL.RawQuery {
+			t.Errorf("Expected %q normalized with %+v to be %q, got %q.", test.query, test.options, test.expected, req.URL.RawQuery)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultQuotaKeyHeader is the request header API keys are read from
+// when QuotaOptions.Key is unset.
+const DefaultQuotaKeyHeader = "X-API-Key"
+
+// Headers reporting the usage of the most constrained quota of a
+// request's API key.
+const (
+	QuotaLimitHeader     = "X-RateLimit-Limit"
+	QuotaRemainingHeader = "X-RateLimit-Remaining"
+	// QuotaResetHeader holds the Unix time the quota resets at.
+	QuotaResetHeader = "X-RateLimit-Reset"
+)
+
+// QuotaPeriod is the calendar period a quota applies to.
+type QuotaPeriod string
+
+// Periods quotas can apply to.
+const (
+	QuotaDaily   QuotaPeriod = "day"
+	QuotaMonthly QuotaPeriod = "month"
+)
+
+// QuotaLimit limits the number of requests per period.
+type QuotaLimit struct {
+	Period QuotaPeriod
+	Limit  int64
+}
+
+// QuotaUsage is the usage of a quota in its current period.
+type QuotaUsage struct {
+	Period    QuotaPeriod `json:"period" xml:"period,attr"`
+	Limit     int64       `json:"limit" xml:"limit"`
+	Used      int64       `json:"used" xml:"used"`
+	Remaining int64       `json:"remaining" xml:"remaining"`
+	Reset     time.Time   `json:"reset" xml:"reset"`
+}
+
+// QuotaStore is implemented by the stores a Quota keeps its counters
+// in, allowing them to be shared between processes (i.e. through
+// Redis). Counters may be dropped once they expire.
+type QuotaStore interface {
+	// Get returns the counter `key`, or zero.
+	Get(key string) (int64, error)
+	// Increment increments the counter `key`, which expires at
+	// `expires`, returning its new value.
+	Increment(key string, expires time.Time) (int64, error)
+}
+
+// MemoryQuotaStore is an in-memory QuotaStore.
+type MemoryQuotaStore struct {
+	sync.Mutex
+	counters map[string]*quotaCounter
+	// swept is when expired counters were last dropped.
+	swept time.Time
+}
+
+// quotaCounter is a counter held by a MemoryQuotaStore.
+type quotaCounter struct {
+	count   int64
+	expires time.Time
+}
+
+// QuotaOptions configures a Quota.
+type QuotaOptions struct {
+	// Limits are the quotas of each API key, i.e. 1000 requests a day
+	// and 20000 a month.
+	Limits []QuotaLimit
+	// Key returns the API key of `req`. Requests without one are not
+	// counted. By default the DefaultQuotaKeyHeader is used.
+	Key func(req *http.Request) string
+	// Location is the time zone periods start in. By default UTC.
+	Location *time.Location
+}
+
+// Quota counts the requests made with each API key per day or month,
+// answering requests exceeding any of its limits with 429 Too Many
+// Requests. Responses report the usage of the most constrained quota
+// in the X-RateLimit-* headers. Register it with a Router as a
+// HandlerWrapper, i.e.
+//
+//	quota := middleware.NewQuota(middleware.NewMemoryQuotaStore(), middleware.QuotaOptions{
+//		Limits: []middleware.QuotaLimit{{middleware.QuotaDaily, 1000}},
+//	})
+//	router.RegisterWrapper(quota.Wrap)
+//	router.Get("/usage", quota.UsageHandler())
+//
+// Concurrent requests may exceed a limit by as many requests as are in
+// flight.
+type Quota struct {
+	store   QuotaStore
+	options QuotaOptions
+}
+
+// NewMemoryQuotaStore creates a new MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// Get returns the counter `key`, or zero if it expired.
+func (s *MemoryQuotaStore) Get(key string) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if counter, ok := s.counters[key]; ok && time.Now().Before(counter.expires) {
+		return counter.count, nil
+	}
+
+	return 0, nil
+}
+
+// Increment increments the counter `key`, restarting it if it
+// expired. All expired counters are dropped at most once every
+// memoryStoreSweepInterval.
+func (s *MemoryQuotaStore) Increment(key string, expires time.Time) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+
+	if memoryStoreSweepInterval <= now.Sub(s.swept) {
+		for stored, counter := range s.counters {
+			if !now.Before(counter.expires) {
+				delete(s.counters, stored)
+			}
+		}
+
+		s.swept = now
+	}
+
+	counter, ok := s.counters[key]
+
+	if !ok || !now.Before(counter.expires) {
+		counter = &quotaCounter{expires: expires}
+		s.counters[key] = counter
+	}
+
+	counter.count++
+	return counter.count, nil
+}
+
+// NewQuota creates a Quota keeping its counters in `store`.
+func NewQuota(store QuotaStore, opts QuotaOptions) *Quota {
+	if nil == opts.Key {
+		opts.Key = func(req *http.Request) string {
+			return req.Header.Get(DefaultQuotaKeyHeader)
+		}
+	}
+
+	if nil == opts.Location {
+		opts.Location = time.UTC
+	}
+
+	return &Quota{store: store, options: opts}
+}
+
+// Wrap returns a handler counting requests against the quotas of their
+// API keys before passing them to `next`.
+func (q *Quota) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		key := q.options.Key(req)
+
+		if "" == key || 0 == len(q.options.Limits) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		usage, err := q.Usage(key)
+
+		if nil != err {
+			dispatcher.WriteError(res, req, err)
+			return
+		}
+
+		for _, used := range usage {
+			if 0 == used.Remaining {
+				writeQuotaHeaders(res.Header(), used)
+				res.Header().Set("Retry-After", strconv.Itoa(int(time.Until(used.Reset).Seconds())+1))
+				http.Error(res, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		for i, limit := range q.options.Limits {
+			if usage[i].Used, err = q.store.Increment(q.counter(key, limit.Period, usage[i].Reset), usage[i].Reset); nil != err {
+				dispatcher.WriteError(res, req, err)
+				return
+			}
+
+			usage[i].Remaining = max(0, limit.Limit-usage[i].Used)
+		}
+
+		writeQuotaHeaders(res.Header(), mostConstrained(usage))
+		next.ServeHTTP(res, req)
+	})
+}
+
+// Usage returns the usage of each quota of the API key `key`.
+func (q *Quota) Usage(key string) ([]QuotaUsage, error) {
+	now := time.Now().In(q.options.Location)
+	usage := make([]QuotaUsage, len(q.options.Limits))
+
+	for i, limit := range q.options.Limits {
+		reset := quotaReset(now, limit.Period)
+		used, err := q.store.Get(q.counter(key, limit.Period, reset))
+
+		if nil != err {
+			return nil, err
+		}
+
+		usage[i] = QuotaUsage{
+			Period:    limit.Period,
+			Limit:     limit.Limit,
+			Used:      used,
+			Remaining: max(0, limit.Limit-used),
+			Reset:     reset,
+		}
+	}
+
+	return usage, nil
+}
+
+// UsageHandler returns a handler responding with the usage of the
+// quotas of the request's API key, encoded with dispatcher.Respond.
+// Requests without an API key are answered with 401 Unauthorized.
+func (q *Quota) UsageHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		key := q.options.Key(req)
+
+		if "" == key {
+			http.Error(res, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		usage, err := q.Usage(key)
+
+		if nil != err {
+			dispatcher.WriteError(res, req, err)
+			return
+		}
+
+		dispatcher.Respond(res, req, http.StatusOK, quotaReport{Quotas: usage})
+	})
+}
+
+// quotaReport is the body written by a Quota's UsageHandler.
+type quotaReport struct {
+	XMLName struct{}     `json:"-" xml:"quotas"`
+	Quotas  []QuotaUsage `json:"quotas" xml:"quota"`
+}
+
+// counter returns the name of the counter of the API key `key` for
+// the period of `period` ending at `reset`.
+func (q *Quota) counter(key string, period QuotaPeriod, reset time.Time) string {
+	return key + "\n" + string(period) + "\n" + strconv.FormatInt(reset.Unix(), 10)
+}
+
+// quotaReset returns when the period of `period` containing `now`
+// ends, in the location of `now`.
+func quotaReset(now time.Time, period QuotaPeriod) time.Time {
+	year, month, day := now.Date()
+
+	if QuotaMonthly == period {
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, now.Location())
+	}
+
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+}
+
+// mostConstrained returns the usage with the fewest requests
+// remaining.
+func mostConstrained(usage []QuotaUsage) QuotaUsage {
+	constrained := usage[0]
+
+	for _, used := range usage[1:] {
+		if used.Remaining < constrained.Remaining {
+			constrained = used
+		}
+	}
+
+	return constrained
+}
+
+// writeQuotaHeaders reports `usage` in `header`.
+func writeQuotaHeaders(header http.Header, usage QuotaUsage) {
+	header.Set(QuotaLimitHeader, strconv.FormatInt(usage.Limit, 10))
+	header.Set(QuotaRemainingHeader, strconv.FormatInt(usage.Remaining, 10))
+	header.Set(QuotaResetHeader, strconv.FormatInt(usage.Reset.Unix(), 10))
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// generateQuotaRequest returns a request made with the API key `key`.
+func generateQuotaRequest(target, key string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+
+	if "" != key {
+		req.Header.Set(DefaultQuotaKeyHeader, key)
+	}
+
+	return req
+}
+
+// TestQuota ensures requests are counted per API key and rejected once
+// a quota is exhausted.
+func TestQuota(t *testing.T) {
+	quota := NewQuota(NewMemoryQuotaStore(), QuotaOptions{
+		Limits: []QuotaLimit{{QuotaMonthly, 10}, {QuotaDaily, 2}},
+	})
+
+	handler := quota.Wrap(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+
+	for i, expected := range []struct {
+		status    int
+		remaining string
+	}{{http.StatusOK, "1"}, {http.StatusOK, "0"}, {http.StatusTooManyRequests, "0"}} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, generateQuotaRequest("/", "a"))
+
+		if expected.status != res.Code || expected.remaining != res.Header().Get(QuotaRemainingHeader) {
+			t.Errorf("Expected request %d to get %d with %s remaining, got %d with %q.", i, expected.status, expected.remaining, res.Code, res.Header().Get(QuotaRemainingHeader))
+		}
+
+		if "2" != res.Header().Get(QuotaLimitHeader) {
+			t.Errorf("Expected the daily limit to be reported, got %q.", res.Header().Get(QuotaLimitHeader))
+		}
+	}
+
+	for _, key := range []string{"b", ""} {
+		res := httptest.NewRecorder()
+
+		if handler.ServeHTTP(res, generateQuotaRequest("/", key)); http.StatusOK != res.Code {
+			t.Errorf("Expected API key %q to be served, got %d.", key, res.Code)
+		}
+	}
+
+	usage, _ := quota.Usage("a")
+
+	if 2 != usage[0].Used || 8 != usage[0].Remaining || 2 != usage[1].Used {
+		t.Errorf("Expected rejected requests not to be counted, got %+v.", usage)
+	}
+
+	if reset := usage[1].Reset; reset.Before(time.Now()) || reset.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("Expected the daily quota to reset within a day, got %v.", reset)
+	}
+}
+
+// TestQuotaUsageHandler ensures the usage of the request's API key is
+// exposed.
+func TestQuotaUsageHandler(t *testing.T) {
+	quota := NewQuota(NewMemoryQuotaStore(), QuotaOptions{Limits: []QuotaLimit{{QuotaDaily, 5}}})
+	quota.Wrap(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), generateQuotaRequest("/", "a"))
+
+	res := httptest.NewRecorder()
+	quota.UsageHandler().ServeHTTP(res, generateQuotaRequest("/usage", "a"))
+
+	var report struct {
+		Quotas []QuotaUsage `json:"quotas"`
+	}
+
+	if err := json.Unmarshal(res.Body.Bytes(), &report); nil != err || 1 != len(report.Quotas) {
+		t.Fatalf("Expected a usage report, got %q (%v).", res.Body.String(), err)
+	}
+
+	if used := report.Quotas[0]; QuotaDaily != used.Period || 1 != used.Used || 4 != used.Remaining {
+		t.Errorf("Expected one request used of the daily quota, got %+v.", used)
+	}
+
+	res = httptest.NewRecorder()
+	quota.UsageHandler().ServeHTTP(res, generateQuotaRequest("/usage", ""))
+
+	if http.StatusUnauthorized != res.Code {
+		t.Errorf("Expected requests without an API key to be unauthorized, got %d.", res.Code)
+	}
+}
+
+// TestMemoryQuotaStoreExpiry ensures expired counters restart when
+// incremented.
+func TestMemoryQuotaStoreExpiry(t *testing.T) {
+	store := NewMemoryQuotaStore()
+
+	store.Increment("a", time.Now().Add(time.Millisecond))
+	store.Increment("a", time.Now().Add(time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+
+	if count, _ := store.Increment("a", time.Now().Add(time.Hour)); 1 != count {
+		t.Errorf("Expected expired counter to restart, got %d.", count)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Route tag keys read by RBAC when RBACOptions leaves them unset.
+const (
+	DefaultRolesKey       = "roles"
+	DefaultPermissionsKey = "permissions"
+)
+
+// RBACOptions configures the RBAC route middleware.
+type RBACOptions struct {
+	// RolesKey is the key of the Route tag listing the roles allowed to
+	// request the Route. A Principal needs any one of them.
+	RolesKey string
+	// PermissionsKey is the key of the Route tag listing the
+	// permissions required to request the Route. A Principal needs all
+	// of them.
+	PermissionsKey string
+	// Principal, if set, resolves the Principal of a request. By
+	// default it is read with dispatcher.PrincipalFromContext.
+	Principal func(req *http.Request) (dispatcher.Principal, bool)
+}
+
+// RBAC returns a route middleware function enforcing the roles and
+// permissions Routes are tagged with, i.e.
+//
+//	router.RegisterRouteMiddleware(middleware.RBAC(middleware.RBACOptions{}))
+//	router.Delete("/users/:id", DeleteUserHandler).Meta("roles", "admin")
+//
+// Requests for tagged Routes without a Principal are served 401
+// Unauthorized; those whose Principal lacks the roles or permissions
+// required are served 403 Forbidden. Routes without tags are left
+// alone.
+func RBAC(opts RBACOptions) dispatcher.RouteMiddlewareHandler {
+	if "" == opts.RolesKey {
+		opts.RolesKey = DefaultRolesKey
+	}
+
+	if "" == opts.PermissionsKey {
+		opts.PermissionsKey = DefaultPermissionsKey
+	}
+
+	if nil == opts.Principal {
+		opts.Principal = func(req *http.Request) (dispatcher.Principal, bool) {
+			return dispatcher.PrincipalFromContext(req.Context())
+		}
+	}
+
+	return func(res http.ResponseWriter, req *http.Request, route *dispatcher.Route, params dispatcher.Params) bool {
+		roles, permissions := route.Meta(opts.RolesKey), route.Meta(opts.PermissionsKey)
+
+		if 0 == len(roles) && 0 == len(permissions) {
+			return false
+		}
+
+		principal, ok := opts.Principal(req)
+
+		if !ok || nil == principal {
+			http.Error(res, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return true
+		}
+
+		if !authorized(principal, roles, permissions) {
+			http.Error(res, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return true
+		}
+
+		return false
+	}
+}
+
+// authorized reports whether `principal` has any of `roles`, if there
+// are any, and all of `permissions`.
+func authorized(principal dispatcher.Principal, roles, permissions []string) bool {
+	allowed := 0 == len(roles)
+
+	for _, role := range roles {
+		allowed = allowed || principal.HasRole(role)
+	}
+
+	for _, permission := range permissions {
+		allowed = allowed && principal.HasPermission(permission)
+	}
+
+	return allowed
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestRBAC ensures Routes tagged with roles and permissions are only
+// served to Principals granted them.
+func TestRBAC(t *testing.T) {
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	router := dispatcher.NewRouter().
+		RegisterRouteMiddleware(RBAC(RBACOptions{})).
+		Get("/public", ok).
+		Delete("/users/:id", ok).Meta("roles", "admin", "support").Meta("permissions", "users:delete")
+
+	principals := map[string]dispatcher.Principal{
+		"admin":   dispatcher.BasicPrincipal{Roles: []string{"admin"}, Permissions: []string{"users:delete"}},
+		"support": dispatcher.BasicPrincipal{Roles: []string{"support"}},
+		"viewer":  dispatcher.BasicPrincipal{Roles: []string{"viewer"}, Permissions: []string{"users:delete"}},
+	}
+
+	router.RegisterPlugin(dispatcher.PluginFunc(func(req *http.Request) *http.Request {
+		if principal, ok := principals[req.Header.Get("X-User")]; ok {
+			return req.WithContext(dispatcher.WithPrincipal(req.Context(), principal))
+		}
+
+		return req
+	}))
+
+	tests := []struct {
+		method, path, user string
+		status             int
+	}{
+		{"GET", "/public", "", http.StatusOK},
+		{"DELETE", "/users/1", "", http.StatusUnauthorized},
+		{"DELETE", "/users/1", "viewer", http.StatusForbidden},
+		{"DELETE", "/users/1", "support", http.StatusForbidden},
+		{"DELETE", "/users/1", "admin", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		req.Header.Set("X-User", test.user)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if test.status != res.Code {
+			t.Errorf("Expected %s %s by %q to be served %d, got %d.", test.method, test.path, test.user, test.status, res.Code)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultRecordMaxBodySize is the largest request or response body
+// recorded when RecordOptions leaves MaxBodySize unset.
+const DefaultRecordMaxBodySize = 1 << 20
+
+// Redacted replaces secrets in recordings.
+const Redacted = "[REDACTED]"
+
+// Secrets redacted from recordings when RecordOptions leaves them
+// unset. Names are matched case insensitively.
+var (
+	DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	DefaultRedactedFields  = []string{"access_token", "api_key", "client_secret", "password", "refresh_token", "secret", "token"}
+)
+
+// RecordOptions configures Record and Replay.
+type RecordOptions struct {
+	// Match, if set, reports whether a request should be recorded. By
+	// default all requests are recorded.
+	Match func(req *http.Request) bool
+	// RedactHeaders are the request and response headers whose values
+	// are replaced with Redacted.
+	RedactHeaders []string
+	// RedactFields are the query parameters, form fields and JSON
+	// object keys whose values are replaced with Redacted.
+	RedactFields []string
+	// MaxBodySize is the largest request or response body recorded.
+	// Exchanges with larger bodies are not recorded.
+	MaxBodySize int64
+	// OnError, if set, is called with errors saving recordings.
+	OnError func(err error)
+}
+
+// Recording is a request and the response it was served, as saved by
+// Record.
+type Recording struct {
+	// Name is the name of the file the Recording was loaded from.
+	Name     string           `json:"-"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request of a Recording.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// Base64 is set if Body is base64 encoded, as it was not UTF-8.
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// RecordedResponse is the response of a Recording.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// Base64 is set if Body is base64 encoded, as it was not UTF-8.
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// unsafeFileName matches runs of characters left out of the names of
+// recording files.
+var unsafeFileName = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Record returns a HandlerWrapper saving each request and the response
+// it is served to a JSON file in `dir`, i.e. to capture the behavior of
+// a Router as golden files for Replay:
+//
+//	router.RegisterWrapper(middleware.Record("testdata/golden", middleware.RecordOptions{}))
+//
+// Secrets in headers, query parameters and bodies are redacted as
+// configured by `opts`. Files are named after the time, a sequence
+// number, the method and the path of the request, so they sort in the
+// order they were recorded.
+func Record(dir string, opts RecordOptions) dispatcher.HandlerWrapper {
+	opts = recordDefaults(opts)
+
+	var sequence atomic.Uint64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if nil != opts.Match && !opts.Match(req) {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			body, ok := bufferBody(req, opts.MaxBodySize)
+			recorder := &cacheRecorder{ResponseWriter: dispatcher.NewResponseWriter(res), limit: opts.MaxBodySize}
+
+			next.ServeHTTP(recorder, req)
+
+			if !ok || recorder.overflowed || recorder.Hijacked() {
+				return
+			}
+
+			recording := &Recording{
+				Request:  recordRequest(req, body, opts),
+				Response: recordResponse(recorder, res.Header(), opts),
+			}
+
+			name := fmt.Sprintf("%s-%06d-%s-%s.json",
+				time.Now().UTC().Format("20060102T150405"),
+				sequence.Add(1),
+				req.Method,
+				strings.Trim(unsafeFileName.ReplaceAllString(req.URL.Path, "-"), "-"),
+			)
+
+			if err := recording.save(filepath.Join(dir, name)); nil != err && nil != opts.OnError {
+				opts.OnError(err)
+			}
+		})
+	}
+}
+
+// LoadRecordings loads the Recordings saved to `dir` by Record, in the
+// order they were recorded.
+func LoadRecordings(dir string) ([]*Recording, error) {
+	entries, err := os.ReadDir(dir)
+
+	if nil != err {
+		return nil, err
+	}
+
+	var recordings []*Recording
+
+	for _, entry := range entries {
+		if entry.IsDir() || ".json" != filepath.Ext(entry.Name()) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+
+		if nil != err {
+			return nil, err
+		}
+
+		recording := &Recording{Name: entry.Name()}
+
+		if err := json.Unmarshal(data, recording); nil != err {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		recordings = append(recordings, recording)
+	}
+
+	return recordings, nil
+}
+
+// Replay serves the request of `recording` with `handler`, i.e. a
+// Router, and compares the response to the one recorded, returning a
+// description of each difference:
+//
+//	for _, recording := range recordings {
+//		for _, diff := range middleware.Replay(router, recording, middleware.RecordOptions{}) {
+//			t.Errorf("%s: %s", recording.Name, diff)
+//		}
+//	}
+//
+// Requests are replayed with their secrets redacted. Only the headers
+// recorded, other than Date, are compared; the response is redacted
+// with `opts` before comparing, so it should match the options the
+// recording was made with.
+func Replay(handler http.Handler, recording *Recording, opts RecordOptions) (diffs []string) {
+	opts = recordDefaults(opts)
+
+	body, err := decodeRecordedBody(recording.Request.Body, recording.Request.Base64)
+
+	if nil != err {
+		return []string{fmt.Sprintf("request body: %v", err)}
+	}
+
+	req := httptest.NewRequest(recording.Request.Method, recording.Request.URL, bytes.NewReader(body))
+	req.Header = recording.Request.Header.Clone()
+
+	if nil == req.Header {
+		req.Header = make(http.Header)
+	}
+
+	res := httptest.NewRecorder()
+	recorder := &cacheRecorder{ResponseWriter: dispatcher.NewResponseWriter(res), limit: opts.MaxBodySize}
+	handler.ServeHTTP(recorder, req)
+
+	expected, actual := recording.Response, recordResponse(recorder, res.Header(), opts)
+
+	if expected.Status != actual.Status {
+		diffs = append(diffs, fmt.Sprintf("status: expected %d, got %d", expected.Status, actual.Status))
+	}
+
+	for _, key := range sortedHeaderKeys(expected.Header) {
+		if "Date" == http.CanonicalHeaderKey(key) {
+			continue
+		}
+
+		want, got := strings.Join(expected.Header[key], ", "), strings.Join(actual.Header.Values(key), ", ")
+
+		if want != got {
+			diffs = append(diffs, fmt.Sprintf("header %s: expected %q, got %q", key, want, got))
+		}
+	}
+
+	if expected.Body != actual.Body || expected.Base64 != actual.Base64 {
+		diffs = append(diffs, fmt.Sprintf("body: expected %q, got %q", expected.Body, actual.Body))
+	}
+
+	return
+}
+
+// recordDefaults fills in the RecordOptions left unset.
+func recordDefaults(opts RecordOptions) RecordOptions {
+	if nil == opts.RedactHeaders {
+		opts.RedactHeaders = DefaultRedactedHeaders
+	}
+
+	if nil == opts.RedactFields {
+		opts.RedactFields = DefaultRedactedFields
+	}
+
+	if 0 >= opts.MaxBodySize {
+		opts.MaxBodySize = DefaultRecordMaxBodySize
+	}
+
+	return opts
+}
+
+// recordRequest returns the redacted RecordedRequest of `req`, whose
+// body is `body`.
+func recordRequest(req *http.Request, body []byte, opts RecordOptions) RecordedRequest {
+	target := *req.URL
+	target.RawQuery = redactValues(target.Query(), opts.RedactFields).Encode()
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    target.RequestURI(),
+		Header: redactHeader(req.Header, opts.RedactHeaders),
+	}
+
+	recorded.Body, recorded.Base64 = encodeRecordedBody(redactBody(req.Header.Get("Content-Type"), body, opts.RedactFields))
+	return recorded
+}
+
+// recordResponse returns the redacted RecordedResponse served through
+// `recorder`. `header` is used if the response was never written.
+func recordResponse(recorder *cacheRecorder, header http.Header, opts RecordOptions) RecordedResponse {
+	status := recorder.Status()
+
+	if 0 == status {
+		status = http.StatusOK
+	}
+
+	if nil != recorder.header {
+		header = recorder.header
+	}
+
+	recorded := RecordedResponse{
+		Status: status,
+		Header: redactHeader(header, opts.RedactHeaders),
+	}
+
+	recorded.Body, recorded.Base64 = encodeRecordedBody(redactBody(header.Get("Content-Type"), recorder.body.Bytes(), opts.RedactFields))
+	return recorded
+}
+
+// save writes the Recording to the file `name`, creating its directory
+// if needed.
+func (r *Recording) save(name string) error {
+	var data bytes.Buffer
+
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(r); nil != err {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); nil != err {
+		return err
+	}
+
+	return os.WriteFile(name, data.Bytes(), 0o644)
+}
+
+// redactHeader returns a copy of `header` with the values of `names`
+// redacted.
+func redactHeader(header http.Header, names []string) http.Header {
+	if 0 == len(header) {
+		return nil
+	}
+
+	redacted := header.Clone()
+
+	for key, values := range redacted {
+		if containsFold(names, key) {
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+
+	return redacted
+}
+
+// redactValues redacts the values of `names` in `values`, returning it.
+func redactValues(values url.Values, names []string) url.Values {
+	for key, vals := range values {
+		if containsFold(names, key) {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+		}
+	}
+
+	return values
+}
+
+// redactBody returns `body` with the values of the fields `names`
+// redacted if it is a JSON or urlencoded form body of type `typ`. Other
+// bodies are returned as is.
+func redactBody(typ string, body []byte, names []string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(typ)
+
+	switch {
+	case 0 == len(body):
+		return body
+	case "application/x-www-form-urlencoded" == mediaType:
+		values, err := url.ParseQuery(string(body))
+
+		if nil != err {
+			return body
+		}
+
+		return []byte(redactValues(values, names).Encode())
+	case "application/json" == mediaType || strings.HasSuffix(mediaType, "+json"):
+		var decoded any
+
+		if err := json.Unmarshal(body, &decoded); nil != err {
+			return body
+		}
+
+		redacted, err := json.Marshal(redactJSON(decoded, names))
+
+		if nil != err {
+			return body
+		}
+
+		return redacted
+	}
+
+	return body
+}
+
+// redactJSON redacts the values of object keys `names` throughout the
+// decoded JSON `value`.
+func redactJSON(value any, names []string) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, v := range value {
+			if containsFold(names, key) {
+				value[key] = Redacted
+			} else {
+				value[key] = redactJSON(v, names)
+			}
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = redactJSON(v, names)
+		}
+	}
+
+	return value
+}
+
+// encodeRecordedBody returns `body` as a string, base64 encoded if it
+// is not UTF-8, and whether it was encoded.
+func encodeRecordedBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// decodeRecordedBody reverses encodeRecordedBody.
+func decodeRecordedBody(body string, encoded bool) ([]byte, error) {
+	if encoded {
+		return base64.StdEncoding.DecodeString(body)
+	}
+
+	return []byte(body), nil
+}
+
+// sortedHeaderKeys returns the keys of `header` in sorted order.
+func sortedHeaderKeys(header http.Header) []string {
+	keys := make([]string, 0, len(header))
+
+	for key := range header {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// containsFold reports whether `value` is one of `values`, ignoring
+// case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestRecordReplay ensures recorded exchanges are redacted, saved and
+// replayed, reporting responses that changed.
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	greeting := "hello"
+
+	router := dispatcher.NewRouter().
+		RegisterWrapper(Record(dir, RecordOptions{OnError: func(err error) { t.Error(err) }})).
+		Post("/login", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Header().Set("Content-Type", "application/json")
+			res.Header().Set("Set-Cookie", "session=secret")
+			res.Write([]byte(`{"greeting":"` + greeting + `","token":"abc"}`))
+		}))
+
+	req := httptest.NewRequest("POST", "/login?api_key=k3y&next=%2F", strings.NewReader(`{"user":"ann","password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-POST-login.json"))
+
+	if 1 != len(files) {
+		t.Fatalf("Expected one recording, got %v.", files)
+	}
+
+	data, _ := os.ReadFile(files[0])
+
+	for _, secret := range []string{"hunter2", "Bearer secret", "session=secret", "abc", "k3y"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be redacted from %s.", secret, data)
+		}
+	}
+
+	recordings, err := LoadRecordings(dir)
+
+	if nil != err || 1 != len(recordings) {
+		t.Fatalf("Expected to load one recording, got %d (%v).", len(recordings), err)
+	}
+
+	if "/login?api_key=%5BREDACTED%5D&next=%2F" != recordings[0].Request.URL {
+		t.Errorf("Expected query to be redacted, got %q.", recordings[0].Request.URL)
+	}
+
+	if diffs := Replay(router, recordings[0], RecordOptions{}); 0 != len(diffs) {
+		t.Errorf("Expected replay to match the recording, got %v.", diffs)
+	}
+
+	greeting = "goodbye"
+
+	if diffs := Replay(router, recordings[0], RecordOptions{}); 1 != len(diffs) || !strings.HasPrefix(diffs[0], "body:") {
+		t.Errorf("Expected replay to report the changed body, got %v.", diffs)
+	}
+}
@@ -0,0 +1,643 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// Defaults used for ResponseCacheOptions left unset.
+const (
+	DefaultResponseCacheTTL         = time.Minute
+	DefaultResponseCacheMaxBodySize = 1 << 20
+)
+
+// CacheStatusHeader is the response header reporting whether a
+// response was served from the cache (`HIT`), served from the cache
+// while being revalidated (`STALE`) or by the handler (`MISS`).
+const CacheStatusHeader = "X-Cache"
+
+// CachedResponse is a response held by a CacheStore.
+type CachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Tags    []string
+	Created time.Time
+	// Expires is when the response stops being fresh.
+	Expires time.Time
+	// StaleUntil is when the response stops being served while it is
+	// revalidated. Stores must keep responses until the later of
+	// Expires and StaleUntil.
+	StaleUntil time.Time
+}
+
+// expiry returns when the response may be dropped from a store.
+func (c *CachedResponse) expiry() time.Time {
+	if c.StaleUntil.After(c.Expires) {
+		return c.StaleUntil
+	}
+
+	return c.Expires
+}
+
+// CacheStore is implemented by the stores a ResponseCache keeps
+// responses in, allowing responses to be shared between processes
+// (i.e. through Redis or memcached). Stores may drop responses at any
+// time, and should drop them once they expire.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+}
+
+// MemoryCacheStore is an in-memory CacheStore evicting the least
+// recently used responses once it holds its maximum number of entries.
+type MemoryCacheStore struct {
+	sync.Mutex
+	// maxEntries is the number of responses that may be held, or zero
+	// for no limit.
+	maxEntries int
+	// entries maps keys to elements of `order`.
+	entries map[string]*list.Element
+	// order holds cached responses, most recently used first.
+	order *list.List
+}
+
+// memoryCacheEntry is a response held by a MemoryCacheStore.
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewMemoryCacheStore creates a new MemoryCacheStore holding up to
+// `maxEntries` responses, or any number if `maxEntries` is zero.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Len returns the number of responses held.
+func (s *MemoryCacheStore) Len() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.order.Len()
+}
+
+// Get returns the response stored under `key`, unless it has expired
+// and may no longer be served stale.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	element, ok := s.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+
+	if time.Now().After(entry.response.expiry()) {
+		s.remove(element)
+		return nil, false
+	}
+
+	s.order.MoveToFront(element)
+	return entry.response, true
+}
+
+// Set stores `response` under `key`, evicting the least recently used
+// response if the store is full.
+func (s *MemoryCacheStore) Set(key string, response *CachedResponse) {
+	s.Lock()
+	defer s.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryCacheEntry{key, response})
+
+	for 0 < s.maxEntries && s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+}
+
+// Delete removes the response stored under `key`.
+func (s *MemoryCacheStore) Delete(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+}
+
+// remove evicts the response held by `element`. The store must be
+// locked by the caller.
+func (s *MemoryCacheStore) remove(element *list.Element) {
+	entry := s.order.Remove(element).(*memoryCacheEntry)
+	delete(s.entries, entry.key)
+}
+
+// ResponseCacheOptions configures a ResponseCache.
+type ResponseCacheOptions struct {
+	// TTL is how long responses are cached when the handler does not
+	// set a max-age or s-maxage Cache-Control directive.
+	TTL time.Duration
+	// Vary lists request headers whose values are part of the cache
+	// key, so requests differing in them are cached separately.
+	// Responses with a Vary header naming other headers are not
+	// cached.
+	Vary []string
+	// MaxBodySize is the size of the largest response body cached.
+	MaxBodySize int64
+	// Router resolves the route names passed to Invalidate.
+	Router *dispatcher.Router
+	// StaleWhileRevalidate is how long after expiring a response is
+	// still served, while it is refreshed in the background, when the
+	// handler does not set a stale-while-revalidate Cache-Control
+	// directive. If zero, expired responses are not served.
+	StaleWhileRevalidate time.Duration
+}
+
+// ErrNoRouter is returned when invalidating a route name without
+// ResponseCacheOptions.Router set.
+var ErrNoRouter = errors.New("middleware: invalidating a route name requires a router")
+
+// ResponseCache caches successful responses to GET and HEAD requests,
+// serving later requests for the same URL from its CacheStore without
+// invoking the handler. Handlers control caching with the Cache-Control
+// response header: `no-store`, `no-cache` and `private` responses are
+// not cached, and `s-maxage` or `max-age` set how long a response is
+// cached for. Responses setting cookies are never cached, and responses
+// to requests carrying an Authorization header only when marked
+// `public` or given an `s-maxage`. Expired responses within their
+// stale-while-revalidate window are served immediately while a single
+// request per key refreshes them in the background, so clients do not
+// wait on expensive handlers. Register it with a Router as a
+// HandlerWrapper, i.e.
+//
+//	cache := middleware.NewResponseCache(middleware.NewMemoryCacheStore(1000))
+//	router.RegisterWrapper(cache.Wrap)
+//
+// Cached responses are purged by URL path, route name or the tags
+// handlers attach with CacheTag. The index used to find them is kept
+// by the ResponseCache, so invalidation only reaches responses cached
+// by the same process.
+type ResponseCache struct {
+	sync.Mutex
+	store   CacheStore
+	options ResponseCacheOptions
+	// paths maps URL paths to the keys of responses cached for them.
+	paths map[string]map[string]struct{}
+	// tags maps tags to the keys of responses carrying them.
+	tags map[string]map[string]struct{}
+	// generation is incremented by every invalidation, so responses
+	// rendered before one are not cached after it.
+	generation uint64
+	// revalidating holds the keys of stale responses being refreshed.
+	revalidating map[string]struct{}
+}
+
+// NewResponseCache creates a ResponseCache keeping responses in
+// `store`.
+func NewResponseCache(store CacheStore, opts ...ResponseCacheOptions) *ResponseCache {
+	var options ResponseCacheOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	if 0 == options.TTL {
+		options.TTL = DefaultResponseCacheTTL
+	}
+
+	if 0 == options.MaxBodySize {
+		options.MaxBodySize = DefaultResponseCacheMaxBodySize
+	}
+
+	return &ResponseCache{
+		store:        store,
+		options:      options,
+		paths:        make(map[string]map[string]struct{}),
+		tags:         make(map[string]map[string]struct{}),
+		revalidating: make(map[string]struct{}),
+	}
+}
+
+// Wrap returns a handler serving requests from the cache, falling back
+// to `next` and caching its response.
+func (c *ResponseCache) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if http.MethodGet != req.Method && http.MethodHead != req.Method {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		directives := parseCacheControl(req.Header.Get("Cache-Control"))
+
+		if _, ok := directives["no-store"]; ok {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		key := c.key(req)
+
+		if _, ok := directives["no-cache"]; !ok {
+			if cached, ok := c.store.Get(key); ok {
+				if time.Now().Before(cached.Expires) {
+					serveCachedResponse(res, req, cached, "HIT")
+					return
+				}
+
+				if time.Now().Before(cached.StaleUntil) {
+					serveCachedResponse(res, req, cached, "STALE")
+					c.revalidate(next, req, key)
+					return
+				}
+			}
+		}
+
+		res.Header().Set(CacheStatusHeader, "MISS")
+		c.fill(next, res, req, key)
+	})
+}
+
+// fill serves `req` with `next`, caching the response under `key` if
+// it is cacheable.
+func (c *ResponseCache) fill(next http.Handler, res http.ResponseWriter, req *http.Request, key string) {
+	c.Lock()
+	generation := c.generation
+	c.Unlock()
+
+	tags := new(cacheTags)
+	recorder := &cacheRecorder{ResponseWriter: dispatcher.NewResponseWriter(res), limit: c.options.MaxBodySize}
+	next.ServeHTTP(recorder, req.WithContext(context.WithValue(req.Context(), cacheTagsContextKey{}, tags)))
+
+	if http.MethodGet != req.Method {
+		return
+	}
+
+	if !recorder.Written() {
+		// The handler wrote nothing, leaving an empty 200 response.
+		recorder.WriteHeader(http.StatusOK)
+	}
+
+	if cached, ok := c.cacheable(req, recorder); ok {
+		cached.Tags = tags.list()
+		c.set(key, req.URL.Path, generation, cached)
+	}
+}
+
+// revalidate refreshes the response cached under `key` in the
+// background, unless it is already being refreshed. The request is
+// detached from the client's, which ends once the stale response has
+// been served.
+func (c *ResponseCache) revalidate(next http.Handler, req *http.Request, key string) {
+	c.Lock()
+
+	if _, ok := c.revalidating[key]; ok {
+		c.Unlock()
+		return
+	}
+
+	c.revalidating[key] = struct{}{}
+	c.Unlock()
+
+	refresh := req.Clone(context.WithoutCancel(req.Context()))
+	refresh.Method, refresh.Body = http.MethodGet, http.NoBody
+
+	go func() {
+		defer func() {
+			c.Lock()
+			delete(c.revalidating, key)
+			c.Unlock()
+		}()
+
+		c.fill(next, discardResponseWriter{make(http.Header)}, refresh, key)
+	}()
+}
+
+// set stores `cached` under `key` and indexes it by `path` and its
+// tags, unless an invalidation happened since `generation`.
+func (c *ResponseCache) set(key, path string, generation uint64, cached *CachedResponse) {
+	c.Lock()
+	defer c.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.store.Set(key, cached)
+	indexCacheKey(c.paths, path, key)
+
+	for _, tag := range cached.Tags {
+		indexCacheKey(c.tags, tag, key)
+	}
+}
+
+// Invalidate purges the responses cached for the Route named `name`
+// with the parameters `params`, i.e.
+//
+//	cache.Invalidate("article.show", id)
+//
+// The Route's URL is built with ResponseCacheOptions.Router and purged
+// with InvalidatePath.
+func (c *ResponseCache) Invalidate(name string, params ...string) error {
+	if nil == c.options.Router {
+		return ErrNoRouter
+	}
+
+	path, err := c.options.Router.URL(name, params...)
+
+	if nil != err {
+		return err
+	}
+
+	c.InvalidatePath(path)
+	return nil
+}
+
+// InvalidatePath purges the responses cached for the URL path `path`,
+// whatever their query strings, hosts or varying headers.
+func (c *ResponseCache) InvalidatePath(path string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.purge(c.paths, path)
+}
+
+// InvalidateTag purges the responses tagged with any of `tags`.
+func (c *ResponseCache) InvalidateTag(tags ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, tag := range tags {
+		c.purge(c.tags, tag)
+	}
+}
+
+// purge deletes the responses indexed under `name` in `index`. The
+// cache must be locked by the caller.
+func (c *ResponseCache) purge(index map[string]map[string]struct{}, name string) {
+	c.generation++
+
+	for key := range index[name] {
+		c.store.Delete(key)
+	}
+
+	delete(index, name)
+}
+
+// indexCacheKey records `key` under `name` in `index`.
+func indexCacheKey(index map[string]map[string]struct{}, name, key string) {
+	if nil == index[name] {
+		index[name] = make(map[string]struct{})
+	}
+
+	index[name][key] = struct{}{}
+}
+
+// cacheTagsContextKey is the context key the tags of the response
+// being rendered are collected under.
+type cacheTagsContextKey struct{}
+
+// cacheTags collects the tags of a response being rendered.
+type cacheTags struct {
+	sync.Mutex
+	tags []string
+}
+
+// list returns the tags collected.
+func (c *cacheTags) list() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.tags
+}
+
+// CacheTag tags the response being rendered for `req` with `tags`, i.e.
+// the records it displays, so it can be purged with InvalidateTag when
+// they change. It has no effect on requests not served through a
+// ResponseCache.
+func CacheTag(req *http.Request, tags ...string) {
+	if collected, ok := req.Context().Value(cacheTagsContextKey{}).(*cacheTags); ok {
+		collected.Lock()
+		collected.tags = append(collected.tags, tags...)
+		collected.Unlock()
+	}
+}
+
+// key returns the cache key of `req`, made of its host, URL and the
+// values of the headers listed in ResponseCacheOptions.Vary.
+func (c *ResponseCache) key(req *http.Request) string {
+	var key strings.Builder
+
+	key.WriteString(req.Host)
+	key.WriteString(req.URL.RequestURI())
+
+	for _, name := range c.options.Vary {
+		key.WriteString("\n")
+		key.WriteString(strings.ToLower(name))
+		key.WriteString(":")
+		key.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	return key.String()
+}
+
+// cacheable returns the response recorded by `recorder` if it may be
+// cached.
+func (c *ResponseCache) cacheable(req *http.Request, recorder *cacheRecorder) (*CachedResponse, bool) {
+	status := recorder.Status()
+
+	if recorder.overflowed || http.StatusOK > status || http.StatusMultipleChoices <= status || http.StatusPartialContent == status {
+		return nil, false
+	}
+
+	header := recorder.header
+
+	if "" != header.Get("Set-Cookie") || !c.varies(header) {
+		return nil, false
+	}
+
+	directives := parseCacheControl(header.Get("Cache-Control"))
+
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return nil, false
+		}
+	}
+
+	ttl := c.options.TTL
+
+	if age, ok := directives["max-age"]; ok {
+		ttl = parseCacheAge(age)
+	}
+
+	shared, ok := directives["s-maxage"]
+
+	if ok {
+		ttl = parseCacheAge(shared)
+	}
+
+	if _, public := directives["public"]; "" != req.Header.Get("Authorization") && !public && !ok {
+		return nil, false
+	}
+
+	if 0 >= ttl {
+		return nil, false
+	}
+
+	stale := c.options.StaleWhileRevalidate
+
+	if window, ok := directives["stale-while-revalidate"]; ok {
+		stale = parseCacheAge(window)
+	}
+
+	now := time.Now()
+	header = header.Clone()
+	header.Del(CacheStatusHeader)
+
+	return &CachedResponse{
+		Status:     status,
+		Header:     header,
+		Body:       recorder.body.Bytes(),
+		Created:    now,
+		Expires:    now.Add(ttl),
+		StaleUntil: now.Add(ttl + stale),
+	}, true
+}
+
+// varies reports whether every header named by the response's Vary
+// header is part of the cache key.
+func (c *ResponseCache) varies(header http.Header) bool {
+	for _, name := range dispatcher.VaryNames(header) {
+		found := false
+
+		for _, vary := range c.options.Vary {
+			found = found || strings.EqualFold(vary, name)
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// serveCachedResponse writes `cached` as the response to `req`,
+// reporting the cache status `status`.
+func serveCachedResponse(res http.ResponseWriter, req *http.Request, cached *CachedResponse, status string) {
+	header := res.Header()
+
+	for name, values := range cached.Header {
+		header[name] = append([]string(nil), values...)
+	}
+
+	header.Set("Age", strconv.Itoa(int(time.Since(cached.Created).Seconds())))
+	header.Set(CacheStatusHeader, status)
+	res.WriteHeader(cached.Status)
+
+	if http.MethodHead != req.Method {
+		res.Write(cached.Body)
+	}
+}
+
+// parseCacheControl parses the directives of a Cache-Control header,
+// mapping their lowercased names to their (unquoted) arguments.
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, directive := range strings.Split(header, ",") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		if "" != name {
+			directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+		}
+	}
+
+	return directives
+}
+
+// parseCacheAge parses a delta-seconds Cache-Control argument,
+// returning zero if it is invalid.
+func parseCacheAge(argument string) time.Duration {
+	seconds, err := strconv.Atoi(argument)
+
+	if nil != err {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// cacheRecorder writes a response through to the client while
+// recording its headers and body, up to `limit` bytes.
+type cacheRecorder struct {
+	*dispatcher.ResponseWriter
+	header     http.Header
+	body       bytes.Buffer
+	limit      int64
+	overflowed bool
+}
+
+// WriteHeader records the headers of the response.
+func (c *cacheRecorder) WriteHeader(status int) {
+	if !c.Written() {
+		c.header = c.ResponseWriter.Header().Clone()
+	}
+
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Write records `data` unless the body has grown too large to cache.
+func (c *cacheRecorder) Write(data []byte) (int, error) {
+	if !c.Written() {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if !c.overflowed {
+		if int64(c.body.Len()+len(data)) > c.limit {
+			c.overflowed = true
+			c.body = bytes.Buffer{}
+		} else {
+			c.body.Write(data)
+		}
+	}
+
+	return c.ResponseWriter.Write(data)
+}
+
+// ReadFrom copies `r` to the response through Write, so it is
+// recorded.
+func (c *cacheRecorder) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{c}, r)
+}
+
+// Flush flushes the response to the client if supported.
+func (c *cacheRecorder) Flush() {
+	if !c.Written() {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	c.ResponseWriter.Flush()
+}
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// generateCachedHandler returns a handler counting its calls and
+// setting the Cache-Control header `cacheControl`.
+func generateCachedHandler(calls *int, cacheControl string) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		*calls++
+
+		if "" != cacheControl {
+			res.Header().Set("Cache-Control", cacheControl)
+		}
+
+		fmt.Fprintf(res, "response %d", *calls)
+	})
+}
+
+// TestResponseCache ensures responses are served from the cache
+// without invoking the handler.
+func TestResponseCache(t *testing.T) {
+	var calls int
+
+	handler := NewResponseCache(NewMemoryCacheStore(10)).Wrap(generateCachedHandler(&calls, ""))
+
+	for i, expected := range []string{"MISS", "HIT"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/articles/1", nil))
+
+		if expected != res.Header().Get(CacheStatusHeader) || "response 1" != res.Body.String() {
+			t.Errorf("Expected request %d to %v with the first response, got %v %q.", i, expected, res.Header().Get(CacheStatusHeader), res.Body.String())
+		}
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("HEAD", "/articles/1", nil))
+
+	if "HIT" != res.Header().Get(CacheStatusHeader) || 0 != res.Body.Len() {
+		t.Errorf("Expected HEAD request to hit without a body, got %v %q.", res.Header().Get(CacheStatusHeader), res.Body.String())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/articles/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/articles/1", nil))
+
+	if 3 != calls {
+		t.Errorf("Expected other URLs and methods to invoke the handler, got %d calls.", calls)
+	}
+}
+
+// TestResponseCacheDirectives ensures handler and request Cache-Control
+// directives are honored.
+func TestResponseCacheDirectives(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		header       http.Header
+		cached       bool
+	}{
+		{"max-age=60", nil, true},
+		{"max-age=0", nil, false},
+		{"s-maxage=60, max-age=0", nil, true},
+		{"no-store", nil, false},
+		{"private, max-age=60", nil, false},
+		{"max-age=60", http.Header{"Authorization": {"Bearer token"}}, false},
+		{"public, max-age=60", http.Header{"Authorization": {"Bearer token"}}, true},
+		{"max-age=60", http.Header{"Cache-Control": {"no-store"}}, false},
+	}
+
+	for _, test := range tests {
+		var calls int
+
+		handler := NewResponseCache(NewMemoryCacheStore(10)).Wrap(generateCachedHandler(&calls, test.cacheControl))
+
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest("GET", "/", nil)
+
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if cached := 1 == calls; test.cached != cached {
+			t.Errorf("Expected caching to be %v for %q with %v, got %d calls.", test.cached, test.cacheControl, test.header, calls)
+		}
+	}
+}
+
+// TestResponseCacheVary ensures requests are cached separately by the
+// configured Vary headers, and responses varying on others are not
+// cached.
+func TestResponseCacheVary(t *testing.T) {
+	var calls int
+
+	handler := NewResponseCache(NewMemoryCacheStore(10), ResponseCacheOptions{Vary: []string{"Accept-Language"}}).Wrap(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.Header().Set("Vary", req.URL.Query().Get("vary"))
+		fmt.Fprint(res, req.Header.Get("Accept-Language"))
+	}))
+
+	for _, language := range []string{"en", "fr", "en"} {
+		req := httptest.NewRequest("GET", "/?vary=Accept-Language", nil)
+		req.Header.Set("Accept-Language", language)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if language != res.Body.String() {
+			t.Errorf("Expected the %v response, got %q.", language, res.Body.String())
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?vary=Cookie", nil))
+	}
+
+	if 4 != calls {
+		t.Errorf("Expected 4 calls, got %d.", calls)
+	}
+}
+
+// TestMemoryCacheStoreEviction ensures the least recently used
+// responses are evicted.
+func TestMemoryCacheStoreEviction(t *testing.T) {
+	var calls int
+
+	store := NewMemoryCacheStore(2)
+	handler := NewResponseCache(store).Wrap(generateCachedHandler(&calls, ""))
+
+	for _, path := range []string{"/a", "/b", "/a", "/c", "/a", "/b"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if 4 != calls || 2 != store.Len() {
+		t.Errorf("Expected 4 calls and 2 entries, got %d calls and %d entries.", calls, store.Len())
+	}
+}
+
+// TestResponseCacheInvalidate ensures responses are purged by route
+// name, path and tag.
+func TestResponseCacheInvalidate(t *testing.T) {
+	var calls int
+
+	router := dispatcher.NewRouter()
+	cache := NewResponseCache(NewMemoryCacheStore(10), ResponseCacheOptions{Router: router})
+
+	router.RegisterWrapper(cache.Wrap).
+		Get("/articles/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			calls++
+			CacheTag(req, "article:"+dispatcher.ParamsFromContext(req.Context()).Get("id"), "articles")
+		})).Name("article.show")
+
+	request := func(path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	request("/articles/1")
+	request("/articles/1?page=2")
+	request("/articles/2")
+
+	if err := cache.Invalidate("article.show", "1"); nil != err {
+		t.Fatal(err)
+	}
+
+	request("/articles/1")
+	request("/articles/1?page=2")
+	request("/articles/2")
+
+	if 5 != calls {
+		t.Errorf("Expected only article 1 to be purged by name, got %d calls.", calls)
+	}
+
+	cache.InvalidateTag("article:2")
+	request("/articles/1")
+	request("/articles/2")
+
+	cache.InvalidateTag("articles")
+	request("/articles/1")
+
+	if 7 != calls {
+		t.Errorf("Expected tagged responses to be purged, got %d calls.", calls)
+	}
+
+	if err := NewResponseCache(NewMemoryCacheStore(10)).Invalidate("article.show", "1"); ErrNoRouter != err {
+		t.Errorf("Expected ErrNoRouter, got %v.", err)
+	}
+}
+
+// TestResponseCacheStaleWhileRevalidate ensures expired responses are
+// served while a single background request refreshes them.
+func TestResponseCacheStaleWhileRevalidate(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		release = make(chan struct{})
+	)
+
+	handler := NewResponseCache(NewMemoryCacheStore(10), ResponseCacheOptions{TTL: time.Second, StaleWhileRevalidate: time.Minute}).Wrap(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if call := calls.Add(1); 1 < call {
+			<-release
+		}
+
+		res.Header().Set("Cache-Control", "max-age=1")
+		fmt.Fprintf(res, "response %d", calls.Load())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	time.Sleep(1100 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		if "STALE" != res.Header().Get(CacheStatusHeader) || "response 1" != res.Body.String() {
+			t.Errorf("Expected the stale response, got %v %q.", res.Header().Get(CacheStatusHeader), res.Body.String())
+		}
+	}
+
+	close(release)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		if "HIT" == res.Header().Get(CacheStatusHeader) {
+			if "response 2" != res.Body.String() {
+				t.Errorf("Expected the refreshed response, got %q.", res.Body.String())
+			}
+
+			break
+		}
+	}
+
+	if 2 != calls.Load() {
+		t.Errorf("Expected a single refresh, got %d calls.", calls.Load())
+	}
+}
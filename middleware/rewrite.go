@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// RewriteRule returns the URL `u` is rewritten to, with its path
+// escaped, i.e. `/pages/about` or `/pages?name=about`, and whether the
+// rule applies to it.
+type RewriteRule func(u *url.URL) (string, bool)
+
+// rewriteTargetParams matches the parameters and wildcard of a
+// RewritePath target.
+var rewriteTargetParams = regexp.MustCompile(`(/?):(\w+)(\??)|\*`)
+
+// Rewrite returns a Plugin rewriting the URL of requests before they
+// are matched, using the first of `rules` which applies, so legacy URLs
+// are served by the current Routes without redirecting clients, i.e.
+//
+//	router.RegisterPlugin(middleware.Rewrite(
+//		middleware.RewritePath("/blog/:slug", "/posts/:slug"),
+//		middleware.RewriteRegexp(`^/index\.php\?page=(\w+)$`, "/pages/$1"),
+//	))
+//
+// A target carrying a query string replaces the request's query,
+// otherwise the request's query is kept. The request's `RequestURI`
+// still holds the URL the client requested.
+func Rewrite(rules ...RewriteRule) dispatcher.PluginFunc {
+	return func(req *http.Request) *http.Request {
+		for _, rule := range rules {
+			target, ok := rule(req.URL)
+
+			if !ok {
+				continue
+			}
+
+			path, query, replaced := strings.Cut(target, "?")
+			setRewrittenPath(req.URL, path)
+
+			if replaced {
+				req.URL.RawQuery = query
+			}
+
+			break
+		}
+
+		return req
+	}
+}
+
+// RewritePath returns a RewriteRule rewriting paths matching `from`, a
+// path in the syntax of Routes, to `to`, whose parameters and wildcard
+// are filled with those of the same name captured from the path, i.e.
+//
+//	middleware.RewritePath("/docs/:version/*", "/manual/*")
+//
+// Parameters `from` doesn't capture are left empty, and optional ones
+// are left out.
+func RewritePath(from, to string) RewriteRule {
+	route := dispatcher.NewRoute(from, false)
+
+	return func(u *url.URL) (string, bool) {
+		params, ok := route.Match(u.Path)
+
+		if !ok {
+			return "", false
+		}
+
+		target := rewriteTargetParams.ReplaceAllStringFunc(to, func(param string) string {
+			if "*" == param {
+				return escapeSegments(params.Get("*"))
+			}
+
+			parts := rewriteTargetParams.FindStringSubmatch(param)
+			value := params.Get(parts[2])
+
+			// Empty optional parameters are left out with their slash.
+			if "" == value && "?" == parts[3] {
+				return ""
+			}
+
+			return parts[1] + url.PathEscape(value)
+		})
+
+		return target, true
+	}
+}
+
+// RewriteRegexp returns a RewriteRule rewriting paths matching the
+// regular expression `pattern` to `replacement`, which may refer to its
+// submatches as `$1` or `${name}`. The pattern is matched against the
+// escaped path. If `pattern` contains `\?`, it is matched against the
+// escaped path followed by `?` and the raw query, so
+// requests can be rewritten by their query, which is then replaced by
+// that of `replacement`. It panics if `pattern` can't be compiled.
+func RewriteRegexp(pattern, replacement string) RewriteRule {
+	matcher := regexp.MustCompile(pattern)
+	withQuery := strings.Contains(pattern, `\?`)
+
+	return func(u *url.URL) (string, bool) {
+		subject := u.EscapedPath()
+
+		if withQuery && "" != u.RawQuery {
+			subject += "?" + u.RawQuery
+		}
+
+		match := matcher.FindStringSubmatchIndex(subject)
+
+		if nil == match {
+			return "", false
+		}
+
+		target := string(matcher.ExpandString(nil, replacement, subject, match))
+
+		if withQuery && !strings.Contains(target, "?") {
+			// The query was matched, so it is not kept.
+			target += "?"
+		}
+
+		return target, true
+	}
+}
+
+// setRewrittenPath sets the path of `u` to the escaped path `escaped`,
+// keeping it as the RawPath if it is not the default encoding, i.e. of
+// an escaped slash.
+func setRewrittenPath(u *url.URL, escaped string) {
+	path, err := url.PathUnescape(escaped)
+
+	if nil != err {
+		u.Path, u.RawPath = escaped, ""
+		return
+	}
+
+	u.Path, u.RawPath = path, escaped
+
+	if escaped == (&url.URL{Path: path}).EscapedPath() {
+		u.RawPath = ""
+	}
+}
+
+// escapeSegments escapes each segment of the slash separated `path`.
+func escapeSegments(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRewrite ensures requests are rewritten by the first rule
+// applying to them, keeping or replacing their query.
+func TestRewrite(t *testing.T) {
+	plugin := Rewrite(
+		RewritePath("/blog/:slug", "/posts/:slug"),
+		RewritePath("/docs/:version/*", "/manual/*"),
+		RewritePath("/archive/:year/:month?", "/posts/:year/:month?"),
+		RewriteRegexp(`^/index\.php\?page=(\w+)$`, "/pages/$1"),
+		RewriteRegexp(`^/search/(?P<term>\w+)$`, "/search?q=${term}"),
+	)
+
+	for target, expected := range map[string]string{
+		"/blog/hello?ref=feed":        "/posts/hello?ref=feed",
+		"/docs/v2/guide/routing":      "/manual/guide/routing",
+		"/archive/2024":               "/posts/2024",
+		"/archive/2024/05":            "/posts/2024/05",
+		"/index.php?page=about":       "/pages/about",
+		"/index.php?page=about&x=1":   "/index.php?page=about&x=1",
+		"/search/routers?page=2":      "/search?q=routers",
+		"/unmatched/path?left=intact": "/unmatched/path?left=intact",
+	} {
+		req := plugin(httptest.NewRequest("GET", target, nil))
+
+		if expected != req.URL.RequestURI() {
+			t.Errorf("Expected %s to be rewritten to %s, got %s.", target, expected, req.URL.RequestURI())
+		}
+
+		if target != req.RequestURI {
+			t.Errorf("Expected the RequestURI %s to be kept, got %s.", target, req.RequestURI)
+		}
+	}
+}
+
+// TestRewriteEscaping ensures escaped characters of rewritten paths
+// reach handlers decoded once.
+func TestRewriteEscaping(t *testing.T) {
+	plugin := Rewrite(
+		RewritePath("/blog/:slug", "/posts/:slug"),
+		RewritePath("/docs/:version/*", "/manual/:version/*"),
+		RewriteRegexp(`^/files/(.+)$`, "/storage/$1"),
+	)
+
+	for target, expected := range map[string][2]string{
+		"/blog/hello%20world":  {"/posts/hello world", "/posts/hello%20world"},
+		"/docs/v%201/a%20b/c":  {"/manual/v 1/a b/c", "/manual/v%201/a%20b/c"},
+		"/files/a%2Fb%20c":     {"/storage/a/b c", "/storage/a%2Fb%20c"},
+		"/files/report%25.pdf": {"/storage/report%.pdf", "/storage/report%25.pdf"},
+	} {
+		req := plugin(httptest.NewRequest("GET", target, nil))
+
+		if expected[0] != req.URL.Path || expected[1] != req.URL.EscapedPath() {
+			t.Errorf("Expected %s to be rewritten to %q (%s), got %q (%s).", target, expected[0], expected[1], req.URL.Path, req.URL.EscapedPath())
+		}
+	}
+}
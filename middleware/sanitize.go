@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// SanitizePolicy determines how the Sanitize middleware treats
+// requests containing unsafe characters.
+type SanitizePolicy int
+
+const (
+	// RejectUnsafe responds to unsafe requests with `400 Bad Request`.
+	RejectUnsafe SanitizePolicy = iota
+	// CleanUnsafe strips unsafe characters from the request and allows
+	// it to continue on to other middleware and Routes.
+	CleanUnsafe
+)
+
+// Sanitize returns a middleware function inspecting the request's path
+// and headers for null bytes, control characters, and invalid or
+// overlong UTF-8 sequences (i.e. a percent-encoded `%C0%AE`). Depending
+// on `policy`, unsafe requests are either rejected or cleaned before
+// route matching.
+func Sanitize(policy SanitizePolicy) dispatcher.MiddlewareHandler {
+	return func(res http.ResponseWriter, req *http.Request) bool {
+		if isSafeRequest(req) {
+			return false
+		}
+
+		if RejectUnsafe == policy {
+			http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return true
+		}
+
+		req.URL.Path = stripUnsafe(req.URL.Path, false)
+		req.URL.RawPath = ""
+
+		for name, values := range req.Header {
+			for i, value := range values {
+				values[i] = stripUnsafe(value, true)
+			}
+
+			req.Header[name] = values
+		}
+
+		return false
+	}
+}
+
+// isSafeRequest reports whether the request's path and header values
+// are free of unsafe characters.
+func isSafeRequest(req *http.Request) bool {
+	if !isSafeString(req.URL.Path, false) {
+		return false
+	}
+
+	for _, values := range req.Header {
+		for _, value := range values {
+			if !isSafeString(value, true) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isSafeString reports whether `s` is valid UTF-8 free of control
+// characters. Horizontal tabs are permitted when `allowTab` is true,
+// as they are valid within header values.
+func isSafeString(s string, allowTab bool) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+
+	for _, c := range s {
+		if isUnsafeRune(c, allowTab) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stripUnsafe removes invalid UTF-8 sequences and control characters
+// from `s`.
+func stripUnsafe(s string, allowTab bool) string {
+	return strings.Map(func(c rune) rune {
+		if isUnsafeRune(c, allowTab) {
+			return -1
+		}
+
+		return c
+	}, strings.ToValidUTF8(s, ""))
+}
+
+// isUnsafeRune reports whether `c` is a control character.
+func isUnsafeRune(c rune, allowTab bool) bool {
+	if '\t' == c && allowTab {
+		return false
+	}
+
+	return c < 0x20 || 0x7f == c
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSanitizeRejectsUnsafePath ensures requests with control
+// characters in their path are rejected with a 400.
+func TestSanitizeRejectsUnsafePath(t *testing.T) {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/file%00.txt", nil)
+
+	if !Sanitize(RejectUnsafe)(res, req) {
+		t.Fatal("Expected Sanitize to handle the unsafe request.")
+	}
+
+	if http.StatusBadRequest != res.Code {
+		t.Errorf("Expected status %d, got %d.", http.StatusBadRequest, res.Code)
+	}
+}
+
+// TestSanitizeCleansUnsafeRequest ensures unsafe characters are
+// stripped from the path and headers when cleaning.
+func TestSanitizeCleansUnsafeRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/a%C0%AE%00b", nil)
+	req.Header.Set("X-Test", "one\x01two")
+
+	if Sanitize(CleanUnsafe)(httptest.NewRecorder(), req) {
+		t.Fatal("Expected Sanitize to allow the cleaned request to continue.")
+	}
+
+	if "/ab" != req.URL.Path {
+		t.Errorf("Expected path to be cleaned to %q, got %q.", "/ab", req.URL.Path)
+	}
+
+	if "onetwo" != req.Header.Get("X-Test") {
+		t.Errorf("Expected header to be cleaned to %q, got %q.", "onetwo", req.Header.Get("X-Test"))
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// DefaultScopesKey is the Route tag key read by RequireScopes when
+// ScopeOptions leaves it unset.
+const DefaultScopesKey = "scopes"
+
+// ScopeOptions configures the RequireScopes route middleware.
+type ScopeOptions struct {
+	// ScopesKey is the key of the Route tag listing the OAuth scopes
+	// required to request the Route. A token needs all of them.
+	ScopesKey string
+	// Realm, if set, is included in `WWW-Authenticate` challenges.
+	Realm string
+	// Principal, if set, resolves the ScopedPrincipal of a request. By
+	// default it is read with dispatcher.PrincipalFromContext.
+	Principal func(req *http.Request) (dispatcher.ScopedPrincipal, bool)
+}
+
+// RequireScopes returns a route middleware function enforcing the
+// OAuth scopes Routes are tagged with, i.e.
+//
+//	router.RegisterRouteMiddleware(middleware.RequireScopes(middleware.ScopeOptions{Realm: "api"}))
+//	router.Post("/orders", CreateOrderHandler).Meta("scopes", "orders:write")
+//
+// Responses follow RFC 6750: requests for tagged Routes without a token
+// are served 401 Unauthorized with a `Bearer` challenge; those whose
+// token lacks a scope are served 403 Forbidden with an
+// `insufficient_scope` error listing the scopes required. Routes
+// without tags are left alone.
+func RequireScopes(opts ScopeOptions) dispatcher.RouteMiddlewareHandler {
+	if "" == opts.ScopesKey {
+		opts.ScopesKey = DefaultScopesKey
+	}
+
+	if nil == opts.Principal {
+		opts.Principal = func(req *http.Request) (dispatcher.ScopedPrincipal, bool) {
+			principal, _ := dispatcher.PrincipalFromContext(req.Context())
+			scoped, ok := principal.(dispatcher.ScopedPrincipal)
+			return scoped, ok
+		}
+	}
+
+	return func(res http.ResponseWriter, req *http.Request, route *dispatcher.Route, params dispatcher.Params) bool {
+		scopes := route.Meta(opts.ScopesKey)
+
+		if 0 == len(scopes) {
+			return false
+		}
+
+		principal, ok := opts.Principal(req)
+
+		if !ok || nil == principal {
+			res.Header().Set("WWW-Authenticate", bearerChallenge(opts.Realm))
+			http.Error(res, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return true
+		}
+
+		for _, scope := range scopes {
+			if principal.HasScope(scope) {
+				continue
+			}
+
+			res.Header().Set("WWW-Authenticate", bearerChallenge(opts.Realm,
+				"error", "insufficient_scope",
+				"error_description", "The access token lacks a required scope",
+				"scope", strings.Join(scopes, " "),
+			))
+
+			http.Error(res, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return true
+		}
+
+		return false
+	}
+}
+
+// bearerChallenge builds a `Bearer` challenge for the
+// `WWW-Authenticate` header from `realm`, if not empty, and the
+// key-value pairs of `attributes`.
+func bearerChallenge(realm string, attributes ...string) string {
+	if "" != realm {
+		attributes = append([]string{"realm", realm}, attributes...)
+	}
+
+	var params []string
+
+	for i := 0; i+1 < len(attributes); i += 2 {
+		params = append(params, fmt.Sprintf("%s=%q", attributes[i], attributes[i+1]))
+	}
+
+	if 0 == len(params) {
+		return "Bearer"
+	}
+
+	return "Bearer " + strings.Join(params, ", ")
+}
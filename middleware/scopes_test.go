@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// TestRequireScopes ensures Routes tagged with scopes are only served
+// to tokens granting them, with RFC 6750 challenges otherwise.
+func TestRequireScopes(t *testing.T) {
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	router := dispatcher.NewRouter().
+		RegisterRouteMiddleware(RequireScopes(ScopeOptions{Realm: "api"})).
+		Get("/orders", ok).
+		Post("/orders", ok).Meta("scopes", "orders:read", "orders:write")
+
+	router.RegisterPlugin(dispatcher.PluginFunc(func(req *http.Request) *http.Request {
+		if scope := req.Header.Get("X-Scope"); "" != scope {
+			principal := dispatcher.BasicPrincipal{Scopes: dispatcher.ParseScope(scope)}
+			return req.WithContext(dispatcher.WithPrincipal(req.Context(), principal))
+		}
+
+		return req
+	}))
+
+	tests := []struct {
+		method, scope string
+		status        int
+		challenge     string
+	}{
+		{"GET", "", http.StatusOK, ""},
+		{"POST", "", http.StatusUnauthorized, `Bearer realm="api"`},
+		{"POST", "orders:read", http.StatusForbidden, `Bearer realm="api", error="insufficient_scope", error_description="The access token lacks a required scope", scope="orders:read orders:write"`},
+		{"POST", "orders:write orders:read", http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/orders", nil)
+		req.Header.Set("X-Scope", test.scope)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if test.status != res.Code {
+			t.Errorf("Expected %s with scope %q to be served %d, got %d.", test.method, test.scope, test.status, res.Code)
+		}
+
+		if challenge := res.Header().Get("WWW-Authenticate"); test.challenge != challenge {
+			t.Errorf("Expected %s with scope %q to be challenged with %q, got %q.", test.method, test.scope, test.challenge, challenge)
+		}
+	}
+}
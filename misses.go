@@ -0,0 +1,115 @@
+package dispatcher
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultMissCacheSize is the number of paths remembered by CacheMisses
+// when given no size.
+const DefaultMissCacheSize = 1024
+
+// missCache remembers the most recent paths no Route matched, evicting
+// the least recently missed once full.
+type missCache struct {
+	sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// miss is a path no Route matched, cached by a missCache.
+type miss struct {
+	key string
+	// allow are the methods of the Routes matching the path.
+	allow []string
+}
+
+// newMissCache creates a missCache remembering up to `size` paths.
+func newMissCache(size int) *missCache {
+	return &missCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// CacheMisses makes the Router remember the last `size` method and path
+// pairs no Route matched, i.e. under scanners probing thousands of
+// nonexistent URLs, so repeated requests for them skip matching the
+// Router's Routes and go straight to its not found handling. Not found
+// hooks, the fallback handler and the not found handler still serve
+// them. The cache is cleared whenever Routes are registered or API
+// versions configured, and unused once Routes are scheduled with
+// ActiveWhen. A non-positive `size` uses DefaultMissCacheSize.
+func (r *Router) CacheMisses(size int) *Router {
+	if 0 >= size {
+		size = DefaultMissCacheSize
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.misses = newMissCache(size)
+	return r
+}
+
+// missKey is the key the miss of `path` for `method` is cached under.
+func missKey(method, path string) string {
+	return method + " " + path
+}
+
+// get reports whether `method` and `path` are known to match no Route,
+// marking them as recently missed if so, and returns the methods of the
+// Routes matching `path`. A nil cache knows no misses.
+func (c *missCache) get(method, path string) ([]string, bool) {
+	if nil == c {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	element, ok := c.entries[missKey(method, path)]
+
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*miss).allow, true
+}
+
+// add remembers that `method` and `path` match no Route, but Routes for
+// the methods `allow`, evicting the least recently missed paths if the
+// cache is full.
+func (c *missCache) add(method, path string, allow []string) {
+	if nil == c {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	key := missKey(method, path)
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&miss{key, allow})
+
+	for c.size < c.order.Len() {
+		delete(c.entries, c.order.Remove(c.order.Back()).(*miss).key)
+	}
+}
+
+// reset forgets all misses, i.e. once Routes were registered that may
+// match them.
+func (c *missCache) reset() {
+	if nil == c {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.order.Init()
+	clear(c.entries)
+}
@@ -0,0 +1,59 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCacheMisses ensures missed paths are remembered until Routes are
+// registered, and still served by the not found handler.
+func TestCacheMisses(t *testing.T) {
+	var notFound int
+
+	router := NewRouter().CacheMisses(0)
+	router.OnNotFound(func(req *http.Request) { notFound++ })
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, "/missing"))
+
+		if http.StatusNotFound != res.Code {
+			t.Errorf("Expected a missed path to be served 404, got %d.", res.Code)
+		}
+	}
+
+	if 2 != notFound || !cached(router.misses, GET, "/missing") {
+		t.Errorf("Expected the miss to be cached and hooks called twice, got %d calls.", notFound)
+	}
+
+	router.Get("/missing", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/missing"))
+
+	if http.StatusOK != res.Code {
+		t.Errorf("Expected registering a Route to clear missed paths, got %d.", res.Code)
+	}
+}
+
+// TestMissCacheEviction ensures the least recently missed paths are
+// evicted once the cache is full.
+func TestMissCacheEviction(t *testing.T) {
+	cache := newMissCache(2)
+
+	cache.add(GET, "/a", nil)
+	cache.add(GET, "/b", nil)
+	cache.get(GET, "/a")
+	cache.add(GET, "/c", []string{POST})
+
+	if !cached(cache, GET, "/a") || cached(cache, GET, "/b") || !cached(cache, GET, "/c") || cached(cache, POST, "/a") {
+		t.Error("Expected the least recently missed path to be evicted.")
+	}
+}
+
+// cached reports whether `cache` knows `method` and `path` match no
+// Route.
+func cached(cache *missCache, method, path string) bool {
+	_, ok := cache.get(method, path)
+	return ok
+}
@@ -0,0 +1,91 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MountOptions configures Mount.
+type MountOptions struct {
+	// StripPrefix removes the mount prefix from the paths of requests
+	// before they are handed to the mounted handler, so handlers
+	// expecting to be served from the root, i.e. an http.FileServer,
+	// see the paths they expect. The original path is available with
+	// OriginalPathFromContext.
+	StripPrefix bool
+	// Methods are registered besides those Match registers, i.e. the
+	// WebDAV methods.
+	Methods []string
+}
+
+// originalPathContextKey is the context key the path of a request
+// before a mount prefix was stripped from it is stored under.
+type originalPathContextKey struct{}
+
+// Mount registers Routes for the HTTP methods Match registers, and the
+// methods of `opts.Methods`, matching `prefix` and the paths beneath it
+// served by `handler`, i.e. a third-party handler or another Router:
+//
+//	router.Mount("/assets", http.FileServer(http.Dir("./public")), dispatcher.MountOptions{StripPrefix: true})
+//	router.Mount("/admin", admin, dispatcher.MountOptions{})
+//
+// Without StripPrefix, the handler is given the request's full path.
+func (r *Router) Mount(prefix string, handler http.Handler, opts MountOptions) *Router {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	if opts.StripPrefix {
+		handler = stripMountPrefix(prefix, handler)
+	}
+
+	var created []*Route
+
+	for _, path := range []string{prefix, strings.TrimSuffix(prefix, "/") + "/*"} {
+		r.Match(path, handler)
+		created = append(created, r.current...)
+
+		for _, method := range opts.Methods {
+			r.AddHandler(method, path, handler)
+			created = append(created, r.current...)
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.current = created
+	return r
+}
+
+// OriginalPathFromContext returns the path of the request whose context
+// is `ctx` before a mount prefix was stripped from it, and whether one
+// was. For nested mounts, the path the outermost mount was requested
+// with is returned.
+func OriginalPathFromContext(ctx context.Context) (string, bool) {
+	path, ok := ctx.Value(originalPathContextKey{}).(string)
+	return path, ok
+}
+
+// stripMountPrefix returns a handler serving requests with `handler`
+// once `prefix` was removed from their path.
+func stripMountPrefix(prefix string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if _, ok := OriginalPathFromContext(ctx); !ok {
+			ctx = context.WithValue(ctx, originalPathContextKey{}, req.URL.Path)
+		}
+
+		stripped := req.WithContext(ctx)
+		stripped.URL = new(url.URL)
+		*stripped.URL = *req.URL
+		stripped.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(req.URL.Path, prefix), "/")
+
+		if "" != req.URL.RawPath {
+			stripped.URL.RawPath = "/" + strings.TrimLeft(strings.TrimPrefix(req.URL.RawPath, prefix), "/")
+		}
+
+		handler.ServeHTTP(res, stripped)
+	})
+}
@@ -0,0 +1,73 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMount ensures mounted handlers are served the paths beneath their
+// prefix, stripped of it if requested.
+func TestMount(t *testing.T) {
+	echo := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		original, _ := OriginalPathFromContext(req.Context())
+		res.Write([]byte(req.URL.Path + " " + original))
+	})
+
+	admin := NewRouter().Get("/users/:id", echo)
+
+	router := NewRouter().
+		Mount("/assets/", echo, MountOptions{StripPrefix: true}).
+		Mount("/full", echo, MountOptions{}).
+		Mount("/admin", admin, MountOptions{StripPrefix: true})
+
+	for path, expected := range map[string]string{
+		"/assets":            "/ /assets",
+		"/assets/css/a.css":  "/css/a.css /assets/css/a.css",
+		"/full/x":            "/full/x ",
+		"/admin/users/42":    "/users/42 /admin/users/42",
+		"/assetsmore/a.css":  "404 page not found\n",
+		"/admin/unknown/404": "404 page not found\n",
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if expected != res.Body.String() {
+			t.Errorf("Expected %s to serve %q, got %q.", path, expected, res.Body.String())
+		}
+	}
+
+	if 6*(len(httpMethods)-1) != len(router.Routes()) {
+		t.Errorf("Expected each mount to register the prefix and paths beneath it, got %d Routes.", len(router.Routes()))
+	}
+}
+
+// TestMountMethods ensures Mount registers the extra methods requested
+// while Match leaves WebDAV methods out.
+func TestMountMethods(t *testing.T) {
+	router := NewRouter().
+		Match("/status", generateCountableHandler(new(int))).
+		Mount("/files", generateCountableHandler(new(int)), MountOptions{Methods: []string{PROPFIND}})
+
+	for _, test := range []struct {
+		method, path string
+		expected     bool
+	}{
+		{PROPFIND, "/files/a.txt", true},
+		{MKCOL, "/files/a.txt", false},
+		{GET, "/files/a.txt", true},
+		{PROPFIND, "/status", false},
+	} {
+		if _, _, ok := router.Lookup(test.method, test.path); test.expected != ok {
+			t.Errorf("Expected %s %s to match: %t.", test.method, test.path, test.expected)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(PROPFIND, "/status"))
+
+	if http.StatusMethodNotAllowed != res.Code || strings.Contains(res.Header().Get("Allow"), PROPFIND) {
+		t.Errorf("Expected PROPFIND /status to be answered with 405 without WebDAV methods allowed, got %d %q.", res.Code, res.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,144 @@
+package dispatcher
+
+import (
+	"sort"
+)
+
+// namedMiddleware pairs Middleware with the name it was registered
+// under, if any, and its priority.
+type namedMiddleware struct {
+	name       string
+	middleware Middleware
+	priority   int
+}
+
+// addMiddleware registers `named`, keeping the Router's Middleware
+// ordered by priority. The Router must be locked by the caller.
+func (r *Router) addMiddleware(named namedMiddleware) {
+	middleware := append(r.middleware[:len(r.middleware):len(r.middleware)], named)
+
+	sort.SliceStable(middleware, func(i, j int) bool {
+		return middleware[i].priority < middleware[j].priority
+	})
+
+	r.middleware = middleware
+}
+
+// RegisterMiddlewarePriority registers Middleware with an explicit
+// priority. Middleware with lower priorities run first; Middleware
+// sharing a priority run in the order they were registered.
+// Middleware registered otherwise has a priority of 0.
+func (r *Router) RegisterMiddlewarePriority(middleware Middleware, priority int) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.addMiddleware(namedMiddleware{middleware: middleware, priority: priority})
+	return r
+}
+
+// InsertMiddlewareBefore registers Middleware under `name` to run just
+// before the Middleware named `before`, sharing its priority, and
+// reports whether `before` was found. `name` may be empty.
+func (r *Router) InsertMiddlewareBefore(before, name string, middleware Middleware) bool {
+	return r.insertMiddleware(before, 0, name, middleware)
+}
+
+// InsertMiddlewareAfter registers Middleware under `name` to run just
+// after the Middleware named `after`, sharing its priority, and
+// reports whether `after` was found. `name` may be empty.
+func (r *Router) InsertMiddlewareAfter(after, name string, middleware Middleware) bool {
+	return r.insertMiddleware(after, 1, name, middleware)
+}
+
+// insertMiddleware registers Middleware at `offset` from the position
+// of the Middleware named `relative`.
+func (r *Router) insertMiddleware(relative string, offset int, name string, middleware Middleware) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i, named := range r.middleware {
+		if relative != named.name {
+			continue
+		}
+
+		inserted := make([]namedMiddleware, 0, len(r.middleware)+1)
+		inserted = append(inserted, r.middleware[:i+offset]...)
+		inserted = append(inserted, namedMiddleware{name, middleware, named.priority})
+		r.middleware = append(inserted, r.middleware[i+offset:]...)
+		return true
+	}
+
+	return false
+}
+
+// RegisterNamedMiddleware registers Middleware under `name`, so it can
+// later be removed or replaced while the Router is serving, i.e. to
+// toggle rate limiting or authentication operationally. Registering a
+// name already in use replaces the Middleware registered under it.
+func (r *Router) RegisterNamedMiddleware(name string, middleware Middleware) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.replaceMiddleware(name, middleware) {
+		r.addMiddleware(namedMiddleware{name: name, middleware: middleware})
+	}
+
+	return r
+}
+
+// RemoveMiddleware removes the Middleware registered under `name`,
+// reporting whether there was any. Requests already being served are
+// unaffected.
+func (r *Router) RemoveMiddleware(name string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i, named := range r.middleware {
+		if name == named.name {
+			remaining := make([]namedMiddleware, 0, len(r.middleware)-1)
+			r.middleware = append(append(remaining, r.middleware[:i]...), r.middleware[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReplaceMiddleware replaces the Middleware registered under `name`,
+// keeping its position, and reports whether there was any.
+func (r *Router) ReplaceMiddleware(name string, middleware Middleware) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.replaceMiddleware(name, middleware)
+}
+
+// replaceMiddleware replaces the Middleware registered under `name`.
+// The Router must be locked by the caller.
+func (r *Router) replaceMiddleware(name string, middleware Middleware) bool {
+	for i, named := range r.middleware {
+		if name == named.name {
+			replaced := append([]namedMiddleware(nil), r.middleware...)
+			replaced[i].middleware = middleware
+			r.middleware = replaced
+			return true
+		}
+	}
+
+	return false
+}
+
+// MiddlewareNames returns the names of the Router's named Middleware in
+// the order they run.
+func (r *Router) MiddlewareNames() (names []string) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, named := range r.middleware {
+		if "" != named.name {
+			names = append(names, named.name)
+		}
+	}
+
+	return
+}
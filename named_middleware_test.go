@@ -0,0 +1,89 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNamedMiddleware ensures named middleware can be removed and
+// replaced in place.
+func TestNamedMiddleware(t *testing.T) {
+	var calls []string
+
+	record := func(name string) MiddlewareHandler {
+		return func(res http.ResponseWriter, req *http.Request) bool {
+			calls = append(calls, name)
+			return false
+		}
+	}
+
+	router := NewRouter().
+		RegisterNamedMiddleware("auth", record("auth")).
+		RegisterMiddleware(record("anonymous")).
+		RegisterNamedMiddleware("ratelimit", record("ratelimit")).
+		Get("/", http.NotFoundHandler())
+
+	serve := func() string {
+		calls = nil
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+		return strings.Join(calls, " ")
+	}
+
+	if expected := "auth anonymous ratelimit"; expected != serve() {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls, " "))
+	}
+
+	if !router.RemoveMiddleware("ratelimit") || router.RemoveMiddleware("ratelimit") {
+		t.Error("Expected ratelimit to be removed once.")
+	}
+
+	if !router.ReplaceMiddleware("auth", record("sandbox")) || router.ReplaceMiddleware("missing", record("missing")) {
+		t.Error("Expected only registered middleware to be replaced.")
+	}
+
+	router.RegisterNamedMiddleware("sandbox", record("first")).RegisterNamedMiddleware("sandbox", record("second"))
+
+	if expected := "sandbox anonymous second"; expected != serve() {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls, " "))
+	}
+
+	if names := router.MiddlewareNames(); "auth sandbox" != strings.Join(names, " ") {
+		t.Errorf("Expected named middleware auth and sandbox, got %v.", names)
+	}
+}
+
+// TestMiddlewareOrdering ensures middleware runs in priority order and
+// can be inserted relative to named middleware.
+func TestMiddlewareOrdering(t *testing.T) {
+	var calls []string
+
+	record := func(name string) MiddlewareHandler {
+		return func(res http.ResponseWriter, req *http.Request) bool {
+			calls = append(calls, name)
+			return false
+		}
+	}
+
+	router := NewRouter().
+		RegisterNamedMiddleware("auth", record("auth")).
+		RegisterMiddleware(record("logger")).
+		RegisterMiddlewarePriority(record("recover"), -10).
+		RegisterMiddlewarePriority(record("audit"), 10).
+		Get("/", http.NotFoundHandler())
+
+	if !router.InsertMiddlewareBefore("auth", "session", record("session")) || !router.InsertMiddlewareAfter("auth", "", record("csrf")) {
+		t.Fatal("Expected middleware to be inserted around auth.")
+	}
+
+	if router.InsertMiddlewareAfter("missing", "", record("missing")) {
+		t.Error("Expected inserting relative to missing middleware to fail.")
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+
+	if expected := "recover session auth csrf logger audit"; expected != strings.Join(calls, " ") {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls, " "))
+	}
+}
@@ -0,0 +1,133 @@
+package dispatcher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrUnknownRoute is returned when building the URL of a Route name
+// that was never registered.
+var ErrUnknownRoute = errors.New("dispatcher: unknown route name")
+
+// Name names the Routes created by the most recent registration call,
+// so their URLs can be built with URL rather than repeated as strings,
+// i.e.
+//
+//	router.Get("/articles/:id", ShowArticleHandler).Name("article.show")
+func (r *Router) Name(name string) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	if nil == r.names {
+		r.names = make(map[string]*Route)
+	}
+
+	for _, route := range r.current {
+		route.name = name
+
+		// Locale-prefixed variants are built from the Route they vary.
+		if !route.localized {
+			r.names[name] = route
+		}
+	}
+
+	return r
+}
+
+// URL builds the path of the Route named `name`, filling its
+// parameters, and then any wildcard, with `params` in order, i.e.
+//
+//	path, err := router.URL("article.show", "42") // "/articles/42"
+//
+// Values are path escaped. Optional parameters may be left out at the
+// end of `params`; missing required parameters are an error.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	r.Lock()
+	route, ok := r.names[name]
+	r.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownRoute, name)
+	}
+
+	return route.url(params)
+}
+
+// url builds the path of the Route from `params`.
+func (route *Route) url(params []string) (string, error) {
+	var (
+		built strings.Builder
+		last  int
+		next  int
+	)
+
+	for _, match := range splitRoutePathParams.FindAllStringSubmatchIndex(route.path, -1) {
+		fragmented := generateFragmentedPathParameter(submatches(route.path, match))
+		built.WriteString(route.path[last:match[0]])
+		last = match[1]
+
+		if next >= len(params) {
+			if 0 == len(fragmented.optional) {
+				return "", fmt.Errorf("dispatcher: missing parameter %q for route %q", fragmented.name, route.path)
+			}
+
+			continue
+		}
+
+		built.WriteString(fragmented.slash)
+		built.WriteString(fragmented.format)
+		built.WriteString(url.PathEscape(params[next]))
+		next++
+	}
+
+	remaining := route.path[last:]
+
+	for strings.Contains(remaining, "*") {
+		value := ""
+
+		if next < len(params) {
+			value = params[next]
+			next++
+		}
+
+		before, after, _ := strings.Cut(remaining, "*")
+		built.WriteString(before)
+		built.WriteString(escapeWildcard(value))
+		remaining = after
+	}
+
+	built.WriteString(remaining)
+
+	if next < len(params) {
+		return "", fmt.Errorf("dispatcher: too many parameters for route %q", route.path)
+	}
+
+	return built.String(), nil
+}
+
+// submatches returns the strings of the submatch `indexes` of `s`.
+func submatches(s string, indexes []int) []string {
+	matches := make([]string, len(indexes)/2)
+
+	for i := range matches {
+		if 0 <= indexes[2*i] {
+			matches[i] = s[indexes[2*i]:indexes[2*i+1]]
+		}
+	}
+
+	return matches
+}
+
+// escapeWildcard path escapes each segment of a wildcard value, which
+// may span several segments.
+func escapeWildcard(value string) string {
+	segments := strings.Split(value, "/")
+
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,51 @@
+package dispatcher
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestRouterURL ensures URLs are built for named Routes.
+func TestRouterURL(t *testing.T) {
+	handler := http.NotFoundHandler()
+
+	router := NewRouter().
+		Get("/articles/:id", handler).Name("article.show").
+		Get("/posts/:year/:month?", handler).Name("posts").
+		Get("/users/:id(\\d+)", handler).Name("user").
+		Get("/files/:name.:format", handler).Name("file").
+		Get("/static/*", handler).Name("static")
+
+	tests := []struct {
+		name     string
+		params   []string
+		expected string
+	}{
+		{"article.show", []string{"42"}, "/articles/42"},
+		{"article.show", []string{"a b"}, "/articles/a%20b"},
+		{"posts", []string{"2013", "january"}, "/posts/2013/january"},
+		{"posts", []string{"2013"}, "/posts/2013"},
+		{"user", []string{"7"}, "/users/7"},
+		{"file", []string{"report", "pdf"}, "/files/report.pdf"},
+		{"static", []string{"css/site.css"}, "/static/css/site.css"},
+	}
+
+	for _, test := range tests {
+		if built, err := router.URL(test.name, test.params...); nil != err || test.expected != built {
+			t.Errorf("Expected %q for %v %v, got %q (%v).", test.expected, test.name, test.params, built, err)
+		}
+	}
+
+	if _, err := router.URL("article.show"); nil == err {
+		t.Error("Expected missing parameters to fail.")
+	}
+
+	if _, err := router.URL("article.show", "1", "2"); nil == err {
+		t.Error("Expected extra parameters to fail.")
+	}
+
+	if _, err := router.URL("missing"); !errors.Is(err, ErrUnknownRoute) {
+		t.Errorf("Expected ErrUnknownRoute, got %v.", err)
+	}
+}
@@ -0,0 +1,191 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptValue is a single value of an Accept, Accept-Language or
+// Accept-Encoding header along with its quality.
+type AcceptValue struct {
+	Value   string
+	Quality float64
+}
+
+// ParseAccept parses a comma separated Accept style header, returning
+// its values ordered by descending quality. Values sharing a quality
+// keep the order they were listed in. Values without a `q` parameter
+// have a quality of 1 and parameters other than `q` are dropped.
+func ParseAccept(header string) []AcceptValue {
+	var values []AcceptValue
+
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+
+		if value = strings.TrimSpace(value); "" == value {
+			continue
+		}
+
+		accepted := AcceptValue{Value: value, Quality: 1}
+
+		for _, param := range strings.Split(params, ";") {
+			key, raw, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+			if !strings.EqualFold("q", key) {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(raw, 64); nil == err && 0 <= q && 1 >= q {
+				accepted.Quality = q
+			} else {
+				accepted.Quality = 0
+			}
+		}
+
+		values = append(values, accepted)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Quality > values[j].Quality
+	})
+
+	return values
+}
+
+// Negotiate returns the media type among `offers` best matching the
+// request's Accept header, i.e.
+//
+//	switch dispatcher.Negotiate(req, "application/json", "text/html") {
+//	case "text/html":
+//	...
+//	}
+//
+// Offers are listed in order of the server's preference, which breaks
+// ties between equally acceptable offers. The first offer is returned
+// if the request has no Accept header, and an empty string if no offer
+// is acceptable.
+func Negotiate(req *http.Request, offers ...string) string {
+	return negotiate(req.Header.Values("Accept"), offers, matchMediaRange)
+}
+
+// NegotiateLanguage returns the language tag among `offers` best
+// matching the request's Accept-Language header. A language range
+// matches tags it is a prefix of, so `en` matches `en-GB`.
+func NegotiateLanguage(req *http.Request, offers ...string) string {
+	return negotiate(req.Header.Values("Accept-Language"), offers, matchLanguageRange)
+}
+
+// NegotiateEncoding returns the content coding among `offers` best
+// matching the request's Accept-Encoding header.
+func NegotiateEncoding(req *http.Request, offers ...string) string {
+	return negotiate(req.Header.Values("Accept-Encoding"), offers, matchEncoding)
+}
+
+// negotiate picks the offer with the highest quality among `header`'s
+// values, using the most specific matching value for each offer.
+// `match` returns the specificity of a value matching an offer, or -1
+// if it does not match.
+func negotiate(header []string, offers []string, match func(value, offer string) int) string {
+	if 0 == len(offers) {
+		return ""
+	}
+
+	if 0 == len(header) {
+		return offers[0]
+	}
+
+	var (
+		values  = ParseAccept(strings.Join(header, ","))
+		best    string
+		quality float64
+	)
+
+	for _, offer := range offers {
+		specificity, q := -1, 0.0
+
+		for _, value := range values {
+			if s := match(value.Value, offer); s > specificity {
+				specificity, q = s, value.Quality
+			}
+		}
+
+		if q > quality {
+			best, quality = offer, q
+		}
+	}
+
+	return best
+}
+
+// matchMediaRange matches media ranges such as `*/*`, `text/*` and
+// `text/html` against a media type.
+func matchMediaRange(value, offer string) int {
+	rangeType, rangeSubtype, _ := strings.Cut(strings.ToLower(value), "/")
+	offerType, offerSubtype, _ := strings.Cut(strings.ToLower(offer), "/")
+
+	switch {
+	case "*" == rangeType && "*" == rangeSubtype:
+		return 0
+	case rangeType != offerType:
+		return -1
+	case "*" == rangeSubtype:
+		return 1
+	case rangeSubtype == offerSubtype:
+		return 2
+	}
+
+	return -1
+}
+
+// matchLanguageRange matches language ranges such as `*`, `en` and
+// `en-GB` against a language tag.
+func matchLanguageRange(value, offer string) int {
+	value, offer = strings.ToLower(value), strings.ToLower(offer)
+
+	switch {
+	case "*" == value:
+		return 0
+	case value == offer:
+		return 2
+	case strings.HasPrefix(offer, value+"-"):
+		return 1
+	}
+
+	return -1
+}
+
+// matchEncoding matches content codings such as `*` and `gzip`.
+func matchEncoding(value, offer string) int {
+	switch {
+	case "*" == value:
+		return 0
+	case strings.EqualFold(value, offer):
+		return 1
+	}
+
+	return -1
+}
+
+// negotiatedContextKey is the context key the negotiated media type is
+// stored under.
+type negotiatedContextKey struct{}
+
+// NegotiateContent returns a Plugin negotiating the request's media
+// type among `offers` and storing it in the request's context, where
+// handlers can retrieve it with NegotiatedType.
+func NegotiateContent(offers ...string) PluginFunc {
+	return func(req *http.Request) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), negotiatedContextKey{}, Negotiate(req, offers...)))
+	}
+}
+
+// NegotiatedType returns the media type negotiated by a
+// NegotiateContent Plugin. False is returned if no negotiation took
+// place; an empty type means no offer was acceptable.
+func NegotiatedType(ctx context.Context) (string, bool) {
+	typ, ok := ctx.Value(negotiatedContextKey{}).(string)
+	return typ, ok
+}
@@ -0,0 +1,75 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestParseAccept ensures values are ordered by quality, keeping the
+// listed order for ties.
+func TestParseAccept(t *testing.T) {
+	values := ParseAccept("text/html;level=1;q=0.5, application/json, */*;q=0.1, text/plain")
+	expected := []AcceptValue{{"application/json", 1}, {"text/plain", 1}, {"text/html", 0.5}, {"*/*", 0.1}}
+
+	if len(expected) != len(values) {
+		t.Fatalf("Expected %d values, got %v.", len(expected), values)
+	}
+
+	for i := range expected {
+		if expected[i] != values[i] {
+			t.Errorf("Expected value %d to be %v, got %v.", i, expected[i], values[i])
+		}
+	}
+}
+
+// TestNegotiate ensures offers are negotiated against request headers.
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header, value string
+		negotiate     func(*http.Request, ...string) string
+		offers        []string
+		expected      string
+	}{
+		{"Accept", "", Negotiate, []string{"application/json", "text/html"}, "application/json"},
+		{"Accept", "text/html, application/json;q=0.9", Negotiate, []string{"application/json", "text/html"}, "text/html"},
+		{"Accept", "text/*;q=0.5, */*;q=0.1", Negotiate, []string{"application/json", "text/html"}, "text/html"},
+		{"Accept", "*/*, text/html;q=0", Negotiate, []string{"text/html", "application/json"}, "application/json"},
+		{"Accept", "image/png", Negotiate, []string{"application/json"}, ""},
+		{"Accept-Language", "fr, en;q=0.8", NegotiateLanguage, []string{"en-GB", "de"}, "en-GB"},
+		{"Accept-Language", "de;q=0.5, en-gb", NegotiateLanguage, []string{"de", "en-GB"}, "en-GB"},
+		{"Accept-Encoding", "gzip;q=0.5, br", NegotiateEncoding, []string{"gzip", "br"}, "br"},
+		{"Accept-Encoding", "*;q=0", NegotiateEncoding, []string{"gzip"}, ""},
+	}
+
+	for _, test := range tests {
+		req := generateHttpRequest(GET, "/")
+
+		if "" != test.value {
+			req.Header.Set(test.header, test.value)
+		}
+
+		if negotiated := test.negotiate(req, test.offers...); test.expected != negotiated {
+			t.Errorf("Expected %s %q to negotiate %q from %v, got %q.", test.header, test.value, test.expected, test.offers, negotiated)
+		}
+	}
+}
+
+// TestNegotiateContent ensures the negotiated type is made available
+// to handlers.
+func TestNegotiateContent(t *testing.T) {
+	var negotiated string
+
+	router := NewRouter().RegisterPlugin(NegotiateContent("application/json", "text/html"))
+	router.Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		negotiated, _ = NegotiatedType(req.Context())
+	}))
+
+	req := generateHttpRequest(GET, "/")
+	req.Header.Set("Accept", "text/html")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if "text/html" != negotiated {
+		t.Errorf("Expected text/html to be negotiated, got %q.", negotiated)
+	}
+}
@@ -0,0 +1,137 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+)
+
+// wildcardGroup is the name of the regular expression group capturing
+// a wildcard `*` in a Route's path.
+const wildcardGroup = "_"
+
+// Param is a single path parameter captured when matching a Route.
+// Wildcards are captured with the key `*`.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is the list of path parameters captured when matching a
+// Route, in the order they appear in its path.
+type Params []Param
+
+// Get returns the value of the first parameter named `key`, or an
+// empty string if there is no such parameter.
+func (p Params) Get(key string) string {
+	value, _ := p.Lookup(key)
+	return value
+}
+
+// Lookup returns the value of the first parameter named `key`, and
+// whether it was found. Optional parameters that were not supplied are
+// reported as found with an empty value.
+func (p Params) Lookup(key string) (string, bool) {
+	for _, param := range p {
+		if key == param.Key {
+			return param.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// paramsContextKey is the context key a matched Route's Params are
+// stored under.
+type paramsContextKey struct{}
+
+// ParamsFromContext returns the Params captured by the Route matching
+// the request whose context is `ctx`, i.e.
+//
+//	router.Get("/posts/:year/:month?", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+//		year := dispatcher.ParamsFromContext(req.Context()).Get("year")
+//	}))
+func ParamsFromContext(ctx context.Context) Params {
+	params, _ := ctx.Value(paramsContextKey{}).(Params)
+	return params
+}
+
+// WithParams returns a copy of `ctx` carrying `params`, i.e. to test a
+// handler reading ParamsFromContext without a Router.
+func WithParams(ctx context.Context, params Params) context.Context {
+	return context.WithValue(ctx, paramsContextKey{}, params)
+}
+
+// WithRoute returns a copy of `ctx` carrying the match of `route` as
+// the Router stores it when serving a request: `route` itself,
+// `params` and the API version of `route`.
+func WithRoute(ctx context.Context, route *Route, params Params) context.Context {
+	ctx = withMatchedRoute(ctx, route, params)
+
+	if 0 < len(params) {
+		ctx = WithParams(ctx, params)
+	}
+
+	if "" != route.version {
+		ctx = context.WithValue(ctx, versionContextKey{}, route.version)
+	}
+
+	return ctx
+}
+
+// params returns the Params captured by the Route for `path`.
+func (route *Route) params(path string) (params Params) {
+	route.appendParams(path, &params)
+
+	if 0 == len(params) {
+		return nil
+	}
+
+	return
+}
+
+// appendParams appends the Params captured by the Route for `path` to
+// `params`. Routes with segments append without allocating if
+// `params` has the capacity.
+func (route *Route) appendParams(path string, params *Params) {
+	if nil != route.segments {
+		route.match(path, params)
+		return
+	}
+
+	names := route.matcher.SubexpNames()
+	matches := route.matcher.FindStringSubmatch(path)
+
+	if nil == matches {
+		return
+	}
+
+	for i, name := range names {
+		switch name {
+		case "":
+			continue
+		case wildcardGroup:
+			name = "*"
+		}
+
+		*params = append(*params, Param{name, matches[i]})
+	}
+}
+
+// paramsPool holds the buffers the Params of requests are captured
+// into, so matching does not allocate.
+var paramsPool = sync.Pool{
+	New: func() any {
+		params := make(Params, 0, 8)
+		return &params
+	},
+}
+
+// Match reports whether the Route's path matches `path`, returning
+// the parameters captured.
+func (route *Route) Match(path string) (Params, bool) {
+	if !route.match(path, nil) {
+		return nil, false
+	}
+
+	return route.params(path), true
+}
@@ -0,0 +1,96 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRouteParams ensures named, captured, optional and wildcard
+// parameters are made available to handlers.
+func TestRouteParams(t *testing.T) {
+	tests := []struct {
+		route, path string
+		expected    Params
+	}{
+		{"/posts/:year/:month?", "/posts/2013/january", Params{{"year", "2013"}, {"month", "january"}}},
+		{"/posts/:year/:month?", "/posts/2013", Params{{"year", "2013"}, {"month", ""}}},
+		{"/users/:id(\\d+)", "/users/42", Params{{"id", "42"}}},
+		{"/files/:name.:format", "/files/report.pdf", Params{{"name", "report"}, {"format", "pdf"}}},
+		{"/static/*", "/static/css/site.css", Params{{"*", "css/site.css"}}},
+	}
+
+	for _, test := range tests {
+		var params Params
+
+		router := NewRouter().Get(test.route, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			params = ParamsFromContext(req.Context())
+		}))
+
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, test.path))
+
+		if len(test.expected) != len(params) {
+			t.Errorf("Expected %v for %q on %q, got %v.", test.expected, test.path, test.route, params)
+			continue
+		}
+
+		for i := range test.expected {
+			if test.expected[i] != params[i] {
+				t.Errorf("Expected %v for %q on %q, got %v.", test.expected, test.path, test.route, params)
+				break
+			}
+		}
+	}
+}
+
+// TestParamsGet ensures parameters are looked up by key.
+func TestParamsGet(t *testing.T) {
+	params := Params{{"year", "2013"}, {"month", ""}}
+
+	if "2013" != params.Get("year") {
+		t.Errorf("Expected year 2013, got %q.", params.Get("year"))
+	}
+
+	if _, ok := params.Lookup("month"); !ok {
+		t.Error("Expected unsupplied optional parameter to be found.")
+	}
+
+	if _, ok := params.Lookup("day"); ok {
+		t.Error("Expected unknown parameter not to be found.")
+	}
+}
+
+// TestRouteMatch ensures Routes match paths outside of a Router.
+func TestRouteMatch(t *testing.T) {
+	route := NewRoute("/users/:id", false)
+
+	if params, ok := route.Match("/users/7"); !ok || "7" != params.Get("id") {
+		t.Errorf("Expected /users/7 to match with id 7, got %v %v.", ok, params)
+	}
+
+	if _, ok := route.Match("/posts/7"); ok {
+		t.Error("Expected /posts/7 not to match.")
+	}
+}
+
+// TestParamsRetained ensures Params retained by a handler are not
+// overwritten by later requests.
+func TestParamsRetained(t *testing.T) {
+	var retained []Params
+
+	router := NewRouter().
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			retained = append(retained, ParamsFromContext(req.Context()))
+		})).
+		Get("/posts/:slug", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/1"))
+
+	for i := 0; i < 10; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/posts/later"))
+	}
+
+	if expected := "1"; expected != retained[0].Get("id") {
+		t.Errorf("Expected retained Params to keep id %q, got %v.", expected, retained[0])
+	}
+}
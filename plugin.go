@@ -0,0 +1,74 @@
+package dispatcher
+
+import (
+	"net/http"
+	"sort"
+)
+
+// The Plugin interface is implemented by types that mutate an HTTP
+// request before the Router attempts to match it against its Routes.
+// Unlike Middleware, a Plugin can not serve a request; it may only
+// modify it (i.e. normalize the path, fix up headers, or override the
+// method) and return the request the Router should continue with.
+type Plugin interface {
+	ModifyRequest(req *http.Request) *http.Request
+}
+
+// The PluginFunc type is an adapter to allow the use of
+// ordinary functions as Plugins.
+type PluginFunc func(req *http.Request) *http.Request
+
+// ModifyRequest calls p(req)
+func (p PluginFunc) ModifyRequest(req *http.Request) *http.Request {
+	return p(req)
+}
+
+// prioritizedPlugin pairs a Plugin with the priority it was
+// registered with.
+type prioritizedPlugin struct {
+	plugin   Plugin
+	priority int
+}
+
+// RegisterPlugin registers a Plugin that will be called with each
+// HTTP request served, before any Middleware runs or Routes are
+// matched. Plugins registered with RegisterPlugin have a priority
+// of 0.
+func (r *Router) RegisterPlugin(plugin Plugin) *Router {
+	return r.RegisterPluginPriority(plugin, 0)
+}
+
+// RegisterPluginPriority registers a Plugin with an explicit
+// priority. Plugins with lower priorities run first; Plugins sharing
+// a priority run in the order they were registered.
+func (r *Router) RegisterPluginPriority(plugin Plugin, priority int) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	// Requests being served may hold the current slice, so it is
+	// replaced rather than sorted in place.
+	plugins := make([]prioritizedPlugin, len(r.plugins), len(r.plugins)+1)
+	copy(plugins, r.plugins)
+	plugins = append(plugins, prioritizedPlugin{plugin, priority})
+
+	sort.SliceStable(plugins, func(i, j int) bool {
+		return plugins[i].priority < plugins[j].priority
+	})
+
+	r.plugins = plugins
+
+	return r
+}
+
+// applyPlugins passes the request through each of `plugins` in
+// priority order, returning the resulting request. Plugins run for
+// every request and are never skipped.
+func applyPlugins(plugins []prioritizedPlugin, req *http.Request) *http.Request {
+	for _, registered := range plugins {
+		if modified := registered.plugin.ModifyRequest(req); nil != modified {
+			req = modified
+		}
+	}
+
+	return req
+}
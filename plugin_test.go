@@ -0,0 +1,94 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestPluginModifiesRequestBeforeMatching ensures Plugins run before
+// Routes are matched, allowing them to rewrite the request's path.
+func TestPluginModifiesRequestBeforeMatching(t *testing.T) {
+	counter := 0
+
+	NewRouter().
+		Get("/rewritten", generateCountableHandler(&counter)).
+		RegisterPlugin(PluginFunc(func(req *http.Request) *http.Request {
+			req.URL.Path = "/rewritten"
+			return req
+		})).
+		ServeHTTP(nil, generateHttpRequest(GET, "/original"))
+
+	if 1 != counter {
+		t.Errorf("Expected counter to be set to 1, was set to %d.", counter)
+	}
+}
+
+// TestPluginPriority ensures Plugins run in priority order, with
+// Plugins of equal priority running in registration order.
+func TestPluginPriority(t *testing.T) {
+	var order []string
+
+	generatePlugin := func(name string) PluginFunc {
+		return func(req *http.Request) *http.Request {
+			order = append(order, name)
+			return req
+		}
+	}
+
+	NewRouter().
+		RegisterPlugin(generatePlugin("b")).
+		RegisterPlugin(generatePlugin("c")).
+		RegisterPluginPriority(generatePlugin("a"), -1).
+		NotFound(generateCountableHandler(new(int))).
+		ServeHTTP(nil, generateHttpRequest(GET, "/"))
+
+	if expected := "abc"; expected != strings.Join(order, "") {
+		t.Errorf("Expected Plugins to run in order %q, ran in order %q.", expected, strings.Join(order, ""))
+	}
+}
+
+// TestPluginRunsBeforeHandledMiddleware ensures Plugins run even
+// when Middleware handles the request.
+func TestPluginRunsBeforeHandledMiddleware(t *testing.T) {
+	counter := 0
+
+	NewRouter().
+		RegisterMiddleware(generateCountableMiddleware(&counter, true)).
+		RegisterPlugin(PluginFunc(func(req *http.Request) *http.Request {
+			counter += 1
+			return req
+		})).
+		ServeHTTP(nil, generateHttpRequest(GET, "/"))
+
+	if 2 != counter {
+		t.Errorf("Expected counter to be set to 2, was set to %d.", counter)
+	}
+}
+
+// TestPluginRegisteredWhileServing ensures Plugins can be registered
+// while the Router serves requests.
+func TestPluginRegisteredWhileServing(t *testing.T) {
+	router := NewRouter().Get("/", http.NotFoundHandler())
+	noop := PluginFunc(func(req *http.Request) *http.Request { return req })
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func(priority int) {
+			defer wg.Done()
+			router.RegisterPluginPriority(noop, -priority)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// pprofProfiles lists the runtime profiles served by MountPprof.
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// MountPprof registers the handlers of the net/http/pprof package
+// beneath the path `prefix`, i.e. `/debug/pprof`. If `guard` is not nil
+// it is called before each pprof handler, and the request is only
+// profiled if the guard returns false, allowing access to be
+// restricted, i.e. to requests from an internal network. The Router's
+// Stats are served as `stats` beneath `prefix` too, and its Routes,
+// with their documentation, as `routes`.
+func (r *Router) MountPprof(prefix string, guard Middleware) *Router {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	guarded := func(handler http.Handler) http.Handler {
+		if nil == guard {
+			return handler
+		}
+
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if !guard.ServeHTTP(res, req) {
+				handler.ServeHTTP(res, req)
+			}
+		})
+	}
+
+	r.Get(prefix+"/", guarded(http.HandlerFunc(pprof.Index)))
+	r.Get(prefix+"/cmdline", guarded(http.HandlerFunc(pprof.Cmdline)))
+	r.Get(prefix+"/profile", guarded(http.HandlerFunc(pprof.Profile)))
+	r.Get(prefix+"/symbol", guarded(http.HandlerFunc(pprof.Symbol)))
+	r.Post(prefix+"/symbol", guarded(http.HandlerFunc(pprof.Symbol)))
+	r.Get(prefix+"/trace", guarded(http.HandlerFunc(pprof.Trace)))
+
+	for _, profile := range pprofProfiles {
+		r.Get(prefix+"/"+profile, guarded(pprof.Handler(profile)))
+	}
+
+	r.Get(prefix+"/stats", guarded(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		Respond(res, req, http.StatusOK, r.Stats())
+	})))
+
+	r.Get(prefix+"/routes", guarded(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		Respond(res, req, http.StatusOK, r.Routes())
+	})))
+
+	return r
+}
@@ -0,0 +1,66 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestMountPprof ensures pprof handlers are served beneath a custom
+// prefix and are protected by the guard.
+func TestMountPprof(t *testing.T) {
+	allowed := true
+
+	router := NewRouter().MountPprof("/internal/pprof", MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+		if !allowed {
+			http.Error(res, "Forbidden", http.StatusForbidden)
+		}
+
+		return !allowed
+	}))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/internal/pprof/goroutine?debug=1"))
+
+	if http.StatusOK != res.Code || 0 == res.Body.Len() {
+		t.Errorf("Expected goroutine profile to be served, got %d.", res.Code)
+	}
+
+	allowed = false
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/internal/pprof/heap"))
+
+	if http.StatusForbidden != res.Code {
+		t.Errorf("Expected guard to deny access, got %d.", res.Code)
+	}
+}
+
+// TestMountPprofRoutes ensures the route table is served with the
+// Routes' documentation.
+func TestMountPprofRoutes(t *testing.T) {
+	router := NewRouter().
+		Get("/users", generateCountableHandler(new(int))).Doc("Lists users").
+		MountPprof("/debug", nil)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/debug/routes"))
+
+	var routes []RouteInfo
+
+	if err := json.NewDecoder(res.Body).Decode(&routes); nil != err {
+		t.Fatalf("Expected the route table to be served as JSON, got %v.", err)
+	}
+
+	for _, route := range routes {
+		if GET == route.Method && "/users" == route.Path {
+			if expected := "Lists users"; expected != route.Doc {
+				t.Errorf("Expected route to be documented as %q, got %q.", expected, route.Doc)
+			}
+
+			return
+		}
+	}
+
+	t.Errorf("Expected the route table to list GET /users, got %+v.", routes)
+}
@@ -0,0 +1,86 @@
+package dispatcher
+
+import (
+	"context"
+	"strings"
+)
+
+// Principal is the identity a request is made on behalf of. It is
+// resolved by authentication, i.e. a Plugin or HandlerWrapper, and
+// stored in the request's context with WithPrincipal.
+type Principal interface {
+	// HasRole reports whether the Principal was granted `role`.
+	HasRole(role string) bool
+	// HasPermission reports whether the Principal was granted
+	// `permission`.
+	HasPermission(permission string) bool
+}
+
+// ScopedPrincipal is a Principal authenticated with an OAuth access
+// token, i.e. a JWT, granting it a set of scopes.
+type ScopedPrincipal interface {
+	Principal
+	// HasScope reports whether the Principal's token grants `scope`.
+	HasScope(scope string) bool
+}
+
+// BasicPrincipal is a ScopedPrincipal with fixed roles, permissions
+// and scopes.
+type BasicPrincipal struct {
+	Name        string
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+}
+
+// principalContextKey is the context key the Principal of a request is
+// stored under.
+type principalContextKey struct{}
+
+// HasRole reports whether `role` is one of the BasicPrincipal's Roles.
+func (p BasicPrincipal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// HasPermission reports whether `permission` is one of the
+// BasicPrincipal's Permissions.
+func (p BasicPrincipal) HasPermission(permission string) bool {
+	return contains(p.Permissions, permission)
+}
+
+// HasScope reports whether `scope` is one of the BasicPrincipal's
+// Scopes.
+func (p BasicPrincipal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// ParseScope splits the space delimited `scope` of an OAuth access
+// token, i.e. its `scope` claim, into its scopes.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// WithPrincipal returns a copy of `ctx` carrying `principal`, i.e.
+//
+//	req = req.WithContext(dispatcher.WithPrincipal(req.Context(), user))
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the Principal stored in `ctx` with
+// WithPrincipal, and whether there is one.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// contains reports whether `value` is one of `values`.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
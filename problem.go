@@ -0,0 +1,165 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProblemContentType is the media type of Problems encoded as JSON.
+const ProblemContentType = "application/problem+json"
+
+// Problem describes an error response, as defined by RFC 9457. The
+// Router's built-in 404 Not Found and 405 Method Not Allowed responses
+// are rendered from Problems.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// ProblemRenderer writes `problem` as the response to `req`.
+type ProblemRenderer func(res http.ResponseWriter, req *http.Request, problem Problem)
+
+// MethodNotAllowed sets the handler serving requests whose path matches
+// Routes registered only for other methods, in place of the built-in
+// 405 Method Not Allowed response. The `Allow` header listing the
+// methods of the Routes is set before the handler is called.
+func (r *Router) MethodNotAllowed(handler http.Handler) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.methodNotAllowedHandler = handler
+	return r
+}
+
+// RenderProblems overrides how the Router's built-in 404 and 405
+// responses are rendered for clients preferring JSON or HTML, i.e. to
+// serve branded error pages. A nil renderer keeps the default.
+// Clients preferring neither are answered with plain text.
+func (r *Router) RenderProblems(json, html ProblemRenderer) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.problemJSON, r.problemHTML = json, html
+	return r
+}
+
+// serveUnmatched serves a request matching no Route, with 405 Method
+// Not Allowed if Routes for the methods `allow` match its path, or 404
+// Not Found.
+func (r *Router) serveUnmatched(res http.ResponseWriter, req *http.Request, allow []string) {
+	r.Lock()
+	notFound, notAllowed := r.notFoundHandler, r.methodNotAllowedHandler
+	r.Unlock()
+
+	if 0 < len(allow) {
+		res.Header().Set("Allow", strings.Join(allow, ", "))
+
+		if nil != notAllowed {
+			notAllowed.ServeHTTP(res, req)
+			return
+		}
+
+		r.writeProblem(res, req, Problem{
+			Status: http.StatusMethodNotAllowed,
+			Detail: "The resource supports the methods " + strings.Join(allow, ", ") + ".",
+		})
+
+		return
+	}
+
+	if nil != notFound {
+		notFound.ServeHTTP(res, req)
+		return
+	}
+
+	r.writeProblem(res, req, Problem{Status: http.StatusNotFound})
+}
+
+// allowedMethods returns the methods other than `method` of the Routes
+// matching `path`.
+func (r *Router) allowedMethods(method, path string) (allow []string) {
+	for _, m := range supportedMethods {
+		if m == strings.ToUpper(method) {
+			continue
+		}
+
+		if _, _, ok := r.Lookup(m, path); ok {
+			allow = append(allow, m)
+		}
+	}
+
+	return
+}
+
+// writeProblem writes `problem` in the format negotiated with the
+// request, filling in its defaults.
+func (r *Router) writeProblem(res http.ResponseWriter, req *http.Request, problem Problem) {
+	if "" == problem.Type {
+		problem.Type = "about:blank"
+	}
+
+	if "" == problem.Title {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	if "" == problem.Instance {
+		problem.Instance = req.URL.Path
+	}
+
+	r.Lock()
+	renderJSON, renderHTML := r.problemJSON, r.problemHTML
+	r.Unlock()
+
+	if nil == renderJSON {
+		renderJSON = writeProblemJSON
+	}
+
+	if nil == renderHTML {
+		renderHTML = writeProblemHTML
+	}
+
+	res.Header().Add("Vary", "Accept")
+
+	switch Negotiate(req, "text/plain", ProblemContentType, "application/json", "text/html") {
+	case ProblemContentType, "application/json":
+		renderJSON(res, req, problem)
+	case "text/html":
+		renderHTML(res, req, problem)
+	default:
+		if http.StatusNotFound == problem.Status {
+			http.NotFound(res, req)
+		} else {
+			http.Error(res, problem.Title, problem.Status)
+		}
+	}
+}
+
+// writeProblemJSON is the default ProblemRenderer for JSON.
+func writeProblemJSON(res http.ResponseWriter, req *http.Request, problem Problem) {
+	res.Header().Set("Content-Type", ProblemContentType)
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(problem.Status)
+	json.NewEncoder(res).Encode(problem)
+}
+
+// writeProblemHTML is the default ProblemRenderer for HTML.
+func writeProblemHTML(res http.ResponseWriter, req *http.Request, problem Problem) {
+	title := html.EscapeString(strconv.Itoa(problem.Status) + " " + problem.Title)
+
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(problem.Status)
+	res.Write([]byte("<!DOCTYPE html>\n<html><head><title>" + title + "</title></head><body><h1>" + title + "</h1>"))
+
+	if "" != problem.Detail {
+		res.Write([]byte("<p>" + html.EscapeString(problem.Detail) + "</p>"))
+	}
+
+	res.Write([]byte("</body></html>\n"))
+}
@@ -0,0 +1,80 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestProblems ensures the built-in 404 and 405 responses are
+// negotiated with the request.
+func TestProblems(t *testing.T) {
+	router := NewRouter().CacheMisses(0).
+		Get("/users", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})).
+		Post("/users", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+
+	for _, test := range []struct {
+		method, path, accept string
+		status               int
+		contentType, body    string
+	}{
+		{GET, "/missing", "", http.StatusNotFound, "text/plain; charset=utf-8", "404 page not found"},
+		{GET, "/missing", "application/json", http.StatusNotFound, ProblemContentType, `"title":"Not Found"`},
+		{GET, "/missing", "text/html,*/*;q=0.8", http.StatusNotFound, "text/html; charset=utf-8", "<h1>404 Not Found</h1>"},
+		{DELETE, "/users", "application/problem+json", http.StatusMethodNotAllowed, ProblemContentType, `"detail":"The resource supports the methods GET, POST."`},
+		{DELETE, "/users", "", http.StatusMethodNotAllowed, "text/plain; charset=utf-8", "Method Not Allowed"},
+	} {
+		// Repeated requests are answered from the miss cache.
+		for i := 0; i < 2; i++ {
+			req := generateHttpRequest(test.method, test.path)
+
+			if "" != test.accept {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			if test.status != res.Code || test.contentType != res.Header().Get("Content-Type") || !strings.Contains(res.Body.String(), test.body) {
+				t.Errorf("Expected %s %s accepting %q to be served %d %s containing %q, got %d %s %q.", test.method, test.path, test.accept, test.status, test.contentType, test.body, res.Code, res.Header().Get("Content-Type"), res.Body.String())
+			}
+
+			if http.StatusMethodNotAllowed == test.status && "GET, POST" != res.Header().Get("Allow") {
+				t.Errorf("Expected the Allow header to list GET, POST, got %q.", res.Header().Get("Allow"))
+			}
+		}
+	}
+}
+
+// TestRenderProblems ensures the renderers and handlers of the built-in
+// responses can be overridden.
+func TestRenderProblems(t *testing.T) {
+	router := NewRouter().
+		Get("/users", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})).
+		RenderProblems(func(res http.ResponseWriter, req *http.Request, problem Problem) {
+			res.WriteHeader(problem.Status)
+			json.NewEncoder(res).Encode(map[string]string{"error": problem.Title})
+		}, nil)
+
+	req := generateHttpRequest(GET, "/missing")
+	req.Header.Set("Accept", "application/json")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if `{"error":"Not Found"}`+"\n" != res.Body.String() {
+		t.Errorf("Expected the custom JSON renderer to be used, got %q.", res.Body.String())
+	}
+
+	router.MethodNotAllowed(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusTeapot)
+	}))
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(PUT, "/users"))
+
+	if http.StatusTeapot != res.Code || GET != res.Header().Get("Allow") {
+		t.Errorf("Expected the MethodNotAllowed handler to serve with the Allow header, got %d %q.", res.Code, res.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,99 @@
+// Package protobuf provides dispatcher Codecs for protocol buffer
+// messages, so Bind and Respond can serve binary APIs.
+package protobuf
+
+import (
+	"io"
+)
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+import (
+	"github.com/chuckpreslar/dispatcher"
+)
+
+// MediaType is the media type of binary protocol buffer messages.
+const MediaType = "application/x-protobuf"
+
+// Codec encodes and decodes proto.Messages in the binary wire format.
+type Codec struct {
+	Marshal   proto.MarshalOptions
+	Unmarshal proto.UnmarshalOptions
+}
+
+// Accepts reports whether `v` is a proto.Message.
+func (c Codec) Accepts(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+// Decode reads the message `v` from `r`.
+func (c Codec) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+
+	if nil != err {
+		return err
+	}
+
+	return c.Unmarshal.Unmarshal(data, v.(proto.Message))
+}
+
+// Encode writes the message `v` to `w`.
+func (c Codec) Encode(w io.Writer, v any) error {
+	data, err := c.Marshal.Marshal(v.(proto.Message))
+
+	if nil != err {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// JSONCodec encodes and decodes proto.Messages using the canonical
+// JSON mapping of protojson rather than encoding/json.
+type JSONCodec struct {
+	Marshal   protojson.MarshalOptions
+	Unmarshal protojson.UnmarshalOptions
+}
+
+// Accepts reports whether `v` is a proto.Message.
+func (c JSONCodec) Accepts(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+// Decode reads the message `v` from `r`.
+func (c JSONCodec) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+
+	if nil != err {
+		return err
+	}
+
+	return c.Unmarshal.Unmarshal(data, v.(proto.Message))
+}
+
+// Encode writes the message `v` to `w`.
+func (c JSONCodec) Encode(w io.Writer, v any) error {
+	data, err := c.Marshal.Marshal(v.(proto.Message))
+
+	if nil != err {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Register registers Codec for MediaType and JSONCodec for
+// `application/json`, so proto.Messages are bound and rendered with
+// the protobuf encodings while other values are left to the
+// dispatcher's built-in JSON handling.
+func Register() {
+	dispatcher.RegisterCodec(MediaType, Codec{})
+	dispatcher.RegisterCodec("application/json", JSONCodec{})
+}
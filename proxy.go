@@ -0,0 +1,133 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// ProxyOptions configures reverse proxies created with NewProxy.
+type ProxyOptions struct {
+	// Prefix is removed from request paths below it before they are
+	// forwarded, i.e. a Prefix of `/api` forwards `/api/users` as
+	// `/users` and `/apiary` unchanged.
+	Prefix string
+	// RewritePath, if set, is called with the request path, after
+	// Prefix has been removed, and returns the path to forward.
+	RewritePath func(path string) string
+	// PreserveHost forwards the incoming request's Host header rather
+	// than the target's host.
+	PreserveHost bool
+	// DisableForwardedHeaders stops the `X-Forwarded-For`,
+	// `X-Forwarded-Host`, and `X-Forwarded-Proto` headers being set on
+	// forwarded requests.
+	DisableForwardedHeaders bool
+	// RemoveHeaders lists request headers removed before forwarding,
+	// i.e. `Cookie` or `Authorization`.
+	RemoveHeaders []string
+	// SetHeaders are set on forwarded requests, replacing any values
+	// sent by the client.
+	SetHeaders http.Header
+	// ModifyResponse, if set, is called with each upstream response
+	// before it is written to the client. Returning an error causes
+	// ErrorHandler to be called.
+	ModifyResponse func(res *http.Response) error
+	// ErrorHandler, if set, is called when the upstream can not be
+	// reached or ModifyResponse returns an error. By default a
+	// `502 Bad Gateway` is written.
+	ErrorHandler func(res http.ResponseWriter, req *http.Request, err error)
+	// Transport is used to make upstream requests. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// NewProxy creates a reverse proxy forwarding requests to the URL
+// `target`, i.e. `http://localhost:8080`, configured by `opts`.
+func NewProxy(target string, opts ProxyOptions) (http.Handler, error) {
+	upstream, err := url.Parse(target)
+
+	if nil != err {
+		return nil, err
+	}
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(proxied *httputil.ProxyRequest) {
+			opts.rewrite(proxied, upstream)
+		},
+		ModifyResponse: opts.ModifyResponse,
+		ErrorHandler:   opts.ErrorHandler,
+		Transport:      opts.Transport,
+	}, nil
+}
+
+// rewrite prepares the outbound request `proxied` to be forwarded to
+// `upstream`.
+func (opts ProxyOptions) rewrite(proxied *httputil.ProxyRequest, upstream *url.URL) {
+	out := proxied.Out
+	path, rawPath := out.URL.Path, out.URL.RawPath
+
+	// Only whole segments are removed, so a Prefix of `/api` leaves
+	// `/apiary` alone. The escaped path is kept, so escaped slashes
+	// reach the upstream escaped.
+	if prefix := strings.TrimSuffix(opts.Prefix, "/"); hasPathPrefix(path, prefix) {
+		path = "/" + strings.TrimLeft(strings.TrimPrefix(path, prefix), "/")
+
+		if hasPathPrefix(rawPath, prefix) {
+			rawPath = "/" + strings.TrimLeft(strings.TrimPrefix(rawPath, prefix), "/")
+		} else {
+			rawPath = ""
+		}
+	}
+
+	if nil != opts.RewritePath {
+		if rewritten := opts.RewritePath(path); rewritten != path {
+			path, rawPath = rewritten, ""
+		}
+	}
+
+	out.URL.Path, out.URL.RawPath = path, rawPath
+	proxied.SetURL(upstream)
+
+	if opts.PreserveHost {
+		out.Host = proxied.In.Host
+	}
+
+	if !opts.DisableForwardedHeaders {
+		proxied.SetXForwarded()
+
+		// Report what the client used, rather than the proxy in front.
+		if _, ok := ForwardedFromContext(proxied.In.Context()); ok {
+			out.Header.Set("X-Forwarded-Host", Host(proxied.In))
+			out.Header.Set("X-Forwarded-Proto", Scheme(proxied.In))
+		}
+	}
+
+	for _, name := range opts.RemoveHeaders {
+		out.Header.Del(name)
+	}
+
+	for name, values := range opts.SetHeaders {
+		out.Header[http.CanonicalHeaderKey(name)] = values
+	}
+}
+
+// Proxy registers a Route matching the given path argument for any
+// supported HTTP method which forwards requests to the URL `target`.
+// An optional ProxyOptions argument configures the proxy. Proxy panics
+// if `target` is not a valid URL.
+func (r *Router) Proxy(path, target string, opts ...ProxyOptions) *Router {
+	var options ProxyOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	proxy, err := NewProxy(target, options)
+
+	if nil != err {
+		panic(err)
+	}
+
+	return r.Match(path, proxy)
+}
@@ -0,0 +1,331 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BalanceStrategy determines how an UpstreamPool selects the upstream
+// a request is forwarded to.
+type BalanceStrategy int
+
+const (
+	// RoundRobin selects each upstream in turn.
+	RoundRobin BalanceStrategy = iota
+	// LeastConnections selects the upstream with the fewest requests in
+	// flight.
+	LeastConnections
+	// WeightedRoundRobin selects upstreams in turn, proportionally to
+	// their weights.
+	WeightedRoundRobin
+)
+
+// Defaults used for PoolOptions left unset.
+const (
+	DefaultMaxFailures      = 3
+	DefaultEjectionDuration = 30 * time.Second
+)
+
+// ErrNoUpstreams is returned when creating an UpstreamPool without
+// any upstreams.
+var ErrNoUpstreams = errors.New("dispatcher: upstream pool requires at least one upstream")
+
+// Upstream describes a server requests may be forwarded to by an
+// UpstreamPool.
+type Upstream struct {
+	// URL of the upstream, i.e. `http://10.0.0.1:8080`.
+	URL string
+	// Weight of the upstream when using the WeightedRoundRobin
+	// strategy. Weights less than 1 are treated as 1.
+	Weight int
+}
+
+// PoolOptions configures an UpstreamPool.
+type PoolOptions struct {
+	Strategy BalanceStrategy
+	// MaxFailures is the number of consecutive failed requests after
+	// which an upstream is ejected from the pool. Requests fail when
+	// the upstream can not be reached or responds with a 5xx status.
+	MaxFailures int
+	// EjectionDuration is how long an ejected upstream is skipped
+	// before it is tried again.
+	EjectionDuration time.Duration
+}
+
+// UpstreamStats reports metrics collected for an upstream.
+type UpstreamStats struct {
+	URL       string `json:"url"`
+	Requests  uint64 `json:"requests"`
+	Failures  uint64 `json:"failures"`
+	Active    int    `json:"active"`
+	Ejections uint64 `json:"ejections"`
+	Ejected   bool   `json:"ejected"`
+}
+
+// UpstreamPool balances requests between several upstreams, ejecting
+// upstreams which repeatedly fail. It is safe for concurrent use.
+type UpstreamPool struct {
+	sync.Mutex
+	options   PoolOptions
+	upstreams []*pooledUpstream
+	// next is the index of the upstream selected next by RoundRobin.
+	next int
+}
+
+// pooledUpstream holds the state an UpstreamPool tracks per upstream.
+type pooledUpstream struct {
+	url           *url.URL
+	weight        int
+	currentWeight int
+	failures      int
+	ejectedUntil  time.Time
+	stats         UpstreamStats
+}
+
+// upstreamContextKey is the context key the upstream selected for a
+// forwarded request is stored under.
+type upstreamContextKey struct{}
+
+// NewUpstreamPool creates an UpstreamPool balancing requests between
+// `upstreams` as configured by `opts`.
+func NewUpstreamPool(opts PoolOptions, upstreams ...Upstream) (*UpstreamPool, error) {
+	if 0 == len(upstreams) {
+		return nil, ErrNoUpstreams
+	}
+
+	if 0 >= opts.MaxFailures {
+		opts.MaxFailures = DefaultMaxFailures
+	}
+
+	if 0 >= opts.EjectionDuration {
+		opts.EjectionDuration = DefaultEjectionDuration
+	}
+
+	pool := &UpstreamPool{options: opts}
+
+	for _, upstream := range upstreams {
+		parsed, err := url.Parse(upstream.URL)
+
+		if nil != err {
+			return nil, err
+		}
+
+		weight := upstream.Weight
+
+		if 1 > weight {
+			weight = 1
+		}
+
+		pool.upstreams = append(pool.upstreams, &pooledUpstream{
+			url:    parsed,
+			weight: weight,
+			stats:  UpstreamStats{URL: upstream.URL},
+		})
+	}
+
+	return pool, nil
+}
+
+// Stats returns the metrics collected for each of the pool's
+// upstreams.
+func (p *UpstreamPool) Stats() (stats []UpstreamStats) {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+
+	for _, upstream := range p.upstreams {
+		upstream.stats.Ejected = now.Before(upstream.ejectedUntil)
+		stats = append(stats, upstream.stats)
+	}
+
+	return
+}
+
+// acquire selects the upstream the next request is forwarded to and
+// counts the request as in flight. If every upstream is ejected, all
+// are considered.
+func (p *UpstreamPool) acquire() *pooledUpstream {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	var candidates []*pooledUpstream
+
+	for _, upstream := range p.upstreams {
+		if !now.Before(upstream.ejectedUntil) {
+			candidates = append(candidates, upstream)
+		}
+	}
+
+	if 0 == len(candidates) {
+		candidates = p.upstreams
+	}
+
+	var selected *pooledUpstream
+
+	switch p.options.Strategy {
+	case LeastConnections:
+		for _, upstream := range candidates {
+			if nil == selected || upstream.stats.Active < selected.stats.Active {
+				selected = upstream
+			}
+		}
+	case WeightedRoundRobin:
+		// Smooth weighted round robin, spreading selections of heavier
+		// upstreams evenly rather than in bursts.
+		total := 0
+
+		for _, upstream := range candidates {
+			upstream.currentWeight += upstream.weight
+			total += upstream.weight
+
+			if nil == selected || upstream.currentWeight > selected.currentWeight {
+				selected = upstream
+			}
+		}
+
+		selected.currentWeight -= total
+	default:
+		selected = candidates[p.next%len(candidates)]
+		p.next += 1
+	}
+
+	selected.stats.Requests += 1
+	selected.stats.Active += 1
+
+	return selected
+}
+
+// release marks a request forwarded to `upstream` as complete,
+// recording whether it failed and ejecting the upstream once it has
+// failed too many times in a row.
+func (p *UpstreamPool) release(upstream *pooledUpstream, failed bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	upstream.stats.Active -= 1
+
+	if !failed {
+		upstream.failures = 0
+		return
+	}
+
+	upstream.stats.Failures += 1
+	upstream.failures += 1
+
+	if upstream.failures >= p.options.MaxFailures {
+		upstream.failures = 0
+		upstream.ejectedUntil = time.Now().Add(p.options.EjectionDuration)
+		upstream.stats.Ejections += 1
+	}
+}
+
+// roundTrip forwards the request to the upstream selected for it,
+// releasing the upstream once the response body is closed.
+func (p *UpstreamPool) roundTrip(transport http.RoundTripper, req *http.Request) (*http.Response, error) {
+	upstream, _ := req.Context().Value(upstreamContextKey{}).(*pooledUpstream)
+
+	if nil == upstream {
+		return transport.RoundTrip(req)
+	}
+
+	res, err := transport.RoundTrip(req)
+
+	if nil != err {
+		p.release(upstream, true)
+		return nil, err
+	}
+
+	body := &releasingBody{ReadCloser: res.Body, release: func() {
+		p.release(upstream, http.StatusInternalServerError <= res.StatusCode)
+	}}
+
+	res.Body = body
+
+	// Upgraded connections are proxied by writing to the body, which
+	// must remain writable.
+	if writer, ok := body.ReadCloser.(io.Writer); ok && http.StatusSwitchingProtocols == res.StatusCode {
+		res.Body = releasingUpgradedBody{body, writer}
+	}
+
+	return res, nil
+}
+
+// releasingBody calls release once when closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the body and calls release.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// releasingUpgradedBody is a releasingBody of an upgraded connection,
+// writing to the connection.
+type releasingUpgradedBody struct {
+	*releasingBody
+	writer io.Writer
+}
+
+// Write writes `p` to the upgraded connection.
+func (b releasingUpgradedBody) Write(p []byte) (int, error) {
+	return b.writer.Write(p)
+}
+
+// poolTransport is a RoundTripper forwarding requests through an
+// UpstreamPool.
+type poolTransport struct {
+	pool      *UpstreamPool
+	transport http.RoundTripper
+}
+
+// RoundTrip calls t.pool.roundTrip(t.transport, req)
+func (t poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.pool.roundTrip(t.transport, req)
+}
+
+// NewPoolProxy creates a reverse proxy forwarding requests to the
+// upstreams of `pool`, configured by `opts`.
+func NewPoolProxy(pool *UpstreamPool, opts ProxyOptions) http.Handler {
+	transport := opts.Transport
+
+	if nil == transport {
+		transport = http.DefaultTransport
+	}
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(proxied *httputil.ProxyRequest) {
+			upstream := pool.acquire()
+			proxied.Out = proxied.Out.WithContext(context.WithValue(proxied.Out.Context(), upstreamContextKey{}, upstream))
+			opts.rewrite(proxied, upstream.url)
+		},
+		ModifyResponse: opts.ModifyResponse,
+		ErrorHandler:   opts.ErrorHandler,
+		Transport:      poolTransport{pool, transport},
+	}
+}
+
+// ProxyPool registers a Route matching the given path argument for any
+// supported HTTP method which forwards requests to the upstreams of
+// `pool`. An optional ProxyOptions argument configures the proxy.
+func (r *Router) ProxyPool(path string, pool *UpstreamPool, opts ...ProxyOptions) *Router {
+	var options ProxyOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	return r.Match(path, NewPoolProxy(pool, options))
+}
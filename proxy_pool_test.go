@@ -0,0 +1,141 @@
+package dispatcher
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestUpstreamPoolWeightedRoundRobin ensures requests are distributed
+// between upstreams proportionally to their weights.
+func TestUpstreamPoolWeightedRoundRobin(t *testing.T) {
+	pool, err := NewUpstreamPool(PoolOptions{Strategy: WeightedRoundRobin},
+		Upstream{URL: "http://heavy", Weight: 3},
+		Upstream{URL: "http://light", Weight: 1},
+	)
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+
+	for i := 0; i < 8; i++ {
+		upstream := pool.acquire()
+		counts[upstream.url.Host] += 1
+		pool.release(upstream, false)
+	}
+
+	if 6 != counts["heavy"] || 2 != counts["light"] {
+		t.Errorf("Expected a 6:2 distribution, got %v.", counts)
+	}
+}
+
+// TestUpstreamPoolEjection ensures upstreams responding with server
+// errors are ejected from the pool.
+func TestUpstreamPoolEjection(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusBadGateway)
+	}))
+
+	defer failing.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+
+	defer healthy.Close()
+
+	pool, err := NewUpstreamPool(PoolOptions{MaxFailures: 1, EjectionDuration: time.Minute},
+		Upstream{URL: failing.URL},
+		Upstream{URL: healthy.URL},
+	)
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	router := NewRouter().ProxyPool("/*", pool)
+
+	for i := 0; i < 4; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+	}
+
+	stats := pool.Stats()
+
+	if !stats[0].Ejected || 1 != stats[0].Requests || 1 != stats[0].Failures {
+		t.Errorf("Expected failing upstream to be ejected after 1 request, got %+v.", stats[0])
+	}
+
+	if 3 != stats[1].Requests || 0 != stats[1].Active {
+		t.Errorf("Expected healthy upstream to serve the remaining 3 requests, got %+v.", stats[1])
+	}
+}
+
+// TestPoolProxyUpgrade ensures upgraded connections are proxied through
+// an UpstreamPool, releasing the upstream once closed.
+func TestPoolProxyUpgrade(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		conn, buffer, err := http.NewResponseController(res).Hijack()
+
+		if nil != err {
+			t.Error(err)
+			return
+		}
+
+		defer conn.Close()
+
+		buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		buffer.Flush()
+		io.Copy(conn, buffer)
+	}))
+
+	defer upstream.Close()
+
+	pool, err := NewUpstreamPool(PoolOptions{}, Upstream{URL: upstream.URL})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(NewRouter().ProxyPool("/*", pool))
+
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if http.StatusSwitchingProtocols != res.StatusCode {
+		t.Fatalf("Expected the upgrade to be proxied, got %d.", res.StatusCode)
+	}
+
+	conn.Write([]byte("ping"))
+	echoed := make([]byte, 4)
+
+	if _, err := io.ReadFull(reader, echoed); nil != err || "ping" != string(echoed) {
+		t.Errorf("Expected the upgraded connection to echo %q, got %q (%v).", "ping", echoed, err)
+	}
+
+	conn.Close()
+
+	for deadline := time.Now().Add(time.Second); 0 != pool.Stats()[0].Active && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if stats := pool.Stats()[0]; 1 != stats.Requests || 0 != stats.Active {
+		t.Errorf("Expected the upstream to be released once the connection closed, got %+v.", stats)
+	}
+}
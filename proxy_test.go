@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProxy ensures requests matching a proxied Route are forwarded
+// upstream with their prefix removed and headers adjusted.
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Path", req.URL.Path)
+		res.Header().Set("X-Token", req.Header.Get("X-Token"))
+		res.Header().Set("X-Cookie", req.Header.Get("Cookie"))
+		res.Header().Set("X-Forwarded", req.Header.Get("X-Forwarded-For"))
+	}))
+
+	defer upstream.Close()
+
+	router := NewRouter().Proxy("/api/*", upstream.URL, ProxyOptions{
+		Prefix:        "/api",
+		RemoveHeaders: []string{"Cookie"},
+		SetHeaders:    http.Header{"X-Token": {"internal"}},
+	})
+
+	req := httptest.NewRequest(GET, "/api/users/1", nil)
+	req.Header.Set("Cookie", "session=secret")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	expectations := map[string]string{
+		"X-Path":      "/users/1",
+		"X-Token":     "internal",
+		"X-Cookie":    "",
+		"X-Forwarded": "192.0.2.1",
+	}
+
+	for name, expected := range expectations {
+		if actual := res.Header().Get(name); expected != actual {
+			t.Errorf("Expected upstream to receive %s %q, got %q.", name, expected, actual)
+		}
+	}
+}
+
+// TestProxyPrefixSegments ensures the Prefix is only removed from paths
+// below it, and escaped slashes are forwarded escaped.
+func TestProxyPrefixSegments(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Path", req.URL.EscapedPath())
+	}))
+
+	defer upstream.Close()
+
+	router := NewRouter().Proxy("/*", upstream.URL, ProxyOptions{Prefix: "/api"})
+
+	for path, expected := range map[string]string{
+		"/api/users/1":  "/users/1",
+		"/api":          "/",
+		"/apiary/x":     "/apiary/x",
+		"/api/a%2Fb/c":  "/a%2Fb/c",
+		"/apiary/a%2Fb": "/apiary/a%2Fb",
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(GET, path, nil))
+
+		if actual := res.Header().Get("X-Path"); expected != actual {
+			t.Errorf("Expected %s to be forwarded as %q, got %q.", path, expected, actual)
+		}
+	}
+}
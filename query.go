@@ -0,0 +1,152 @@
+package dispatcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryParams provides typed access to a request's query string. Getters
+// return their default when a key is absent, and record an error when
+// a value fails to parse or a required key is missing; the first such
+// error is reported by Err, i.e.
+//
+//	query := dispatcher.Query(req).Require("id")
+//	id, page := query.Int("id", 0), query.Int("page", 1)
+//
+//	if err := query.Err(); nil != err {
+//		dispatcher.WriteError(res, req, err)
+//		return
+//	}
+type QueryParams struct {
+	values url.Values
+	err    error
+}
+
+// Query returns the QueryParams of `req`.
+func Query(req *http.Request) *QueryParams {
+	return &QueryParams{values: req.URL.Query()}
+}
+
+// Err returns the first error recorded by Require or a getter as a
+// *BindError, or nil.
+func (q *QueryParams) Err() error {
+	return q.err
+}
+
+// fail records the failure of `key` unless an earlier one was recorded.
+func (q *QueryParams) fail(key string, err error) {
+	if nil == q.err {
+		q.err = &BindError{Status: http.StatusBadRequest, Field: key, Err: err}
+	}
+}
+
+// Require records an error for the first of `keys` missing or empty in
+// the query string, returning the QueryParams for chaining.
+func (q *QueryParams) Require(keys ...string) *QueryParams {
+	for _, key := range keys {
+		if "" == q.values.Get(key) {
+			q.fail(key, errors.New("is required"))
+		}
+	}
+
+	return q
+}
+
+// Has reports whether `key` is present in the query string.
+func (q *QueryParams) Has(key string) bool {
+	return q.values.Has(key)
+}
+
+// String returns the first value of `key`, or `def` if it is absent or
+// empty.
+func (q *QueryParams) String(key, def string) string {
+	if value := q.values.Get(key); "" != value {
+		return value
+	}
+
+	return def
+}
+
+// Int returns the value of `key` as an int.
+func (q *QueryParams) Int(key string, def int) int {
+	return int(q.Int64(key, int64(def)))
+}
+
+// Int64 returns the value of `key` as an int64.
+func (q *QueryParams) Int64(key string, def int64) int64 {
+	return parseQuery(q, key, def, "integer", func(value string) (int64, error) {
+		return strconv.ParseInt(value, 10, 64)
+	})
+}
+
+// Float returns the value of `key` as a float64.
+func (q *QueryParams) Float(key string, def float64) float64 {
+	return parseQuery(q, key, def, "number", func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+// Bool returns the value of `key` as a bool, accepting the values
+// understood by strconv.ParseBool.
+func (q *QueryParams) Bool(key string, def bool) bool {
+	return parseQuery(q, key, def, "boolean", strconv.ParseBool)
+}
+
+// Duration returns the value of `key` as a time.Duration, i.e. `1m30s`.
+func (q *QueryParams) Duration(key string, def time.Duration) time.Duration {
+	return parseQuery(q, key, def, "duration", time.ParseDuration)
+}
+
+// Time returns the value of `key` as a time.Time parsed with `layout`,
+// i.e. time.RFC3339.
+func (q *QueryParams) Time(key, layout string, def time.Time) time.Time {
+	return parseQuery(q, key, def, "time", func(value string) (time.Time, error) {
+		return time.Parse(layout, value)
+	})
+}
+
+// CSV returns the values of `key` split on commas, so `?tags=a,b&tags=c`
+// yields `a`, `b` and `c`. Empty elements are dropped and `def` is
+// returned if none remain.
+func (q *QueryParams) CSV(key string, def ...string) []string {
+	var values []string
+
+	for _, value := range q.values[key] {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); "" != element {
+				values = append(values, element)
+			}
+		}
+	}
+
+	if 0 == len(values) {
+		return def
+	}
+
+	return values
+}
+
+// parseQuery returns the value of `key` parsed by `parse`, or `def` if
+// it is absent or empty. Failures are recorded on `q` describing the
+// expected `kind` of value.
+func parseQuery[T any](q *QueryParams, key string, def T, kind string, parse func(string) (T, error)) T {
+	value := q.values.Get(key)
+
+	if "" == value {
+		return def
+	}
+
+	parsed, err := parse(value)
+
+	if nil != err {
+		q.fail(key, fmt.Errorf("invalid %v %q", kind, value))
+		return def
+	}
+
+	return parsed
+}
@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestQuery ensures typed getters parse values and fall back to their
+// defaults.
+func TestQuery(t *testing.T) {
+	query := Query(generateHttpRequest(GET, "/?page=2&ratio=0.5&draft=true&ttl=1m&since=2013-01-02T00:00:00Z&tags=a,b&tags=c"))
+
+	if 2 != query.Int("page", 1) || 10 != query.Int("limit", 10) {
+		t.Error("Expected page 2 and the default limit.")
+	}
+
+	if 0.5 != query.Float("ratio", 1) || !query.Bool("draft", false) || time.Minute != query.Duration("ttl", 0) {
+		t.Error("Expected ratio, draft and ttl to be parsed.")
+	}
+
+	if since := query.Time("since", time.RFC3339, time.Time{}); 2013 != since.Year() {
+		t.Errorf("Expected since to be parsed, got %v.", since)
+	}
+
+	if tags := query.CSV("tags"); "a|b|c" != strings.Join(tags, "|") {
+		t.Errorf("Expected tags a, b and c, got %v.", tags)
+	}
+
+	if "asc" != query.String("order", "asc") || 1 != len(query.CSV("fields", "id")) {
+		t.Error("Expected defaults for absent keys.")
+	}
+
+	if err := query.Err(); nil != err {
+		t.Errorf("Expected no error, got %v.", err)
+	}
+}
+
+// TestQueryErrors ensures missing required keys and unparsable values
+// are reported.
+func TestQueryErrors(t *testing.T) {
+	var bindError *BindError
+
+	query := Query(generateHttpRequest(GET, "/?page=two")).Require("id")
+
+	if 1 != query.Int("page", 1) {
+		t.Error("Expected the default for an unparsable value.")
+	}
+
+	if err := query.Err(); !errors.As(err, &bindError) || "id" != bindError.Field || http.StatusBadRequest != bindError.StatusCode() {
+		t.Errorf("Expected the missing id to be reported first, got %v.", err)
+	}
+
+	query = Query(generateHttpRequest(GET, "/?page=two"))
+	query.Int("page", 1)
+
+	if err := query.Err(); nil == err || `page: invalid integer "two"` != err.Error() {
+		t.Errorf("Expected the unparsable page to be reported, got %v.", err)
+	}
+}
@@ -0,0 +1,73 @@
+package dispatcher
+
+import (
+	"net/http"
+	"strings"
+)
+
+// redirectHandler redirects requests to the path built from a target
+// Route with the parameters they captured.
+type redirectHandler struct {
+	target *Route
+	code   int
+}
+
+// Redirect registers Routes matching `from` for all supported HTTP
+// methods which redirect to `to` with the status `code`, so URL
+// migrations live in the route table, i.e.
+//
+//	router.Redirect("/articles/:id", "/posts/:id", http.StatusMovedPermanently)
+//	router.Redirect("/docs/*", "/manual/*", http.StatusFound)
+//
+// Parameters and the wildcard of `to` are filled with those of the same
+// name captured from the request's path, and the request's query
+// string is preserved. Leading slashes of the built path are collapsed
+// so the redirect always stays on the same host. Requests are served
+// 500 if `to` uses a required parameter `from` doesn't capture. A
+// `code` that isn't a redirection defaults to 301 Moved Permanently.
+func (r *Router) Redirect(from, to string, code int) *Router {
+	if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+		code = http.StatusMovedPermanently
+	}
+
+	return r.Match(from, redirectHandler{NewRoute(to, true), code})
+}
+
+// ServeHTTP redirects the request to the path built from the target
+// Route.
+func (h redirectHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	params := ParamsFromContext(req.Context())
+	values := make([]string, 0, len(h.target.keys)+1)
+
+	for _, key := range h.target.keys {
+		values = append(values, params.Get(key))
+	}
+
+	if strings.Contains(h.target.path, "*") {
+		values = append(values, params.Get("*"))
+	}
+
+	// Missing trailing values leave optional parameters out.
+	for 0 < len(values) && "" == values[len(values)-1] {
+		values = values[:len(values)-1]
+	}
+
+	location, err := h.target.url(values)
+
+	if nil != err {
+		http.Error(res, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// Parameters may start with slashes, and a location starting with
+	// `//` or `/\` would be followed by clients to another host.
+	for strings.HasPrefix(location, "//") || strings.HasPrefix(location, `/\`) {
+		location = location[1:]
+	}
+
+	if "" != req.URL.RawQuery {
+		location += "?" + req.URL.RawQuery
+	}
+
+	http.Redirect(res, req, location, h.code)
+}
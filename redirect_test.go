@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRedirect ensures redirects interpolate parameters and wildcards
+// and preserve query strings.
+func TestRedirect(t *testing.T) {
+	router := NewRouter().
+		Redirect("/articles/:id", "/posts/:id", http.StatusMovedPermanently).
+		Redirect("/archive/:year/:month?", "/posts/:year/:month?", http.StatusFound).
+		Redirect("/docs/*", "/manual/*", 0).
+		Redirect("/broken", "/users/:id", http.StatusFound)
+
+	for path, expected := range map[string]string{
+		"/articles/42?ref=feed":  "/posts/42?ref=feed",
+		"/archive/2024/05":       "/posts/2024/05",
+		"/archive/2024":          "/posts/2024",
+		"/docs/guide/routing.md": "/manual/guide/routing.md",
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(POST, path))
+
+		if expected != res.Header().Get("Location") {
+			t.Errorf("Expected %s to redirect to %s, got %q.", path, expected, res.Header().Get("Location"))
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/docs/index"))
+
+	if http.StatusMovedPermanently != res.Code {
+		t.Errorf("Expected an invalid code to default to 301, got %d.", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/broken"))
+
+	if http.StatusInternalServerError != res.Code {
+		t.Errorf("Expected a missing parameter to be served 500, got %d.", res.Code)
+	}
+}
+
+// TestRedirectLeadingSlashes ensures wildcard values can not turn the
+// built location into a protocol-relative URL pointing at another
+// host.
+func TestRedirectLeadingSlashes(t *testing.T) {
+	router := NewRouter().Redirect("/docs/*", "/*", http.StatusFound)
+
+	for _, path := range []string{"/docs//evil.com", "/docs/%2Fevil.com", "/docs/%5Cevil.com"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if location := res.Header().Get("Location"); strings.HasPrefix(location, "//") || strings.HasPrefix(location, `/\`) {
+			t.Errorf("Expected %q to redirect to a path on the same host, got %q.", path, location)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package dispatcher
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// DefaultTemplateExtension is the extension of template files when
+// TemplateOptions.Extension is unset.
+const DefaultTemplateExtension = ".html"
+
+// ErrNoRenderer is returned when rendering with a Router that has no
+// Renderer.
+var ErrNoRenderer = errors.New("dispatcher: no renderer registered")
+
+// Renderer is implemented by types rendering named templates, i.e.
+// `users/show`, with data.
+type Renderer interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+// TemplateOptions configures a TemplateRenderer.
+type TemplateOptions struct {
+	// FS holds the template files. Templates are named by their path
+	// within FS, without extension, i.e. `users/show` for
+	// `users/show.html`.
+	FS fs.FS
+	// Extension of template files. If empty,
+	// DefaultTemplateExtension is used.
+	Extension string
+	// Layout, if set, names a template wrapping every view, i.e.
+	// `layouts/main`. Views define a `content` template which the
+	// layout includes with `{{block "content" .}}{{end}}`.
+	Layout string
+	// Partials is a glob matching templates parsed alongside every
+	// view, i.e. `partials/*.html`, so views can include them.
+	Partials string
+	// Funcs are made available to all templates.
+	Funcs template.FuncMap
+	// Reload re-parses templates on every render rather than caching
+	// them, so changes are picked up without restarting during
+	// development.
+	Reload bool
+}
+
+// TemplateRenderer is a Renderer backed by the html/template package.
+// Parsed templates are cached unless reloading is enabled.
+type TemplateRenderer struct {
+	sync.Mutex
+	options TemplateOptions
+	cache   map[string]*template.Template
+}
+
+// NewTemplateRenderer creates a TemplateRenderer configured by `opts`,
+// parsing the layout and partials to report errors early.
+func NewTemplateRenderer(opts TemplateOptions) (*TemplateRenderer, error) {
+	if "" == opts.Extension {
+		opts.Extension = DefaultTemplateExtension
+	}
+
+	renderer := &TemplateRenderer{options: opts, cache: make(map[string]*template.Template)}
+
+	if _, err := renderer.base(); nil != err {
+		return nil, err
+	}
+
+	return renderer, nil
+}
+
+// Render executes the view `name` with `data`, wrapped in the layout
+// if one is configured, writing the result to `w`.
+func (t *TemplateRenderer) Render(w io.Writer, name string, data any) error {
+	tmpl, err := t.lookup(name)
+
+	if nil != err {
+		return err
+	}
+
+	if "" != t.options.Layout {
+		return tmpl.ExecuteTemplate(w, t.options.Layout, data)
+	}
+
+	return tmpl.ExecuteTemplate(w, name, data)
+}
+
+// lookup returns the parsed template set for the view `name`.
+func (t *TemplateRenderer) lookup(name string) (*template.Template, error) {
+	if !t.options.Reload {
+		t.Lock()
+		tmpl, ok := t.cache[name]
+		t.Unlock()
+
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	tmpl, err := t.base()
+
+	if nil != err {
+		return nil, err
+	}
+
+	if err := t.parse(tmpl, name); nil != err {
+		return nil, err
+	}
+
+	if !t.options.Reload {
+		t.Lock()
+		t.cache[name] = tmpl
+		t.Unlock()
+	}
+
+	return tmpl, nil
+}
+
+// base parses the layout and partials shared by every view.
+func (t *TemplateRenderer) base() (*template.Template, error) {
+	tmpl := template.New("").Funcs(t.options.Funcs)
+
+	if "" != t.options.Layout {
+		if err := t.parse(tmpl, t.options.Layout); nil != err {
+			return nil, err
+		}
+	}
+
+	if "" == t.options.Partials {
+		return tmpl, nil
+	}
+
+	partials, err := fs.Glob(t.options.FS, t.options.Partials)
+
+	if nil != err {
+		return nil, err
+	}
+
+	for _, partial := range partials {
+		name := partial[:len(partial)-len(path.Ext(partial))]
+
+		if err := t.parse(tmpl, name); nil != err {
+			return nil, err
+		}
+	}
+
+	return tmpl, nil
+}
+
+// parse parses the template file for `name` into `tmpl` as a template
+// named `name`.
+func (t *TemplateRenderer) parse(tmpl *template.Template, name string) error {
+	data, err := fs.ReadFile(t.options.FS, name+t.options.Extension)
+
+	if nil != err {
+		return err
+	}
+
+	_, err = tmpl.New(name).Parse(string(data))
+	return err
+}
+
+// RenderWith sets the Renderer used by the Router's Render method.
+func (r *Router) RenderWith(renderer Renderer) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.renderer = renderer
+	return r
+}
+
+// Render renders the template `name` with `data` using the Router's
+// Renderer, writing the result to the response as HTML, i.e.
+//
+//	router.Render(res, "users/show", user)
+//
+// The template is rendered in full before anything is written, so a
+// failed render does not leave a partial response.
+func (r *Router) Render(res http.ResponseWriter, name string, data any) error {
+	r.Lock()
+	renderer := r.renderer
+	r.Unlock()
+
+	if nil == renderer {
+		return ErrNoRenderer
+	}
+
+	var buffer bytes.Buffer
+
+	if err := renderer.Render(&buffer, name, data); nil != err {
+		return err
+	}
+
+	if "" == res.Header().Get("Content-Type") {
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	_, err := buffer.WriteTo(res)
+	return err
+}
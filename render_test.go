@@ -0,0 +1,63 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// TestRenderWithLayout ensures views are rendered within the layout
+// and may include partials.
+func TestRenderWithLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/main.html":  &fstest.MapFile{Data: []byte(`<main>{{block "content" .}}{{end}}</main>`)},
+		"partials/name.html": &fstest.MapFile{Data: []byte(`<b>{{.}}</b>`)},
+		"users/show.html":    &fstest.MapFile{Data: []byte(`{{define "content"}}Hello {{template "partials/name" .}}{{end}}`)},
+	}
+
+	renderer, err := NewTemplateRenderer(TemplateOptions{FS: fsys, Layout: "layouts/main", Partials: "partials/*.html"})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var router *Router
+
+	router = NewRouter().RenderWith(renderer).Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if err := router.Render(res, "users/show", "<Ada>"); nil != err {
+			t.Error(err)
+		}
+	}))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/"))
+
+	if expected := "<main>Hello <b>&lt;Ada&gt;</b></main>"; expected != res.Body.String() {
+		t.Errorf("Expected %q to be rendered, got %q.", expected, res.Body.String())
+	}
+
+	if "text/html; charset=utf-8" != res.Header().Get("Content-Type") {
+		t.Errorf("Expected HTML Content-Type, got %q.", res.Header().Get("Content-Type"))
+	}
+}
+
+// TestRenderReload ensures templates are re-parsed on every render when
+// reloading is enabled, and cached otherwise.
+func TestRenderReload(t *testing.T) {
+	for _, reload := range []bool{true, false} {
+		view := &fstest.MapFile{Data: []byte("v1")}
+		renderer, _ := NewTemplateRenderer(TemplateOptions{FS: fstest.MapFS{"view.html": view}, Reload: reload})
+		router := NewRouter().RenderWith(renderer)
+
+		router.Render(httptest.NewRecorder(), "view", nil)
+		view.Data = []byte("v2")
+
+		res := httptest.NewRecorder()
+		router.Render(res, "view", nil)
+
+		if expected := map[bool]string{true: "v2", false: "v1"}[reload]; expected != res.Body.String() {
+			t.Errorf("Expected %q to be rendered with reload %v, got %q.", expected, reload, res.Body.String())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package dispatcher
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter, recording the status
+// code, number of body bytes and first error written through it, for
+// use by logging, metrics and caching code. It implements
+// http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher,
+// delegating to the wrapped ResponseWriter: Flush is a no-op and
+// Hijack and Push fail with http.ErrNotSupported when the wrapped
+// ResponseWriter lacks support. It also works with
+// http.ResponseController through Unwrap.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	written  int64
+	err      error
+	hijacked bool
+}
+
+// NewResponseWriter wraps `res`. If `res` is already a *ResponseWriter
+// it is returned as is, so layered wrappers share one record.
+func NewResponseWriter(res http.ResponseWriter) *ResponseWriter {
+	if wrapped, ok := res.(*ResponseWriter); ok {
+		return wrapped
+	}
+
+	return &ResponseWriter{ResponseWriter: res}
+}
+
+// Status returns the status code written, 200 if the body was written
+// without an explicit status, or 0 if nothing has been written.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.written
+}
+
+// Err returns the first error encountered writing the body.
+func (w *ResponseWriter) Err() error {
+	return w.err
+}
+
+// Written reports whether the response's header has been written.
+func (w *ResponseWriter) Written() bool {
+	return 0 != w.status
+}
+
+// Hijacked reports whether the connection was hijacked.
+func (w *ResponseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+// WriteHeader records and writes the status code. Informational
+// statuses, which may be followed by another, are written but not
+// recorded.
+func (w *ResponseWriter) WriteHeader(status int) {
+	if 0 == w.status && (http.StatusContinue > status || http.StatusOK <= status || http.StatusSwitchingProtocols == status) {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes `data`, recording the bytes written and any error.
+func (w *ResponseWriter) Write(data []byte) (int, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.record(int64(n), err)
+	return n, err
+}
+
+// ReadFrom copies `r` to the response, using the wrapped
+// ResponseWriter's io.ReaderFrom (i.e. sendfile) when available.
+func (w *ResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+
+	var (
+		n   int64
+		err error
+	)
+
+	if from, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = from.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, r)
+	}
+
+	w.record(n, err)
+	return n, err
+}
+
+// record counts `n` bytes written and keeps the first error.
+func (w *ResponseWriter) record(n int64, err error) {
+	w.written += n
+
+	if nil == w.err {
+		w.err = err
+	}
+}
+
+// Flush sends buffered data to the client, if supported.
+func (w *ResponseWriter) Flush() {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection, if supported.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buffer, err := http.NewResponseController(w.ResponseWriter).Hijack()
+
+	if nil == err {
+		w.hijacked = true
+	}
+
+	return conn, buffer, err
+}
+
+// Push initiates an HTTP/2 server push, if supported.
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped ResponseWriter.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerOnly hides all methods of a ResponseWriter but Write, so
+// io.Copy does not call back into ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
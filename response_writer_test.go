@@ -0,0 +1,76 @@
+package dispatcher
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestResponseWriter ensures the status, bytes written and errors are
+// recorded.
+func TestResponseWriter(t *testing.T) {
+	res := NewResponseWriter(httptest.NewRecorder())
+
+	if res.Written() || 0 != res.Status() {
+		t.Error("Expected nothing to be recorded before writing.")
+	}
+
+	res.Write([]byte("hello "))
+	res.WriteHeader(http.StatusNotFound)
+	res.ReadFrom(strings.NewReader("world"))
+
+	if http.StatusOK != res.Status() || 11 != res.BytesWritten() || nil != res.Err() {
+		t.Errorf("Expected an implicit 200 and 11 bytes, got %d and %d (%v).", res.Status(), res.BytesWritten(), res.Err())
+	}
+
+	if NewResponseWriter(res) != res {
+		t.Error("Expected wrapping a ResponseWriter to return it.")
+	}
+
+	res = NewResponseWriter(httptest.NewRecorder())
+	res.WriteHeader(http.StatusEarlyHints)
+	res.WriteHeader(http.StatusCreated)
+
+	if http.StatusCreated != res.Status() {
+		t.Errorf("Expected informational statuses not to be recorded, got %d.", res.Status())
+	}
+}
+
+// failingWriter is a ResponseWriter whose writes fail.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write(data []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+// TestResponseWriterInterfaces ensures optional interfaces delegate to
+// the wrapped ResponseWriter and errors are recorded.
+func TestResponseWriterInterfaces(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	res := NewResponseWriter(recorder)
+	res.Flush()
+
+	if !recorder.Flushed {
+		t.Error("Expected Flush to be delegated.")
+	}
+
+	if _, _, err := res.Hijack(); !errors.Is(err, http.ErrNotSupported) || res.Hijacked() {
+		t.Errorf("Expected Hijack to be unsupported, got %v.", err)
+	}
+
+	if err := res.Push("/app.js", nil); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected Push to be unsupported, got %v.", err)
+	}
+
+	res = NewResponseWriter(failingWriter{httptest.NewRecorder()})
+	res.Write([]byte("lost"))
+
+	if io.ErrClosedPipe != res.Err() {
+		t.Errorf("Expected the write error to be recorded, got %v.", res.Err())
+	}
+}
@@ -0,0 +1,69 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+)
+
+// routeContextKey is the context key the Route matching a request is
+// stored under, either as the Route or as the matchedRoute a Router
+// with HandlerWrappers sets it on.
+type routeContextKey struct{}
+
+// matchedRoute holds the Route matching a request and a copy of its
+// Params once it was matched.
+type matchedRoute struct {
+	mu     sync.Mutex
+	route  *Route
+	params Params
+}
+
+// RouteFromContext returns a RouteInfo describing the Route matching
+// the request whose context is `ctx`, and whether it was matched, so
+// requests can be logged or measured by their Route's path, i.e.
+// `/users/:id`, rather than by unbounded URL paths:
+//
+//	if route, ok := dispatcher.RouteFromContext(req.Context()); ok {
+//		requests.WithLabelValues(route.Method, route.Path).Inc()
+//	}
+//
+// Route Middleware and handlers are passed the Route through their
+// request's context. HandlerWrappers run before the Route is matched,
+// but can find it in their request's context after calling the next
+// handler.
+func RouteFromContext(ctx context.Context) (RouteInfo, bool) {
+	switch matched := ctx.Value(routeContextKey{}).(type) {
+	case *Route:
+		return matched.Info(), true
+	case *matchedRoute:
+		if route, _ := matched.get(); nil != route {
+			return route.Info(), true
+		}
+	}
+
+	return RouteInfo{}, false
+}
+
+// withMatchedRoute returns a copy of `ctx` carrying `route`, unless it
+// carries a matchedRoute to set it and `params` on.
+func withMatchedRoute(ctx context.Context, route *Route, params Params) context.Context {
+	if matched, ok := ctx.Value(routeContextKey{}).(*matchedRoute); ok {
+		matched.mu.Lock()
+		defer matched.mu.Unlock()
+
+		// The Params are copied, as the Router reuses their memory once
+		// the request was served.
+		matched.route, matched.params = route, append(Params(nil), params...)
+		return ctx
+	}
+
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// get returns the Route matched and its Params, if any.
+func (m *matchedRoute) get() (*Route, Params) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.route, m.params
+}
@@ -0,0 +1,48 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRouteFromContext ensures handlers and HandlerWrappers find the
+// matched Route in their request's context.
+func TestRouteFromContext(t *testing.T) {
+	var handled, wrapped RouteInfo
+
+	router := NewRouter()
+	router.Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		handled, _ = RouteFromContext(req.Context())
+	})).Name("user").Meta("team", "accounts")
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/1"))
+
+	if "/users/:id" != handled.Path || "user" != handled.Name || "accounts" != handled.Meta["team"][0] {
+		t.Errorf("Expected the handler to find the matched Route, got %+v.", handled)
+	}
+
+	router.RegisterWrapper(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if _, ok := RouteFromContext(req.Context()); ok {
+				t.Error("Expected no Route before matching.")
+			}
+
+			next.ServeHTTP(res, req)
+			wrapped, _ = RouteFromContext(req.Context())
+		})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/2"))
+
+	if "/users/:id" != wrapped.Path || GET != wrapped.Method {
+		t.Errorf("Expected the wrapper to find the matched Route, got %+v.", wrapped)
+	}
+
+	wrapped = RouteInfo{Path: "unset"}
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/missing"))
+
+	if "" != wrapped.Path {
+		t.Errorf("Expected no Route for unmatched requests, got %+v.", wrapped)
+	}
+}
@@ -0,0 +1,54 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRouteMiddleware ensures route middleware runs after matching
+// with the matched Route and parameters, and may serve the request.
+func TestRouteMiddleware(t *testing.T) {
+	var (
+		served  bool
+		matched string
+	)
+
+	router := NewRouter().
+		RegisterRouteMiddleware(RouteMiddlewareHandler(func(res http.ResponseWriter, req *http.Request, route *Route, params Params) bool {
+			matched = route.Info().Name + " " + params.Get("id")
+
+			if "admin" == route.Info().Name {
+				http.Error(res, "forbidden", http.StatusForbidden)
+				return true
+			}
+
+			return false
+		})).
+		Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			served = true
+		})).Name("user").
+		Get("/admin", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			t.Error("Expected the admin handler not to be called.")
+		})).Name("admin")
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/7"))
+
+	if !served || "user 7" != matched {
+		t.Errorf("Expected the handler to be called after the middleware saw the route, got %v %q.", served, matched)
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/admin"))
+
+	if http.StatusForbidden != res.Code {
+		t.Errorf("Expected route middleware to serve the request, got %d.", res.Code)
+	}
+
+	matched = ""
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/missing"))
+
+	if "" != matched {
+		t.Error("Expected route middleware not to run for unmatched requests.")
+	}
+}
@@ -0,0 +1,42 @@
+package dispatcher
+
+import (
+	"time"
+)
+
+// ActiveBetween makes the Routes created by the most recent
+// registration call match only from `start` until `end`, i.e. for a
+// promotion or a maintenance window, without redeploying:
+//
+//	router.Get("/sale", SaleHandler).ActiveBetween(blackFriday, cyberMonday)
+//
+// Outside the window requests are routed as if the Routes didn't
+// exist. A zero `start` or `end` leaves the window open at that end.
+func (r *Router) ActiveBetween(start, end time.Time) *Router {
+	return r.ActiveWhen(func(now time.Time) bool {
+		return (start.IsZero() || !now.Before(start)) && (end.IsZero() || now.Before(end))
+	})
+}
+
+// ActiveWhen makes the Routes created by the most recent registration
+// call match only while `active` reports true for the time they are
+// matched at, i.e. for schedules such as business hours.
+func (r *Router) ActiveWhen(active func(now time.Time) bool) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, route := range r.current {
+		route.active = active
+	}
+
+	// Missed paths may be matched once the Routes become active.
+	r.scheduled = true
+	r.misses.reset()
+	return r
+}
+
+// activeNow reports whether the Route is active, which it always is
+// unless scheduled.
+func (route *Route) activeNow() bool {
+	return nil == route.active || route.active(time.Now())
+}
@@ -0,0 +1,53 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestActiveBetween ensures scheduled Routes only match within their
+// window, falling through to other Routes outside it.
+func TestActiveBetween(t *testing.T) {
+	now := time.Now()
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(body))
+		})
+	}
+
+	router := NewRouter().CacheMisses(0).
+		Get("/sale", handler("sale")).ActiveBetween(now.Add(-time.Hour), now.Add(time.Hour)).
+		Get("/maintenance", handler("maintenance")).ActiveBetween(now.Add(time.Hour), time.Time{}).
+		Get("/expired", handler("expired")).ActiveBetween(time.Time{}, now.Add(-time.Hour)).
+		Get("/:page", handler("page"))
+
+	for path, expected := range map[string]string{
+		"/sale":        "sale",
+		"/maintenance": "page",
+		"/expired":     "page",
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if expected != res.Body.String() {
+			t.Errorf("Expected %s to be served by %q, got %q.", path, expected, res.Body.String())
+		}
+	}
+
+	// Misses aren't cached, as scheduled Routes may match them later.
+	open := false
+	flash := NewRouter().CacheMisses(0).Get("/flash", handler("flash")).ActiveWhen(func(time.Time) bool { return open })
+
+	for _, expected := range []int{http.StatusNotFound, http.StatusOK} {
+		res := httptest.NewRecorder()
+		flash.ServeHTTP(res, generateHttpRequest(GET, "/flash"))
+
+		if expected != res.Code {
+			t.Errorf("Expected /flash to be served %d, got %d.", expected, res.Code)
+		}
+
+		open = true
+	}
+}
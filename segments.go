@@ -0,0 +1,171 @@
+package dispatcher
+
+import (
+	"strings"
+)
+
+// segment is one `/` separated part of a Route's path.
+type segment struct {
+	literal  string // literal is the text a literal segment must equal.
+	param    string // param is the name of a parameter segment.
+	optional bool   // optional is set for optional parameter segments.
+	wildcard bool   // wildcard is set for a trailing `*` segment.
+}
+
+// compileSegments splits `path` into segments if it only uses literal
+// segments, whole segment parameters, trailing optional parameters and
+// a trailing wildcard, so Routes for it can be matched without regular
+// expressions or allocations. Other paths return nil and are matched
+// with the Route's regular expression.
+func compileSegments(path string) []segment {
+	if !strings.HasPrefix(path, "/") {
+		return nil
+	}
+
+	parts := strings.Split(path[1:], "/")
+	segments := make([]segment, 0, len(parts))
+
+	for i, part := range parts {
+		last := i == len(parts)-1
+
+		switch {
+		case "*" == part && last:
+			if 0 < i && segments[i-1].optional {
+				return nil
+			}
+
+			segments = append(segments, segment{wildcard: true})
+		case strings.HasPrefix(part, ":"):
+			name, optional := strings.CutSuffix(part[1:], "?")
+
+			if !paramName(name) || (0 < i && segments[i-1].optional && !optional) {
+				return nil
+			}
+
+			segments = append(segments, segment{param: name, optional: optional})
+		case staticPath(part):
+			if 0 < i && segments[i-1].optional {
+				return nil
+			}
+
+			segments = append(segments, segment{literal: part})
+		default:
+			return nil
+		}
+	}
+
+	return segments
+}
+
+// paramName reports whether `name` is a valid parameter name, matching
+// `\w+`.
+func paramName(name string) bool {
+	if "" == name {
+		return false
+	}
+
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || '_' == c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match reports whether the Route matches `path`, appending the
+// parameters captured to `params` if it is not nil. Routes with
+// segments are matched as their regular expression would, without
+// allocating beyond the growth of `params`.
+func (route *Route) match(path string, params *Params) bool {
+	if nil == route.segments {
+		return route.matcher.MatchString(path)
+	}
+
+	var start int
+
+	if nil != params {
+		start = len(*params)
+	}
+
+	if matchSegments(route.segments, path, params) {
+		return true
+	}
+
+	reset(params, start)
+
+	// Lenient Routes allow a trailing slash, which is only left over
+	// when the Route ends with a parameter or literal.
+	if !route.strict && 1 < len(path) && '/' == path[len(path)-1] {
+		if matchSegments(route.segments, path[:len(path)-1], params) {
+			return true
+		}
+
+		reset(params, start)
+	}
+
+	return false
+}
+
+// matchSegments reports whether `path` matches `segments`, appending
+// the parameters captured to `params` if it is not nil.
+func matchSegments(segments []segment, path string, params *Params) bool {
+	if "" == path || '/' != path[0] {
+		return false
+	}
+
+	rest, exhausted := path[1:], false
+
+	for _, seg := range segments {
+		if exhausted {
+			if !seg.optional {
+				return false
+			}
+
+			capture(params, seg.param, "")
+			continue
+		}
+
+		if seg.wildcard {
+			capture(params, "*", rest)
+			return true
+		}
+
+		part := rest
+
+		if end := strings.IndexByte(rest, '/'); -1 != end {
+			part, rest = rest[:end], rest[end+1:]
+		} else {
+			rest, exhausted = "", true
+		}
+
+		switch {
+		case "" != seg.param:
+			if "" == part {
+				return false
+			}
+
+			capture(params, seg.param, part)
+		case seg.literal != part:
+			return false
+		}
+	}
+
+	return exhausted
+}
+
+// capture appends the parameter `key` with `value` to `params` if it is
+// not nil.
+func capture(params *Params, key, value string) {
+	if nil != params {
+		*params = append(*params, Param{key, value})
+	}
+}
+
+// reset truncates `params`, if it is not nil, to `length`, dropping the
+// parameters captured by a failed match.
+func reset(params *Params, length int) {
+	if nil != params {
+		*params = (*params)[:length]
+	}
+}
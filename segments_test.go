@@ -0,0 +1,150 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestSegmentsMatchLikeRegexp ensures Routes matched by their segments
+// match and capture exactly as their regular expressions do.
+func TestSegmentsMatchLikeRegexp(t *testing.T) {
+	patterns := []string{
+		"/",
+		"/users",
+		"/users/",
+		"/users/:id",
+		"/users/:id/posts/:post",
+		"/posts/:year/:month?/:day?",
+		"/posts/*",
+		"/*",
+		"/assets/app.min.js",
+	}
+
+	paths := []string{
+		"", "/", "//", "/users", "/users/", "/users//", "/users/1", "/users/1/",
+		"/users/1/posts/2", "/users/1/posts/2/", "/users//posts/2", "/posts",
+		"/posts/", "/posts/2013", "/posts/2013/", "/posts/2013/01/02",
+		"/posts/2013/01/02/", "/posts/2013/01/02/03", "/assets/app.min.js",
+		"/assets/appxminxjs",
+	}
+
+	for _, pattern := range patterns {
+		for _, strict := range []bool{true, false} {
+			route := NewRoute(pattern, strict)
+
+			if nil == route.segments {
+				t.Fatalf("Expected %q to be matched by segments.", pattern)
+			}
+
+			regexpRoute := *route
+			regexpRoute.segments = nil
+
+			for _, path := range paths {
+				if expected, got := regexpRoute.match(path, nil), route.match(path, nil); expected != got {
+					t.Errorf("Expected %q (strict %t) matching %q to be %t.", pattern, strict, path, expected)
+				}
+
+				if expected, got := regexpRoute.params(path), route.params(path); !reflect.DeepEqual(expected, got) {
+					t.Errorf("Expected %q (strict %t) to capture %v from %q, got %v.", pattern, strict, expected, path, got)
+				}
+			}
+		}
+	}
+}
+
+// TestCompileSegments ensures paths the segment matcher can not match
+// as their regular expression would are left to the regular expression.
+func TestCompileSegments(t *testing.T) {
+	for _, pattern := range []string{
+		"users",
+		"/files/:name.:ext",
+		"/:lang?/users",
+		"/:lang?/:id",
+		"/:lang?/*",
+		"/files/*/raw",
+		"/users/:id(\\d+)",
+		"/(users|accounts)",
+		"/users-:id",
+	} {
+		if nil != compileSegments(pattern) {
+			t.Errorf("Expected %q not to be matched by segments.", pattern)
+		}
+	}
+}
+
+// TestMatchAllocations ensures matching Routes and capturing their
+// parameters does not allocate.
+func TestMatchAllocations(t *testing.T) {
+	router := generateBenchmarkRouter()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buffer := paramsPool.Get().(*Params)
+		*buffer = (*buffer)[:0]
+
+		route, _ := router.matchPath(GET, "/users/42/posts/7")
+		route.appendParams("/users/42/posts/7", buffer)
+
+		paramsPool.Put(buffer)
+	})
+
+	if 0 != allocs {
+		t.Errorf("Expected matching to not allocate, got %v allocations.", allocs)
+	}
+}
+
+// generateBenchmarkRouter returns a Router with a mix of static,
+// parameterized and wildcard Routes.
+func generateBenchmarkRouter() *Router {
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+	router := NewRouter()
+
+	for _, path := range []string{
+		"/healthz", "/metrics", "/users", "/users/:id", "/users/:id/posts",
+		"/users/:id/posts/:post", "/posts/:year/:month?", "/assets/*",
+	} {
+		router.Get(path, handler)
+	}
+
+	return router
+}
+
+// benchmarkServe benchmarks the Router serving GET requests to `path`.
+func benchmarkServe(b *testing.B, path string) {
+	router := generateBenchmarkRouter()
+	req := httptest.NewRequest(GET, path, nil)
+	res := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(res, req)
+	}
+}
+
+// benchmarkMatch benchmarks matching GET requests to `path` and
+// capturing their parameters.
+func benchmarkMatch(b *testing.B, path string) {
+	router := generateBenchmarkRouter()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buffer := paramsPool.Get().(*Params)
+		*buffer = (*buffer)[:0]
+
+		route, _ := router.matchPath(GET, path)
+		route.appendParams(path, buffer)
+
+		paramsPool.Put(buffer)
+	}
+}
+
+func BenchmarkMatchStatic(b *testing.B)   { benchmarkMatch(b, "/metrics") }
+func BenchmarkMatchParams(b *testing.B)   { benchmarkMatch(b, "/users/42/posts/7") }
+func BenchmarkMatchWildcard(b *testing.B) { benchmarkMatch(b, "/assets/css/app.css") }
+func BenchmarkServeStatic(b *testing.B)   { benchmarkServe(b, "/metrics") }
+func BenchmarkServeParams(b *testing.B)   { benchmarkServe(b, "/users/42/posts/7") }
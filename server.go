@@ -0,0 +1,261 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ServeOptions configures a Server started with the Router's Serve
+// method.
+type ServeOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// HandlerTimeout, if positive, limits how long requests are served
+	// for. Requests are given a context deadline, the earlier of their
+	// HandlerTimeout and WriteTimeout, so handlers can size the
+	// timeouts of downstream calls with RemainingBudget.
+	HandlerTimeout time.Duration
+	MaxHeaderBytes int
+	// MaxConnections, if positive, limits the number of connections open
+	// at once. Excess connections are closed as soon as they are
+	// accepted, answered with 503 Service Unavailable over plaintext
+	// and reset over TLS, protecting the process under connection
+	// floods.
+	MaxConnections int
+	// MaxConnectionsPerIP, if positive, limits the number of connections
+	// open at once from each remote IP address, as MaxConnections.
+	MaxConnectionsPerIP int
+	// H2C enables HTTP/2 over cleartext connections, with and without
+	// prior knowledge, alongside HTTP/1. This allows HTTP/2 clients,
+	// i.e. gRPC-web or internal services, to connect when TLS is
+	// terminated upstream by a load balancer.
+	H2C bool
+	// HTTP3, if set, is started alongside the Server to answer requests
+	// over QUIC, and responses served over TCP advertise it with an
+	// `Alt-Svc` header. It is typically configured with the Router as
+	// its handler and the same TLS configuration, i.e.
+	//
+	//	h3 := &http3.Server{Addr: ":443", Handler: router, TLSConfig: http3.ConfigureTLSConfig(config)}
+	//	server, err := router.ServeTLSConfig(":443", config, dispatcher.ServeOptions{HTTP3: h3})
+	HTTP3 HTTP3Server
+}
+
+// HTTP3Server is implemented by HTTP/3 servers, such as *http3.Server
+// from the github.com/quic-go/quic-go/http3 package, which can be served
+// alongside a Server.
+type HTTP3Server interface {
+	// ListenAndServe listens on the server's UDP address and serves
+	// HTTP/3 requests until the server is closed.
+	ListenAndServe() error
+	// SetQUICHeaders sets the `Alt-Svc` header advertising the server.
+	SetQUICHeaders(header http.Header) error
+	// Close immediately closes the server.
+	Close() error
+}
+
+// ShutdownHook is called while a Server shuts down, after in-flight
+// requests have drained, i.e. to close database connections.
+type ShutdownHook func(ctx context.Context) error
+
+// Server is a handle to an HTTP server started with the Router's Serve
+// method.
+type Server struct {
+	sync.Mutex
+	server   *http.Server
+	listener net.Listener
+	// inFlight tracks the requests being served.
+	inFlight *requestCounter
+	hooks    []ShutdownHook
+	// http3 answers requests over QUIC alongside the Server.
+	http3 HTTP3Server
+	// done is closed once the server stops serving, after which err
+	// holds the reason.
+	done chan struct{}
+	err  error
+}
+
+// Serve starts an HTTP server serving the Router on the TCP network
+// address `addr`, i.e. `:3000`, returning a handle that can be used to
+// shut it down gracefully. An error is returned if the address can not
+// be listened on.
+func (r *Router) Serve(addr string, opts ServeOptions) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+
+	if nil != err {
+		return nil, err
+	}
+
+	return r.ServeListener(listener, opts), nil
+}
+
+// ServeListener behaves as Serve, accepting connections from
+// `listener`.
+func (r *Router) ServeListener(listener net.Listener, opts ServeOptions) *Server {
+	listener = limitConnections(listener, opts, true)
+	s := newServer(r, listener, opts)
+	s.start(func() error { return s.server.Serve(listener) })
+	return s
+}
+
+// newServer creates a Server serving `handler` on `listener`. The
+// Server must be started before it accepts connections.
+func newServer(handler http.Handler, listener net.Listener, opts ServeOptions) *Server {
+	s := &Server{
+		listener: listener,
+		inFlight: newRequestCounter(),
+		done:     make(chan struct{}),
+		http3:    opts.HTTP3,
+	}
+
+	if nil != s.http3 {
+		handler = s.advertiseHTTP3(handler)
+	}
+
+	if budget := requestBudget(opts); 0 < budget {
+		handler = limitBudget(handler, budget)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.countRequests(handler),
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+
+	if opts.H2C {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.server.Protocols = protocols
+	}
+
+	return s
+}
+
+// start calls `serve` in a new goroutine, recording the error it
+// returns once the Server stops. The Server's HTTP/3 server, if any, is
+// started as well; if it fails, i.e. to bind its UDP address, the
+// Server is closed and stops with its error.
+func (s *Server) start(serve func() error) {
+	if nil != s.http3 {
+		go func() {
+			if err := s.http3.ListenAndServe(); nil != err && !errors.Is(err, http.ErrServerClosed) {
+				s.fail(err)
+				s.server.Close()
+			}
+		}()
+	}
+
+	go func() {
+		if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+			s.fail(err)
+		}
+
+		close(s.done)
+	}()
+}
+
+// fail records `err` as the reason the Server stopped, unless one was
+// recorded already.
+func (s *Server) fail(err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if nil == s.err {
+		s.err = err
+	}
+}
+
+// countRequests is a middleware handler tracking the number of
+// requests in flight.
+func (s *Server) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		s.inFlight.begin()
+		defer s.inFlight.end()
+
+		next.ServeHTTP(res, req)
+	})
+}
+
+// advertiseHTTP3 is a middleware handler setting the `Alt-Svc` header
+// advertising the Server's HTTP/3 server on each response.
+func (s *Server) advertiseHTTP3(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		s.http3.SetQUICHeaders(res.Header())
+		next.ServeHTTP(res, req)
+	})
+}
+
+// Addr returns the address the Server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// InFlight returns the number of requests being served.
+func (s *Server) InFlight() int64 {
+	return s.inFlight.count.Load()
+}
+
+// OnShutdown registers a hook called when the Server shuts down. Hooks
+// are called in the order they were registered.
+func (s *Server) OnShutdown(hook ShutdownHook) *Server {
+	s.Lock()
+	defer s.Unlock()
+
+	s.hooks = append(s.hooks, hook)
+	return s
+}
+
+// Shutdown gracefully shuts down the Server: it stops accepting new
+// connections, waits for in-flight requests to complete, and then
+// calls the Server's shutdown hooks. If `ctx` expires first, its error
+// is returned, though the shutdown hooks are still called. Otherwise
+// the first error returned by a hook is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+
+	if nil != s.http3 {
+		// Prefer a graceful shutdown where the HTTP/3 server supports it.
+		if graceful, ok := s.http3.(interface{ Shutdown(context.Context) error }); ok {
+			graceful.Shutdown(ctx)
+		} else {
+			s.http3.Close()
+		}
+	}
+
+	if nil == err {
+		err = s.inFlight.drain(ctx)
+	}
+
+	s.Lock()
+	hooks := s.hooks
+	s.Unlock()
+
+	for _, hook := range hooks {
+		if hookErr := hook(ctx); nil == err {
+			err = hookErr
+		}
+	}
+
+	return err
+}
+
+// Wait blocks until the Server stops serving, returning the error that
+// caused it to stop, or nil if it was shut down.
+func (s *Server) Wait() error {
+	<-s.done
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.err
+}
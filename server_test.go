@@ -0,0 +1,175 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServerShutdown ensures Shutdown waits for in-flight requests to
+// complete before calling shutdown hooks.
+func TestServerShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var events []string
+
+	router := NewRouter().Get("/slow", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		events = append(events, "request")
+	}))
+
+	server, err := router.Serve("127.0.0.1:0", ServeOptions{})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	server.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "hook")
+		return nil
+	})
+
+	go http.Get("http://" + server.Addr().String() + "/slow")
+	<-started
+
+	if 1 != server.InFlight() {
+		t.Errorf("Expected 1 request in flight, found %d.", server.InFlight())
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	if err := server.Shutdown(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+
+	if err := server.Wait(); nil != err {
+		t.Fatal(err)
+	}
+
+	if 2 != len(events) || "request" != events[0] || "hook" != events[1] {
+		t.Errorf("Expected request to complete before shutdown hook, got %v.", events)
+	}
+}
+
+// TestServerH2C ensures HTTP/2 cleartext requests are served when
+// H2C is enabled.
+func TestServerH2C(t *testing.T) {
+	router := NewRouter().Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Proto", req.Proto)
+	}))
+
+	server, err := router.Serve("127.0.0.1:0", ServeOptions{H2C: true})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer server.Shutdown(context.Background())
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	res, err := client.Get("http://" + server.Addr().String() + "/")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if "HTTP/2.0" != res.Header.Get("X-Proto") {
+		t.Errorf("Expected request to be served over HTTP/2, was %q.", res.Header.Get("X-Proto"))
+	}
+}
+
+// fakeHTTP3Server is an HTTP3Server recording how it is used.
+type fakeHTTP3Server struct {
+	started chan struct{}
+	closed  chan struct{}
+}
+
+func (f *fakeHTTP3Server) ListenAndServe() error {
+	close(f.started)
+	<-f.closed
+	return nil
+}
+
+func (f *fakeHTTP3Server) SetQUICHeaders(header http.Header) error {
+	header.Set("Alt-Svc", `h3=":443"; ma=2592000`)
+	return nil
+}
+
+func (f *fakeHTTP3Server) Close() error {
+	close(f.closed)
+	return nil
+}
+
+// TestServerHTTP3 ensures the HTTP/3 server is started, advertised on
+// TCP responses, and closed on shutdown.
+func TestServerHTTP3(t *testing.T) {
+	h3 := &fakeHTTP3Server{make(chan struct{}), make(chan struct{})}
+	server, err := NewRouter().Serve("127.0.0.1:0", ServeOptions{HTTP3: h3})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	<-h3.started
+	res, err := http.Get("http://" + server.Addr().String() + "/")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if "" == res.Header.Get("Alt-Svc") {
+		t.Error("Expected response to advertise HTTP/3.")
+	}
+
+	server.Shutdown(context.Background())
+
+	select {
+	case <-h3.closed:
+	default:
+		t.Error("Expected HTTP/3 server to be closed on shutdown.")
+	}
+}
+
+// failingHTTP3Server is an HTTP3Server failing to listen.
+type failingHTTP3Server struct {
+	fakeHTTP3Server
+}
+
+func (f *failingHTTP3Server) ListenAndServe() error {
+	return errors.New("listen udp :443: bind: address already in use")
+}
+
+// TestServerHTTP3Failure ensures a failing HTTP/3 server stops the
+// Server with its error.
+func TestServerHTTP3Failure(t *testing.T) {
+	server, err := NewRouter().Serve("127.0.0.1:0", ServeOptions{HTTP3: &failingHTTP3Server{}})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- server.Wait() }()
+
+	select {
+	case err := <-done:
+		if nil == err || !strings.Contains(err.Error(), "bind") {
+			t.Errorf("Expected the HTTP/3 error, got %v.", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the Server to stop when HTTP/3 fails.")
+	}
+}
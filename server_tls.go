@@ -0,0 +1,38 @@
+package dispatcher
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// ServeTLS behaves as Serve, serving HTTPS with the certificate and
+// private key found in the PEM encoded files `certFile` and `keyFile`.
+func (r *Router) ServeTLS(addr, certFile, keyFile string, opts ServeOptions) (*Server, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+	if nil != err {
+		return nil, err
+	}
+
+	return r.ServeTLSConfig(addr, &tls.Config{Certificates: []tls.Certificate{certificate}}, opts)
+}
+
+// ServeTLSConfig behaves as Serve, serving HTTPS configured with
+// `config`, i.e. one obtaining certificates dynamically with
+// GetCertificate.
+func (r *Router) ServeTLSConfig(addr string, config *tls.Config, opts ServeOptions) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+
+	if nil != err {
+		return nil, err
+	}
+
+	// Rejected connections are reset, as answering them requires a TLS
+	// handshake.
+	listener = limitConnections(listener, opts, false)
+	s := newServer(r, listener, opts)
+	s.server.TLSConfig = config
+	s.start(func() error { return s.server.ServeTLS(listener, "", "") })
+
+	return s, nil
+}
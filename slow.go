@@ -0,0 +1,138 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// DefaultSlowRequestThreshold is the duration after which requests are
+// reported as slow when SlowRequestOptions.Threshold is unset.
+const DefaultSlowRequestThreshold = 5 * time.Second
+
+// SlowRequest describes a request still being served after the
+// threshold of SlowRequests.
+type SlowRequest struct {
+	Request *http.Request
+	// Route describes the Route matching the request, if Matched.
+	Route   RouteInfo
+	Matched bool
+	Params  Params
+	// Duration is how long the request has been served for.
+	Duration time.Duration
+	// Stack is the stack of the goroutine serving the request, if
+	// SlowRequestOptions.Stack is set.
+	Stack []byte
+}
+
+// SlowRequestOptions configures SlowRequests.
+type SlowRequestOptions struct {
+	// Threshold is how long requests are served for before they are
+	// reported.
+	Threshold time.Duration
+	// Stack includes the stack of the goroutine serving a request in
+	// its report, showing where the handler is stuck. Capturing it
+	// briefly stops the world.
+	Stack bool
+	// OnSlow is called with each slow request, from its own goroutine.
+	OnSlow func(slow SlowRequest)
+}
+
+// SlowRequests returns a HandlerWrapper calling SlowRequestOptions.OnSlow
+// once a request has been served for longer than the threshold, while
+// it is still being served, so handlers stuck on downstream calls are
+// caught, i.e.
+//
+//	router.RegisterWrapper(dispatcher.SlowRequests(dispatcher.SlowRequestOptions{
+//		Threshold: 2 * time.Second,
+//		Stack:     true,
+//		OnSlow:    func(slow dispatcher.SlowRequest) { log.Printf("slow %s: %s", slow.Route.Path, slow.Stack) },
+//	}))
+func SlowRequests(opts SlowRequestOptions) HandlerWrapper {
+	if 0 >= opts.Threshold {
+		opts.Threshold = DefaultSlowRequestThreshold
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if nil == opts.OnSlow {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			matched, ok := req.Context().Value(routeContextKey{}).(*matchedRoute)
+
+			if !ok {
+				matched = new(matchedRoute)
+				req = req.WithContext(context.WithValue(req.Context(), routeContextKey{}, matched))
+			}
+
+			var (
+				start     = time.Now()
+				goroutine int64
+			)
+
+			if opts.Stack {
+				goroutine = goroutineID()
+			}
+
+			timer := time.AfterFunc(opts.Threshold, func() {
+				slow := SlowRequest{Request: req, Duration: time.Since(start)}
+
+				if route, params := matched.get(); nil != route {
+					slow.Route, slow.Matched, slow.Params = route.Info(), true, params
+				}
+
+				if opts.Stack {
+					slow.Stack = goroutineStack(goroutine)
+				}
+
+				opts.OnSlow(slow)
+			})
+
+			defer timer.Stop()
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// header of its stack, i.e. `goroutine 18 [running]:`.
+func goroutineID() int64 {
+	var buffer [64]byte
+
+	header := bytes.TrimPrefix(buffer[:runtime.Stack(buffer[:], false)], []byte("goroutine "))
+	id, _, _ := bytes.Cut(header, []byte(" "))
+	parsed, _ := strconv.ParseInt(string(id), 10, 64)
+	return parsed
+}
+
+// goroutineStack returns the stack of the goroutine `id`, or nil if it
+// has exited.
+func goroutineStack(id int64) []byte {
+	buffer := make([]byte, 64<<10)
+
+	for {
+		n := runtime.Stack(buffer, true)
+
+		if n < len(buffer) {
+			buffer = buffer[:n]
+			break
+		}
+
+		buffer = make([]byte, 2*len(buffer))
+	}
+
+	prefix := []byte("goroutine " + strconv.FormatInt(id, 10) + " [")
+
+	for _, stack := range bytes.Split(buffer, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, prefix) {
+			return stack
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSlowRequests ensures requests still being served after the
+// threshold are reported with their Route, Params and stack.
+func TestSlowRequests(t *testing.T) {
+	reported := make(chan SlowRequest, 1)
+	release := make(chan struct{})
+
+	router := NewRouter()
+	router.RegisterWrapper(SlowRequests(SlowRequestOptions{
+		Threshold: 10 * time.Millisecond,
+		Stack:     true,
+		OnSlow:    func(slow SlowRequest) { reported <- slow },
+	}))
+	router.Get("/reports/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if "slow" == ParamsFromContext(req.Context()).Get("id") {
+			<-release
+		}
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/reports/fast"))
+
+	done := make(chan struct{})
+
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/reports/slow"))
+		close(done)
+	}()
+
+	var slow SlowRequest
+
+	select {
+	case slow = <-reported:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the slow request to be reported.")
+	}
+
+	close(release)
+	<-done
+
+	if !slow.Matched || "/reports/:id" != slow.Route.Path || "slow" != slow.Params.Get("id") || 10*time.Millisecond > slow.Duration {
+		t.Errorf("Expected the slow request's Route and Params, got %+v.", slow)
+	}
+
+	if !bytes.Contains(slow.Stack, []byte("TestSlowRequests")) {
+		t.Errorf("Expected the stack of the handler, got %s", slow.Stack)
+	}
+
+	select {
+	case slow = <-reported:
+		t.Errorf("Expected only the slow request to be reported, got %+v.", slow)
+	default:
+	}
+}
@@ -0,0 +1,12 @@
+package dispatcher
+
+import (
+	"github.com/chuckpreslar/dispatcher/sse"
+)
+
+// SSE registers a route to match the given path argument for HTTP GET
+// requests which subscribes clients to `broker`, streaming the
+// Server-Sent Events published to it until they disconnect.
+func (r *Router) SSE(path string, broker *sse.Broker) *Router {
+	return r.Get(path, broker)
+}
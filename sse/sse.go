@@ -0,0 +1,329 @@
+// Package sse provides a broker for publishing Server-Sent Events to
+// subscribed HTTP clients.
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults used for Options left unset.
+const (
+	DefaultHeartbeat    = 15 * time.Second
+	DefaultReplaySize   = 100
+	DefaultClientBuffer = 16
+)
+
+// Event is a message published to the subscribers of a topic.
+type Event struct {
+	// ID is assigned by the Broker when the event is published, and is
+	// sent to clients so they can resume after reconnecting.
+	ID uint64
+	// Event names the type of the event. If empty, clients receive it
+	// as a `message` event. Line breaks are removed when it is sent.
+	Event string
+	// Data is sent as one `data` field per line, with lines ending at
+	// `\r\n`, `\r` or `\n`.
+	Data string
+	// Retry, if positive, instructs clients how long to wait before
+	// reconnecting.
+	Retry time.Duration
+}
+
+// Options configures a Broker.
+type Options struct {
+	// Heartbeat is the interval at which comments are sent to idle
+	// clients so proxies do not close their connections.
+	Heartbeat time.Duration
+	// ReplaySize is the number of recent events retained per topic for
+	// replay to clients reconnecting with a `Last-Event-ID` header.
+	ReplaySize int
+	// ClientBuffer is the number of events buffered per client. Clients
+	// falling further behind are disconnected, and may catch up through
+	// replay once they reconnect.
+	ClientBuffer int
+	// Topics returns the topics a request subscribes to. By default the
+	// values of the `topic` query parameter are used, or the empty
+	// topic if there are none.
+	Topics func(req *http.Request) []string
+}
+
+// Broker distributes published events to subscribed clients. A Broker
+// is an http.Handler streaming events to the clients it serves. It is
+// safe for concurrent use.
+type Broker struct {
+	sync.Mutex
+	options Options
+	// sequence is the ID of the most recently published event.
+	sequence uint64
+	// topics maps topic names to their subscribers and recent events.
+	topics map[string]*topic
+	closed bool
+}
+
+// topic holds the subscribers and recent events of a topic.
+type topic struct {
+	subscribers map[*Subscription]struct{}
+	recent      []Event
+}
+
+// Subscription receives the events published to one or more topics.
+type Subscription struct {
+	// Events receives published events. It is closed when the
+	// subscription ends.
+	Events <-chan Event
+	events chan Event
+	topics []string
+	once   sync.Once
+}
+
+// NewBroker creates a Broker configured by `opts`.
+func NewBroker(opts Options) *Broker {
+	if 0 >= opts.Heartbeat {
+		opts.Heartbeat = DefaultHeartbeat
+	}
+
+	if 0 >= opts.ReplaySize {
+		opts.ReplaySize = DefaultReplaySize
+	}
+
+	if 0 >= opts.ClientBuffer {
+		opts.ClientBuffer = DefaultClientBuffer
+	}
+
+	if nil == opts.Topics {
+		opts.Topics = queryTopics
+	}
+
+	return &Broker{options: opts, topics: make(map[string]*topic)}
+}
+
+// queryTopics returns the values of the request's `topic` query
+// parameter.
+func queryTopics(req *http.Request) []string {
+	if topics := req.URL.Query()["topic"]; 0 < len(topics) {
+		return topics
+	}
+
+	return []string{""}
+}
+
+// Publish sends `event` to the subscribers of `name`, returning the ID
+// assigned to it.
+func (b *Broker) Publish(name string, event Event) uint64 {
+	b.Lock()
+	defer b.Unlock()
+
+	b.sequence += 1
+	event.ID = b.sequence
+
+	t := b.topic(name)
+	t.recent = append(t.recent, event)
+
+	if len(t.recent) > b.options.ReplaySize {
+		t.recent = t.recent[len(t.recent)-b.options.ReplaySize:]
+	}
+
+	for subscription := range t.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			// The subscriber has fallen behind; disconnect it.
+			b.unsubscribe(subscription)
+		}
+	}
+
+	return event.ID
+}
+
+// Subscribe subscribes to the topics `names`. Retained events published
+// after the event `lastEventID` are delivered first; a `lastEventID` of
+// zero skips replay.
+func (b *Broker) Subscribe(lastEventID uint64, names ...string) *Subscription {
+	b.Lock()
+	defer b.Unlock()
+
+	var replay []Event
+
+	if 0 < lastEventID {
+		for _, name := range names {
+			for _, event := range b.topic(name).recent {
+				if event.ID > lastEventID {
+					replay = append(replay, event)
+				}
+			}
+		}
+
+		sort.Slice(replay, func(i, j int) bool {
+			return replay[i].ID < replay[j].ID
+		})
+	}
+
+	events := make(chan Event, b.options.ClientBuffer+len(replay))
+	subscription := &Subscription{Events: events, events: events, topics: names}
+
+	for _, event := range replay {
+		events <- event
+	}
+
+	if b.closed {
+		subscription.close()
+		return subscription
+	}
+
+	for _, name := range names {
+		b.topic(name).subscribers[subscription] = struct{}{}
+	}
+
+	return subscription
+}
+
+// Unsubscribe ends `subscription`, closing its Events channel.
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.unsubscribe(subscription)
+}
+
+// Subscribers returns the number of subscriptions to the topic `name`.
+func (b *Broker) Subscribers(name string) int {
+	b.Lock()
+	defer b.Unlock()
+
+	if t, ok := b.topics[name]; ok {
+		return len(t.subscribers)
+	}
+
+	return 0
+}
+
+// Close ends all subscriptions, disconnecting clients. Subscriptions
+// made after the Broker is closed end immediately.
+func (b *Broker) Close() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.closed = true
+
+	for _, t := range b.topics {
+		for subscription := range t.subscribers {
+			b.unsubscribe(subscription)
+		}
+	}
+}
+
+// topic returns the topic `name`, creating it if necessary. The broker
+// must be locked by the caller.
+func (b *Broker) topic(name string) *topic {
+	t, ok := b.topics[name]
+
+	if !ok {
+		t = &topic{subscribers: make(map[*Subscription]struct{})}
+		b.topics[name] = t
+	}
+
+	return t
+}
+
+// unsubscribe ends `subscription`. The broker must be locked by the
+// caller.
+func (b *Broker) unsubscribe(subscription *Subscription) {
+	for _, name := range subscription.topics {
+		if t, ok := b.topics[name]; ok {
+			delete(t.subscribers, subscription)
+		}
+	}
+
+	subscription.close()
+}
+
+// close closes the subscription's Events channel once.
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.events) })
+}
+
+// ServeHTTP subscribes the client to the topics of the request and
+// streams events to it until the client disconnects, the subscription
+// ends, or the Broker is closed.
+func (b *Broker) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	controller := http.NewResponseController(res)
+	lastEventID, _ := strconv.ParseUint(req.Header.Get("Last-Event-ID"), 10, 64)
+
+	header := res.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if err := controller.Flush(); nil != err {
+		return
+	}
+
+	subscription := b.Subscribe(lastEventID, b.options.Topics(req)...)
+	defer b.Unsubscribe(subscription)
+
+	heartbeat := time.NewTicker(b.options.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+
+			if _, err := writeEvent(res, event); nil != err {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ":\n\n"); nil != err {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		}
+
+		if err := controller.Flush(); nil != err {
+			return
+		}
+	}
+}
+
+// Replacers normalizing the line endings of the event stream format
+// to `\n`, and removing them.
+var (
+	lineBreaks       = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	removeLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+)
+
+// writeEvent writes `event` to `w` in the event stream format. Line
+// breaks can not inject fields: they are removed from the event name
+// and end a data field.
+func writeEvent(w http.ResponseWriter, event Event) (int, error) {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "id: %d\n", event.ID)
+
+	if name := removeLineBreaks.Replace(event.Event); "" != name {
+		fmt.Fprintf(&builder, "event: %s\n", name)
+	}
+
+	if 0 < event.Retry {
+		fmt.Fprintf(&builder, "retry: %d\n", event.Retry.Milliseconds())
+	}
+
+	for _, line := range strings.Split(lineBreaks.Replace(event.Data), "\n") {
+		fmt.Fprintf(&builder, "data: %s\n", line)
+	}
+
+	builder.WriteString("\n")
+
+	return fmt.Fprint(w, builder.String())
+}
@@ -0,0 +1,98 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBrokerReplay ensures subscribers reconnecting with a last event
+// ID receive the retained events they missed.
+func TestBrokerReplay(t *testing.T) {
+	broker := NewBroker(Options{ReplaySize: 2})
+
+	broker.Publish("news", Event{Data: "one"})
+	broker.Publish("news", Event{Data: "two"})
+	broker.Publish("news", Event{Data: "three"})
+
+	subscription := broker.Subscribe(1, "news")
+	broker.Unsubscribe(subscription)
+
+	var replayed []string
+
+	for event := range subscription.Events {
+		replayed = append(replayed, event.Data)
+	}
+
+	if 2 != len(replayed) || "two" != replayed[0] || "three" != replayed[1] {
+		t.Errorf("Expected events two and three to be replayed, got %v.", replayed)
+	}
+}
+
+// TestBrokerServeHTTP ensures published events are streamed to clients
+// in the event stream format.
+func TestBrokerServeHTTP(t *testing.T) {
+	broker := NewBroker(Options{})
+	server := httptest.NewServer(broker)
+
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "?topic=news")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if "text/event-stream" != res.Header.Get("Content-Type") {
+		t.Errorf("Expected event stream Content-Type, got %q.", res.Header.Get("Content-Type"))
+	}
+
+	for 0 == broker.Subscribers("news") {
+		time.Sleep(time.Millisecond)
+	}
+
+	broker.Publish("news", Event{Event: "headline", Data: "line one\nline two"})
+	broker.Close()
+
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if nil != err {
+			break
+		}
+
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	expected := "id: 1|event: headline|data: line one|data: line two|"
+
+	if actual := strings.Join(lines, "|"); expected != actual {
+		t.Errorf("Expected event stream %q, got %q.", expected, actual)
+	}
+}
+
+// TestWriteEventLineBreaks ensures line breaks in an event's name or
+// data can not inject fields.
+func TestWriteEventLineBreaks(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	writeEvent(res, Event{
+		ID:    1,
+		Event: "headline\nid: 99\r\ndata: injected",
+		Data:  "one\rid: 99\r\ntwo\nthree",
+	})
+
+	expected := "id: 1\nevent: headlineid: 99data: injected\ndata: one\ndata: id: 99\ndata: two\ndata: three\n\n"
+
+	if actual := res.Body.String(); expected != actual {
+		t.Errorf("Expected event %q, got %q.", expected, actual)
+	}
+}
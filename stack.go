@@ -0,0 +1,58 @@
+package dispatcher
+
+import (
+	"net/http"
+)
+
+// Stack bundles an ordered set of Middleware so it can be applied as a
+// unit, i.e.
+//
+//	api := dispatcher.NewStack(authenticate, rateLimit)
+//
+//	router.RegisterMiddleware(api)                    // to every request
+//	router.Get("/admin", api.Then(AdminHandler))      // to a single Route
+//
+// A Stack is itself Middleware, running its Middleware in order until
+// one serves the request. Stacks are immutable once created.
+type Stack struct {
+	middleware []Middleware
+}
+
+// NewStack creates a Stack of `middleware`, run in the order given.
+func NewStack(middleware ...Middleware) *Stack {
+	return &Stack{append([]Middleware(nil), middleware...)}
+}
+
+// Append returns a new Stack running the Stack's Middleware followed
+// by `middleware`, leaving the Stack unchanged.
+func (s *Stack) Append(middleware ...Middleware) *Stack {
+	combined := make([]Middleware, 0, len(s.middleware)+len(middleware))
+	return &Stack{append(append(combined, s.middleware...), middleware...)}
+}
+
+// Len returns the number of Middleware in the Stack.
+func (s *Stack) Len() int {
+	return len(s.middleware)
+}
+
+// ServeHTTP runs the Stack's Middleware in order, returning `true` as
+// soon as one serves the request.
+func (s *Stack) ServeHTTP(res http.ResponseWriter, req *http.Request) bool {
+	for _, middleware := range s.middleware {
+		if middleware.ServeHTTP(res, req) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Then returns a handler running the Stack's Middleware before
+// `handler`, which is only called if none of them served the request.
+func (s *Stack) Then(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !s.ServeHTTP(res, req) {
+			handler.ServeHTTP(res, req)
+		}
+	})
+}
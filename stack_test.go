@@ -0,0 +1,89 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestStack ensures Stacks run their middleware in order when applied
+// to a Router or a single Route.
+func TestStack(t *testing.T) {
+	var calls []string
+
+	record := func(name string, serve bool) MiddlewareHandler {
+		return func(res http.ResponseWriter, req *http.Request) bool {
+			calls = append(calls, name)
+			return serve
+		}
+	}
+
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			calls = append(calls, name)
+		})
+	}
+
+	api := NewStack(record("auth", false), record("limit", false))
+	admin := api.Append(record("deny", true))
+
+	router := NewRouter().
+		RegisterMiddleware(NewStack(record("global", false))).
+		Get("/users", api.Then(handler("users"))).
+		Get("/admin", admin.Then(handler("admin")))
+
+	for path, expected := range map[string]string{
+		"/users": "global auth limit users",
+		"/admin": "global auth limit deny",
+	} {
+		calls = nil
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, path))
+
+		if expected != strings.Join(calls, " ") {
+			t.Errorf("Expected %q for %v, got %q.", expected, path, strings.Join(calls, " "))
+		}
+	}
+
+	if 2 != api.Len() || 3 != admin.Len() {
+		t.Errorf("Expected Append to leave the original stack unchanged, got %d and %d.", api.Len(), admin.Len())
+	}
+}
+
+// TestUse ensures Use registers middleware functions and values in
+// order.
+func TestUse(t *testing.T) {
+	var calls []string
+
+	record := func(name string) MiddlewareHandler {
+		return func(res http.ResponseWriter, req *http.Request) bool {
+			calls = append(calls, name)
+			return false
+		}
+	}
+
+	plain := func(res http.ResponseWriter, req *http.Request) bool {
+		calls = append(calls, "fifth")
+		return false
+	}
+
+	router := NewRouter().
+		RegisterMiddleware(record("first")).
+		Use(record("second"), NewStack(record("third"), record("fourth"))).
+		Use(plain).
+		Use()
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+
+	if expected := "first second third fourth fifth"; expected != strings.Join(calls, " ") {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls, " "))
+	}
+
+	defer func() {
+		if nil == recover() {
+			t.Error("Expected Use to panic when given an unsupported value.")
+		}
+	}()
+
+	router.Use(http.NotFoundHandler())
+}
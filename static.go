@@ -0,0 +1,69 @@
+package dispatcher
+
+import (
+	"strings"
+)
+
+// staticPath reports whether `path` has no parameters, wildcards or
+// patterns, so a Route created for it only matches the path itself
+// and, if lenient, the path with a trailing slash.
+func staticPath(path string) bool {
+	return !strings.ContainsAny(path, `:*()?[]{}+|^$\`)
+}
+
+// staticPaths returns the request paths a static `route` matches.
+func (route *Route) staticPaths() []string {
+	if route.strict {
+		return []string{route.path}
+	}
+
+	return []string{route.path, route.path + "/"}
+}
+
+// indexRoute adds the request paths of `route` to the Router's index
+// of static paths if its path is static, so requests for them are
+// matched without running any regular expressions. Each path is
+// indexed to the first Route registered matching it, which need not
+// be `route`. The Router must be locked by the caller.
+func (r *Router) indexRoute(route *Route) {
+	// Any Route registered may match paths missed before.
+	r.misses.reset()
+
+	if !staticPath(route.path) {
+		return
+	}
+
+	if nil == r.static {
+		r.static = make(map[string]map[string]RouteHandler)
+	}
+
+	paths, ok := r.static[route.method]
+
+	if !ok {
+		paths = make(map[string]RouteHandler)
+		r.static[route.method] = paths
+	}
+
+	for _, path := range route.staticPaths() {
+		if _, ok := paths[path]; ok {
+			continue
+		}
+
+		for _, registered := range r.dispatcher[route.method] {
+			if registered.Route.match(path, nil) {
+				paths[path] = registered
+				break
+			}
+		}
+	}
+}
+
+// reindex rebuilds the Router's index of static paths for `method`.
+// The Router must be locked by the caller.
+func (r *Router) reindex(method string) {
+	delete(r.static, method)
+
+	for _, registered := range r.dispatcher[method] {
+		r.indexRoute(registered.Route)
+	}
+}
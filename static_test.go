@@ -0,0 +1,53 @@
+package dispatcher
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestStaticPath ensures only paths without parameters, wildcards or
+// patterns are considered static.
+func TestStaticPath(t *testing.T) {
+	for path, static := range map[string]bool{
+		"/healthz":            true,
+		"/assets/app.js":      true,
+		"/users/:id":          false,
+		"/posts/*":            false,
+		"/(users|accounts)":   false,
+		"/files/:name.:ext?":  false,
+		"/api/v1/status-page": true,
+	} {
+		if static != staticPath(path) {
+			t.Errorf("Expected staticPath(%q) to be %t.", path, static)
+		}
+	}
+}
+
+// TestStaticRoutes ensures static Routes are matched through the
+// index, honoring their strictness.
+func TestStaticRoutes(t *testing.T) {
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+
+	router := NewRouter().
+		Get("/healthz", ok).
+		Get("/metrics", ok).Strict().
+		Get("/users/:id", ok)
+
+	if 3 != len(router.static[GET]) {
+		t.Errorf("Expected 3 indexed paths, got %v.", router.static[GET])
+	}
+
+	tests := map[string]bool{
+		"/healthz":  true,
+		"/healthz/": true,
+		"/metrics":  true,
+		"/metrics/": false,
+		"/users/1":  true,
+	}
+
+	for path, matches := range tests {
+		if route, _ := router.findMatchingRouteAndHandler(generateHttpRequest(GET, path)); matches != (nil != route) {
+			t.Errorf("Expected %q to match: %t.", path, matches)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package dispatcher
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults used for StatsOptions left unset.
+const (
+	DefaultStatsWindow = time.Minute
+	DefaultStatsSlots  = 12
+)
+
+// statsBuckets is the number of latency histogram buckets. Bucket `i`
+// counts latencies below 2^(i/2) microseconds, so percentiles are
+// estimated within a factor of √2, up to about 2^31µs (35 minutes).
+const statsBuckets = 63
+
+// StatsOptions configures the statistics collected by CollectStats.
+type StatsOptions struct {
+	// Window is how far back statistics reach.
+	Window time.Duration
+	// Slots is the number of intervals the window is divided in;
+	// statistics expire one slot at a time.
+	Slots int
+}
+
+// RouteStats are the latency statistics of a Route over the window of
+// the Router's StatsOptions. Durations are estimated from a histogram,
+// except for Mean and Max.
+type RouteStats struct {
+	Method string        `json:"method"`
+	Path   string        `json:"path"`
+	Count  uint64        `json:"count"`
+	Errors uint64        `json:"errors"` // Errors counts 5xx responses.
+	Mean   time.Duration `json:"mean"`
+	P50    time.Duration `json:"p50"`
+	P90    time.Duration `json:"p90"`
+	P99    time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// statsCollector collects the statistics of a Router's Routes.
+type statsCollector struct {
+	sync.Mutex
+	options StatsOptions
+	routes  map[*Route]*routeStats
+}
+
+// routeStats is a rolling histogram of a Route's latencies, kept as
+// one histogram per slot of the window.
+type routeStats struct {
+	slots []statsSlot
+}
+
+// statsSlot is the histogram of the latencies recorded in the slot
+// numbered `epoch` since the Unix epoch.
+type statsSlot struct {
+	epoch   int64
+	buckets [statsBuckets]uint64
+	count   uint64
+	errors  uint64
+	sum     time.Duration
+	max     time.Duration
+}
+
+// CollectStats makes the Router collect latency statistics of each of
+// its Routes over a rolling window, reported by Stats, i.e. for admin
+// pages or adaptive throttling without an external metrics system.
+func (r *Router) CollectStats(opts ...StatsOptions) *Router {
+	var options StatsOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	if 0 >= options.Window {
+		options.Window = DefaultStatsWindow
+	}
+
+	if 0 >= options.Slots {
+		options.Slots = DefaultStatsSlots
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.stats = &statsCollector{options: options, routes: make(map[*Route]*routeStats)}
+	return r
+}
+
+// Stats returns the statistics of the Routes that served requests
+// within the window, ordered by path and then by method, or nil unless
+// CollectStats was called.
+func (r *Router) Stats() []RouteStats {
+	r.Lock()
+	collector := r.stats
+	r.Unlock()
+
+	if nil == collector {
+		return nil
+	}
+
+	collector.Lock()
+	defer collector.Unlock()
+
+	var (
+		stats   []RouteStats
+		current = collector.epoch(time.Now())
+	)
+
+	for route, collected := range collector.routes {
+		var merged statsSlot
+
+		for _, slot := range collected.slots {
+			if current-slot.epoch >= int64(len(collected.slots)) {
+				continue
+			}
+
+			for i, count := range slot.buckets {
+				merged.buckets[i] += count
+			}
+
+			merged.count += slot.count
+			merged.errors += slot.errors
+			merged.sum += slot.sum
+			merged.max = max(merged.max, slot.max)
+		}
+
+		if 0 == merged.count {
+			continue
+		}
+
+		stats = append(stats, RouteStats{
+			Method: route.method,
+			Path:   route.path,
+			Count:  merged.count,
+			Errors: merged.errors,
+			Mean:   merged.sum / time.Duration(merged.count),
+			P50:    merged.percentile(0.5),
+			P90:    merged.percentile(0.9),
+			P99:    merged.percentile(0.99),
+			Max:    merged.max,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Path != stats[j].Path {
+			return stats[i].Path < stats[j].Path
+		}
+
+		return stats[i].Method < stats[j].Method
+	})
+
+	return stats
+}
+
+// epoch returns the number of the slot `now` lies in.
+func (c *statsCollector) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(c.options.Window/time.Duration(c.options.Slots))
+}
+
+// record records a request served by `route` in `duration`, with the
+// status code `status`.
+func (c *statsCollector) record(route *Route, duration time.Duration, status int) {
+	c.Lock()
+	defer c.Unlock()
+
+	collected, ok := c.routes[route]
+
+	if !ok {
+		collected = &routeStats{slots: make([]statsSlot, c.options.Slots)}
+		c.routes[route] = collected
+	}
+
+	epoch := c.epoch(time.Now())
+	slot := &collected.slots[epoch%int64(len(collected.slots))]
+
+	if epoch != slot.epoch {
+		*slot = statsSlot{epoch: epoch}
+	}
+
+	slot.buckets[statsBucket(duration)]++
+	slot.count++
+	slot.sum += duration
+	slot.max = max(slot.max, duration)
+
+	if http.StatusInternalServerError <= status {
+		slot.errors++
+	}
+}
+
+// statsBucket returns the histogram bucket of `duration`.
+func statsBucket(duration time.Duration) int {
+	micros := float64(duration) / float64(time.Microsecond)
+
+	if 1 >= micros {
+		return 0
+	}
+
+	return min(statsBuckets-1, int(math.Ceil(2*math.Log2(micros))))
+}
+
+// percentile estimates the `p`th percentile of the slot's latencies as
+// the upper bound of the bucket it lies in, capped by the maximum.
+func (s *statsSlot) percentile(p float64) time.Duration {
+	rank := uint64(math.Ceil(p * float64(s.count)))
+
+	var seen uint64
+
+	for i, count := range s.buckets {
+		if seen += count; seen >= rank {
+			bound := time.Duration(math.Pow(2, float64(i)/2) * float64(time.Microsecond))
+			return min(bound, s.max)
+		}
+	}
+
+	return s.max
+}
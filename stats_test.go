@@ -0,0 +1,81 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestStats ensures latencies and errors are collected per Route.
+func TestStats(t *testing.T) {
+	router := NewRouter()
+	router.Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if "2" == ParamsFromContext(req.Context()).Get("id") {
+			time.Sleep(5 * time.Millisecond)
+			res.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/users/1"))
+
+	if nil != router.Stats() {
+		t.Fatal("Expected no statistics before CollectStats.")
+	}
+
+	router.CollectStats()
+
+	for _, path := range []string{"/users/1", "/users/1", "/users/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, path))
+	}
+
+	stats := router.Stats()
+
+	if 1 != len(stats) {
+		t.Fatalf("Expected statistics of one Route, got %+v.", stats)
+	}
+
+	if s := stats[0]; "/users/:id" != s.Path || GET != s.Method || 3 != s.Count || 1 != s.Errors {
+		t.Errorf("Expected three requests and an error, got %+v.", s)
+	}
+
+	if s := stats[0]; 5*time.Millisecond > s.Max || s.P50 > s.P99 || s.P99 > s.Max || 5*time.Millisecond > s.P99 {
+		t.Errorf("Expected consistent latencies, got %+v.", s)
+	}
+
+	res := httptest.NewRecorder()
+	router.MountPprof("/debug/pprof", nil).ServeHTTP(res, generateHttpRequest(GET, "/debug/pprof/stats"))
+
+	var served []RouteStats
+
+	if err := json.Unmarshal(res.Body.Bytes(), &served); nil != err || 0 == len(served) {
+		t.Errorf("Expected the statistics to be served, got %q (%v).", res.Body.String(), err)
+	}
+}
+
+// TestStatsWindow ensures statistics expire with their slots.
+func TestStatsWindow(t *testing.T) {
+	collector := &statsCollector{options: StatsOptions{Window: time.Hour, Slots: 2}, routes: make(map[*Route]*routeStats)}
+	route := NewRoute("/", false)
+	collector.record(route, time.Millisecond, http.StatusOK)
+
+	collected := collector.routes[route]
+	collected.slots[collector.epoch(time.Now())%2].epoch -= 2
+
+	router := NewRouter()
+	router.stats = collector
+
+	if stats := router.Stats(); 0 != len(stats) {
+		t.Errorf("Expected expired statistics to be dropped, got %+v.", stats)
+	}
+}
+
+// TestStatsBucket ensures latencies are bucketed by powers of √2.
+func TestStatsBucket(t *testing.T) {
+	for duration, expected := range map[time.Duration]int{0: 0, time.Microsecond: 0, 2 * time.Microsecond: 2, 3 * time.Microsecond: 4, time.Hour: statsBuckets - 1} {
+		if bucket := statsBucket(duration); expected != bucket {
+			t.Errorf("Expected %v in bucket %d, got %d.", duration, expected, bucket)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NDJSONContentType is the media type of newline delimited JSON.
+const NDJSONContentType = "application/x-ndjson"
+
+// Streamer writes a response in chunks, flushing each to the client as
+// it is written, until the client disconnects. Its writes are
+// serialized, so keep-alive comments can be injected between them. See
+// Stream.
+type Streamer struct {
+	mu         sync.Mutex
+	res        http.ResponseWriter
+	controller *http.ResponseController
+	ctx        context.Context
+	err        error
+}
+
+// NDJSONWriter writes values as newline delimited JSON, one per line.
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+// NewStreamer creates a Streamer writing the response to `req` to
+// `res`.
+func NewStreamer(res http.ResponseWriter, req *http.Request) *Streamer {
+	return &Streamer{res: res, controller: http.NewResponseController(res), ctx: req.Context()}
+}
+
+// Stream calls `step` with a Streamer writing to `res` until it returns
+// false or the client disconnects, i.e.
+//
+//	err := dispatcher.Stream(res, req, func(w io.Writer) bool {
+//		line, ok := <-lines
+//		if ok {
+//			fmt.Fprintln(w, line)
+//		}
+//		return ok
+//	})
+//
+// The error returned is the first write error, or the request context's
+// error if the client disconnected.
+func Stream(res http.ResponseWriter, req *http.Request, step func(w io.Writer) bool) error {
+	streamer := NewStreamer(res, req)
+
+	for nil == streamer.Err() && step(streamer) {
+	}
+
+	return streamer.Err()
+}
+
+// Write writes `data` to the client and flushes it. Once the client
+// has disconnected, or a write failed, nothing more is written and the
+// error is returned.
+func (s *Streamer) Write(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failed(); nil != err {
+		return 0, err
+	}
+
+	n, err := s.res.Write(data)
+
+	if nil == err {
+		if err = s.controller.Flush(); errors.Is(err, http.ErrNotSupported) {
+			err = nil
+		}
+	}
+
+	if nil != err {
+		s.err = err
+	}
+
+	return n, err
+}
+
+// Done returns a channel closed when the client disconnects.
+func (s *Streamer) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Err returns the first write error, or the request context's error if
+// the client disconnected.
+func (s *Streamer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failed()
+}
+
+// failed returns the Streamer's error. The Streamer must be locked by
+// the caller.
+func (s *Streamer) failed() error {
+	if nil != s.err {
+		return s.err
+	}
+
+	return s.ctx.Err()
+}
+
+// KeepAlive writes `comment` every `interval` until the returned func
+// is called or the client disconnects, so proxies do not close idle
+// connections, i.e. `": keep-alive\n\n"` for Server-Sent Events or a
+// blank line for NDJSON:
+//
+//	stop := streamer.KeepAlive(15*time.Second, []byte("\n"))
+//	defer stop()
+//
+// The returned func waits for the last comment to be written.
+func (s *Streamer) KeepAlive(interval time.Duration, comment []byte) (stop func()) {
+	var (
+		stopped = make(chan struct{})
+		done    = make(chan struct{})
+		once    sync.Once
+	)
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Write(comment); nil != err {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(stopped) })
+		<-done
+	}
+}
+
+// NewNDJSONWriter creates an NDJSONWriter writing to `w`, i.e. a
+// Streamer so each value is flushed as it is written:
+//
+//	res.Header().Set("Content-Type", dispatcher.NDJSONContentType)
+//	values := dispatcher.NewNDJSONWriter(dispatcher.NewStreamer(res, req))
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &NDJSONWriter{encoder}
+}
+
+// Write writes `value` as a line of JSON.
+func (n *NDJSONWriter) Write(value any) error {
+	return n.encoder.Encode(value)
+}
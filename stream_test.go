@@ -0,0 +1,98 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStream ensures each write is flushed until `step` returns false.
+func TestStream(t *testing.T) {
+	res := httptest.NewRecorder()
+	lines := []string{"a\n", "b\n"}
+
+	err := Stream(res, generateHttpRequest(GET, "/"), func(w io.Writer) bool {
+		if 0 == len(lines) {
+			return false
+		}
+
+		io.WriteString(w, lines[0])
+		lines = lines[1:]
+		return true
+	})
+
+	if nil != err {
+		t.Errorf("Expected no error, got %v.", err)
+	}
+
+	if "a\nb\n" != res.Body.String() || !res.Flushed {
+		t.Errorf("Expected a flushed body, got %q (flushed %v).", res.Body.String(), res.Flushed)
+	}
+}
+
+// TestStreamDisconnect ensures streaming stops once the client
+// disconnects.
+func TestStreamDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := generateHttpRequest(GET, "/").WithContext(ctx)
+	res := httptest.NewRecorder()
+	steps := 0
+
+	err := Stream(res, req, func(w io.Writer) bool {
+		if steps++; 2 == steps {
+			cancel()
+		}
+
+		io.WriteString(w, "x")
+		return true
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v.", err)
+	}
+
+	if 2 != steps || "x" != res.Body.String() {
+		t.Errorf("Expected streaming to stop after disconnecting, got %d steps writing %q.", steps, res.Body.String())
+	}
+}
+
+// TestKeepAlive ensures comments are written while idle and stop being
+// written once stopped.
+func TestKeepAlive(t *testing.T) {
+	res := httptest.NewRecorder()
+	streamer := NewStreamer(res, generateHttpRequest(GET, "/"))
+	stop := streamer.KeepAlive(time.Millisecond, []byte(": keep-alive\n\n"))
+
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	stop()
+
+	written := res.Body.String()
+
+	if !strings.HasPrefix(written, ": keep-alive\n\n") {
+		t.Errorf("Expected keep-alive comments, got %q.", written)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if written != res.Body.String() {
+		t.Error("Expected no comments after stopping.")
+	}
+}
+
+// TestNDJSONWriter ensures values are written one per line.
+func TestNDJSONWriter(t *testing.T) {
+	res := httptest.NewRecorder()
+	values := NewNDJSONWriter(NewStreamer(res, generateHttpRequest(GET, "/")))
+
+	values.Write(map[string]int{"n": 1})
+	values.Write("<b>")
+
+	if expected := "{\"n\":1}\n\"<b>\"\n"; expected != res.Body.String() {
+		t.Errorf("Expected %q, got %q.", expected, res.Body.String())
+	}
+}
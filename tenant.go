@@ -0,0 +1,168 @@
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TenantResolver resolves the tenant a request is made for, returning
+// the tenant's ID, the path the request is routed with, which is its
+// own unless the tenant was given in it, and whether a tenant was
+// found.
+type TenantResolver func(req *http.Request) (tenant, path string, ok bool)
+
+// TenantOptions configures Tenants.
+type TenantOptions struct {
+	// Resolvers are tried in order until one finds the tenant of a
+	// request.
+	Resolvers []TenantResolver
+	// Known, if set, reports whether `tenant` exists. Requests for
+	// unknown tenants are served 404.
+	Known func(tenant string) bool
+	// Required serves requests no tenant was found for 404.
+	Required bool
+	// Routers maps tenants to the Routers serving their requests in
+	// place of the Router Tenants is registered with, i.e. for tenants
+	// with custom route tables. Other tenants share the Router's
+	// Routes.
+	Routers map[string]*Router
+}
+
+// tenantContextKey is the context key the tenant of a request is
+// stored under.
+type tenantContextKey struct{}
+
+// Tenants returns a HandlerWrapper resolving the tenant of each request
+// with TenantOptions.Resolvers, i.e.
+//
+//	router.RegisterWrapper(dispatcher.Tenants(dispatcher.TenantOptions{
+//		Resolvers: []dispatcher.TenantResolver{
+//			dispatcher.TenantFromSubdomain("example.com"),
+//			dispatcher.TenantFromHeader("X-Tenant"),
+//		},
+//		Known:    accounts.Exists,
+//		Required: true,
+//	}))
+//
+// The tenant is stored in the request's context, read back with
+// TenantFromContext.
+func Tenants(opts TenantOptions) HandlerWrapper {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			tenant, path, ok := resolveTenant(req, opts.Resolvers)
+
+			if (!ok && opts.Required) || (ok && nil != opts.Known && !opts.Known(tenant)) {
+				http.NotFound(res, req)
+				return
+			}
+
+			if !ok {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), tenantContextKey{}, tenant)
+			stripped := path != req.URL.Path
+
+			if _, ok := OriginalPathFromContext(ctx); stripped && !ok {
+				ctx = context.WithValue(ctx, originalPathContextKey{}, req.URL.Path)
+			}
+
+			routed := req.WithContext(ctx)
+
+			if stripped {
+				routed.URL = new(url.URL)
+				*routed.URL = *req.URL
+				routed.URL.Path, routed.URL.RawPath = path, ""
+			}
+
+			if router, ok := opts.Routers[tenant]; ok {
+				router.ServeHTTP(res, routed)
+				return
+			}
+
+			next.ServeHTTP(res, routed)
+		})
+	}
+}
+
+// TenantFromContext returns the tenant of the request whose context is
+// `ctx`, and whether one was resolved.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok
+}
+
+// TenantFromSubdomain returns a TenantResolver taking the tenant from
+// the subdomain of `domain` requests are made to, i.e. `acme` for
+// `acme.example.com`. The host is read with Host, so hosts forwarded
+// by trusted proxies are used. Deeper subdomains, i.e.
+// `www.acme.example.com`, name no tenant.
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+
+	return func(req *http.Request) (string, string, bool) {
+		host := strings.ToLower(Host(req))
+
+		if hostname, _, err := net.SplitHostPort(host); nil == err {
+			host = hostname
+		}
+
+		tenant, ok := strings.CutSuffix(host, suffix)
+
+		if !ok || "" == tenant || strings.Contains(tenant, ".") {
+			return "", req.URL.Path, false
+		}
+
+		return tenant, req.URL.Path, true
+	}
+}
+
+// TenantFromHeader returns a TenantResolver taking the tenant from the
+// request header `name`.
+func TenantFromHeader(name string) TenantResolver {
+	return func(req *http.Request) (string, string, bool) {
+		tenant := strings.TrimSpace(req.Header.Get(name))
+		return tenant, req.URL.Path, "" != tenant
+	}
+}
+
+// TenantFromPath returns a TenantResolver taking the tenant from the
+// first segment of the path of requests beneath `prefix`, i.e. `acme`
+// for `/t/acme/users` with the prefix `/t`. The tenant's segment and
+// the prefix are removed from the path requests are routed with, and
+// the original path is available with OriginalPathFromContext.
+func TenantFromPath(prefix string) TenantResolver {
+	prefix = strings.TrimSuffix("/"+strings.Trim(prefix, "/"), "/") + "/"
+
+	return func(req *http.Request) (string, string, bool) {
+		rest, ok := strings.CutPrefix(req.URL.Path, prefix)
+
+		if !ok {
+			return "", req.URL.Path, false
+		}
+
+		tenant, path, _ := strings.Cut(rest, "/")
+
+		if "" == tenant {
+			return "", req.URL.Path, false
+		}
+
+		return tenant, "/" + path, true
+	}
+}
+
+// resolveTenant returns the tenant found by the first of `resolvers`
+// finding one, and the path `req` is routed with.
+func resolveTenant(req *http.Request, resolvers []TenantResolver) (string, string, bool) {
+	for _, resolve := range resolvers {
+		if tenant, path, ok := resolve(req); ok {
+			return tenant, path, true
+		}
+	}
+
+	return "", req.URL.Path, false
+}
@@ -0,0 +1,57 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTenants ensures tenants are resolved by subdomain, header and
+// path, and served by their own Routers if they have them.
+func TestTenants(t *testing.T) {
+	echo := func(table string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			tenant, _ := TenantFromContext(req.Context())
+			original, _ := OriginalPathFromContext(req.Context())
+			res.Write([]byte(table + " " + tenant + " " + original))
+		})
+	}
+
+	router := NewRouter().Get("/users", echo("shared"))
+	router.RegisterWrapper(Tenants(TenantOptions{
+		Resolvers: []TenantResolver{
+			TenantFromSubdomain("example.com"),
+			TenantFromHeader("X-Tenant"),
+			TenantFromPath("/t"),
+		},
+		Known:    func(tenant string) bool { return "unknown" != tenant },
+		Required: true,
+		Routers:  map[string]*Router{"custom": NewRouter().Get("/users", echo("custom"))},
+	}))
+
+	for _, test := range []struct {
+		host, header, path string
+		expected           string
+	}{
+		{"acme.example.com:8080", "", "/users", "shared acme "},
+		{"localhost", "globex", "/users", "shared globex "},
+		{"localhost", "", "/t/initech/users", "shared initech /t/initech/users"},
+		{"custom.example.com", "", "/users", "custom custom "},
+		{"www.acme.example.com", "", "/users", "404 page not found\n"},
+		{"unknown.example.com", "", "/users", "404 page not found\n"},
+	} {
+		req := generateHttpRequest(GET, test.path)
+		req.Host = test.host
+
+		if "" != test.header {
+			req.Header.Set("X-Tenant", test.header)
+		}
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		if test.expected != res.Body.String() {
+			t.Errorf("Expected %s%s to serve %q, got %q.", test.host, test.path, test.expected, res.Body.String())
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerTimingHeader is the response header timing spans are reported
+// in.
+const ServerTimingHeader = "Server-Timing"
+
+// timings collects the timing spans of a request.
+type timings struct {
+	mu    sync.Mutex
+	start time.Time
+	spans []timingSpan
+}
+
+// timingSpan is a named duration reported in the ServerTimingHeader.
+type timingSpan struct {
+	name        string
+	description string
+	duration    time.Duration
+}
+
+// timingContextKey is the context key a request's timings are stored
+// under.
+type timingContextKey struct{}
+
+// timingWriter adds the ServerTimingHeader to a response before its
+// header is written.
+type timingWriter struct {
+	http.ResponseWriter
+	timings *timings
+	written bool
+}
+
+// quoteTiming escapes the quotes and backslashes of descriptions.
+var quoteTiming = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// ServerTiming returns a HandlerWrapper reporting the timing spans
+// added by Middleware and handlers with AddTiming and StartTiming in
+// the Server-Timing header, along with the total time spent before the
+// response header was written, so browser developer tools show where
+// the time serving a request went:
+//
+//	router.RegisterWrapper(dispatcher.ServerTiming())
+//
+// Spans ended after the header was written are not reported.
+func ServerTiming() HandlerWrapper {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			collected := &timings{start: time.Now()}
+			writer := &timingWriter{ResponseWriter: res, timings: collected}
+
+			next.ServeHTTP(writer, req.WithContext(context.WithValue(req.Context(), timingContextKey{}, collected)))
+			writer.writeTimings()
+		})
+	}
+}
+
+// AddTiming adds a span named `name`, lasting `duration`, to the
+// timings of the request whose context is `ctx`. `name` must be an
+// HTTP token, i.e. "db"; `description` may be empty. Without
+// ServerTiming, AddTiming does nothing.
+func AddTiming(ctx context.Context, name string, duration time.Duration, description string) {
+	collected, ok := ctx.Value(timingContextKey{}).(*timings)
+
+	if !ok {
+		return
+	}
+
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+
+	collected.spans = append(collected.spans, timingSpan{name, description, duration})
+}
+
+// StartTiming starts a span named `name` of the timings of the request
+// whose context is `ctx`, returning the func ending it, i.e.
+//
+//	defer dispatcher.StartTiming(req.Context(), "db")()
+func StartTiming(ctx context.Context, name string) (stop func()) {
+	start := time.Now()
+
+	return func() {
+		AddTiming(ctx, name, time.Since(start), "")
+	}
+}
+
+// header returns the value of the ServerTimingHeader for the spans
+// collected so far.
+func (t *timings) header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var built strings.Builder
+
+	for _, span := range append(t.spans, timingSpan{name: "total", duration: time.Since(t.start)}) {
+		if 0 < built.Len() {
+			built.WriteString(", ")
+		}
+
+		built.WriteString(span.name)
+		built.WriteString(";dur=")
+		built.WriteString(strconv.FormatFloat(float64(span.duration.Round(time.Microsecond))/float64(time.Millisecond), 'f', -1, 64))
+
+		if "" != span.description {
+			built.WriteString(`;desc="`)
+			built.WriteString(quoteTiming.Replace(span.description))
+			built.WriteString(`"`)
+		}
+	}
+
+	return built.String()
+}
+
+// writeTimings adds the ServerTimingHeader, unless the header was
+// already written.
+func (w *timingWriter) writeTimings() {
+	if w.written {
+		return
+	}
+
+	w.written = true
+	w.Header().Add(ServerTimingHeader, w.timings.header())
+}
+
+// WriteHeader adds the ServerTimingHeader and writes the header.
+func (w *timingWriter) WriteHeader(status int) {
+	w.writeTimings()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write adds the ServerTimingHeader before the header is first
+// written, and writes `data`.
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.writeTimings()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush adds the ServerTimingHeader before the header is first
+// written, and flushes the response, if supported.
+func (w *timingWriter) Flush() {
+	w.writeTimings()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,47 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestServerTiming ensures spans ended before the header is written are
+// reported with the total.
+func TestServerTiming(t *testing.T) {
+	router := NewRouter()
+	router.RegisterWrapper(ServerTiming())
+	router.Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		AddTiming(req.Context(), "db", 1500*time.Microsecond, `Query "users"`)
+		StartTiming(req.Context(), "render")()
+
+		res.Write([]byte("ok"))
+		AddTiming(req.Context(), "late", time.Millisecond, "")
+	}))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/"))
+
+	expected := regexp.MustCompile(`^db;dur=1\.5;desc="Query \\"users\\"", render;dur=[0-9.]+, total;dur=[0-9.]+$`)
+
+	if header := res.Header().Get(ServerTimingHeader); !expected.MatchString(header) {
+		t.Errorf("Expected the spans in the Server-Timing header, got %q.", header)
+	}
+}
+
+// TestServerTimingUnwritten ensures responses whose handlers write
+// nothing are reported too, and that AddTiming is a no-op without
+// ServerTiming.
+func TestServerTimingUnwritten(t *testing.T) {
+	handler := ServerTiming()(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, generateHttpRequest(GET, "/"))
+
+	if header := res.Header().Get(ServerTimingHeader); !regexp.MustCompile(`^total;dur=`).MatchString(header) {
+		t.Errorf("Expected the total in the Server-Timing header, got %q.", header)
+	}
+
+	AddTiming(generateHttpRequest(GET, "/").Context(), "db", time.Millisecond, "")
+}
@@ -0,0 +1,195 @@
+package dispatcher
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RouteTraceHeader is the response header a request's RouteTrace is
+// reported in.
+const RouteTraceHeader = "X-Route-Trace"
+
+// TraceResult is the outcome of testing a Route against a request.
+type TraceResult string
+
+// Outcomes of testing Routes against traced requests.
+const (
+	// TraceMatched is the Route serving the request.
+	TraceMatched TraceResult = "matched"
+	// TracePathMismatch is a Route not matching the request's path.
+	TracePathMismatch TraceResult = "path mismatch"
+	// TraceConstraintMismatch is a Route matching the request's path
+	// only if its parameters' patterns, i.e. `:id(\d+)`, are ignored.
+	TraceConstraintMismatch TraceResult = "constraint mismatch"
+	// TraceMethodMismatch is a Route matching the request's path that
+	// was registered for another method.
+	TraceMethodMismatch TraceResult = "method mismatch"
+	// TraceInactive is a Route matching the request that is scheduled
+	// to be inactive at the time.
+	TraceInactive TraceResult = "inactive"
+)
+
+// TraceOptions configures TraceRouting.
+type TraceOptions struct {
+	// Header, if set, is the request header enabling tracing for the
+	// requests carrying it, i.e. RouteTraceHeader.
+	Header string
+	// Always traces every request.
+	Always bool
+	// Log, if set, is called with the RouteTrace of each traced
+	// request once it was served.
+	Log func(trace *RouteTrace)
+}
+
+// RouteTrace records how the Router routed a request.
+type RouteTrace struct {
+	Method string
+	Path   string
+	// Middleware lists the Middleware that ran, by name, or by their
+	// position, i.e. `#2`, if they were not named.
+	Middleware []string
+	// ServedBy is the Middleware serving the request, if any.
+	ServedBy string
+	// Resolved is the path an API version resolved the request to, if
+	// it matched no Route as is.
+	Resolved string
+	// Routes are the Routes tested against the request, up to the one
+	// matching it, and those registered for other methods matching its
+	// path.
+	Routes []TraceStep
+	log    func(trace *RouteTrace)
+}
+
+// TraceStep is the outcome of testing a Route against a request.
+type TraceStep struct {
+	Method string
+	Path   string
+	Result TraceResult
+}
+
+// relaxConstraints matches the patterns of parameters, i.e. `(\d+)` of
+// `:id(\d+)`.
+var relaxConstraints = regexp.MustCompile(`(:\w+)\(.*?\)`)
+
+// TraceRouting makes the Router record how it routes requests: the
+// Middleware that ran, the Routes tested and why they failed to match.
+// The RouteTrace of a request is passed to TraceOptions.Log and, unless
+// Middleware served it, reported in its RouteTraceHeader response
+// header, i.e.
+//
+//	router.TraceRouting(dispatcher.TraceOptions{Header: dispatcher.RouteTraceHeader})
+//
+// traces requests sent with an X-Route-Trace header. Traces expose the
+// route table, so tracing is meant for debugging.
+func (r *Router) TraceRouting(opts TraceOptions) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.tracing = &opts
+	return r
+}
+
+// String formats the RouteTrace as reported in the RouteTraceHeader,
+// i.e. `middleware=auth,#2; GET /users/:id(\d+) constraint mismatch`.
+func (t *RouteTrace) String() string {
+	parts := []string{"middleware=" + strings.Join(t.Middleware, ",")}
+
+	if "" != t.ServedBy {
+		parts = append(parts, "served by "+t.ServedBy)
+	}
+
+	if "" != t.Resolved {
+		parts = append(parts, "resolved "+t.Resolved)
+	}
+
+	for _, step := range t.Routes {
+		parts = append(parts, step.Method+" "+step.Path+" "+string(step.Result))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// startTrace returns a RouteTrace for `req` if it is to be traced, or
+// nil.
+func (r *Router) startTrace(req *http.Request) *RouteTrace {
+	r.Lock()
+	tracing := r.tracing
+	r.Unlock()
+
+	if nil == tracing || !(tracing.Always || ("" != tracing.Header && "" != req.Header.Get(tracing.Header))) {
+		return nil
+	}
+
+	return &RouteTrace{Method: req.Method, Path: req.URL.Path, log: tracing.Log}
+}
+
+// ranMiddleware records the Middleware at `index`, named `name`.
+func (t *RouteTrace) ranMiddleware(index int, name string) {
+	if nil == t {
+		return
+	}
+
+	if "" == name {
+		name = "#" + strconv.Itoa(index+1)
+	}
+
+	t.Middleware = append(t.Middleware, name)
+}
+
+// traceRoutes records the outcome of testing the Router's Routes
+// against the traced request, resolved to `resolved`.
+func (r *Router) traceRoutes(t *RouteTrace, resolved string) {
+	if resolved != t.Path {
+		t.Resolved = resolved
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	method := strings.ToUpper(t.Method)
+
+	for _, m := range supportedMethods {
+		for _, registered := range r.dispatcher[m] {
+			route := registered.Route
+			matched := route.match(resolved, nil)
+
+			switch {
+			case m != method:
+				if matched {
+					t.Routes = append(t.Routes, TraceStep{m, route.path, TraceMethodMismatch})
+				}
+			case matched && !route.activeNow():
+				t.Routes = append(t.Routes, TraceStep{m, route.path, TraceInactive})
+				matched = false
+			case matched:
+				t.Routes = append(t.Routes, TraceStep{m, route.path, TraceMatched})
+			case relaxConstraints.MatchString(route.path) && NewRoute(relaxConstraints.ReplaceAllString(route.path, "$1"), route.strict).match(resolved, nil):
+				t.Routes = append(t.Routes, TraceStep{m, route.path, TraceConstraintMismatch})
+			default:
+				t.Routes = append(t.Routes, TraceStep{m, route.path, TracePathMismatch})
+			}
+
+			if m == method && matched {
+				// Routes registered later are not tested.
+				break
+			}
+		}
+	}
+}
+
+// report sets the RouteTraceHeader of the response to the traced
+// request.
+func (t *RouteTrace) report(res http.ResponseWriter) {
+	if nil != t {
+		res.Header().Set(RouteTraceHeader, t.String())
+	}
+}
+
+// done passes the RouteTrace to TraceOptions.Log, if set.
+func (t *RouteTrace) done() {
+	if nil != t && nil != t.log {
+		t.log(t)
+	}
+}
@@ -0,0 +1,89 @@
+package dispatcher
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// TraceContentType is the media type of the responses written by a
+// TraceResponder.
+const TraceContentType = "message/http"
+
+// DefaultTraceRedactedHeaders are the request headers a TraceResponder
+// leaves out of its responses by default, as they carry credentials a
+// cross-site tracing attack could otherwise read.
+var DefaultTraceRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+}
+
+// AllowTraceMethod allows TRACE Routes to be registered with the
+// Router. Routers ignore TRACE registrations by default, including
+// those of Match, so TRACE requests are answered with the built-in 405
+// response or the 404 response when no other method matches the path,
+// as most deployments need to show TRACE is off. Routes registered
+// before the call are not affected, i.e.
+//
+//	router.AllowTraceMethod().Trace("/*", dispatcher.TraceResponder())
+func (r *Router) AllowTraceMethod() *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.traceAllowed = true
+	return r
+}
+
+// registers reports whether Routes for `method` may be registered with
+// the Router. The Router must be locked by the caller.
+func (r *Router) registers(method string) bool {
+	return TRACE != method || r.traceAllowed
+}
+
+// TraceResponder returns a handler answering TRACE requests as RFC 9110
+// describes: the request line and headers are echoed back as the body
+// of a TraceContentType response. DefaultTraceRedactedHeaders and the
+// headers `redact` are left out of the echo. Requests of other methods
+// are answered with 405 Method Not Allowed.
+func TraceResponder(redact ...string) http.Handler {
+	exclude := make(map[string]bool, len(DefaultTraceRedactedHeaders)+len(redact))
+
+	for _, names := range [][]string{DefaultTraceRedactedHeaders, redact} {
+		for _, name := range names {
+			exclude[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if TRACE != req.Method {
+			res.Header().Set("Allow", TRACE)
+			http.Error(res, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		uri := req.RequestURI
+
+		if "" == uri {
+			uri = req.URL.RequestURI()
+		}
+
+		var body bytes.Buffer
+
+		body.WriteString(req.Method + " " + uri + " " + req.Proto + "\r\n")
+
+		if "" != req.Host {
+			body.WriteString("Host: " + req.Host + "\r\n")
+		}
+
+		req.Header.WriteSubset(&body, exclude)
+		body.WriteString("\r\n")
+
+		res.Header().Set("Content-Type", TraceContentType)
+		res.Header().Set("Cache-Control", "no-store")
+		res.WriteHeader(http.StatusOK)
+		res.Write(body.Bytes())
+	})
+}
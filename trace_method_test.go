@@ -0,0 +1,83 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTraceDisabledByDefault ensures TRACE Routes are only registered
+// once the Router allows them.
+func TestTraceDisabledByDefault(t *testing.T) {
+	counter := 0
+
+	router := NewRouter().
+		Match("/any", generateCountableHandler(&counter)).
+		Trace("/trace", generateCountableHandler(&counter))
+
+	for _, path := range []string{"/any", "/trace"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(TRACE, path))
+
+		if 0 != counter {
+			t.Fatalf("Expected TRACE %s not to be routed, counter set to %d.", path, counter)
+		}
+
+		if strings.Contains(res.Header().Get("Allow"), TRACE) {
+			t.Errorf("Expected TRACE to be left out of the Allow header, got %q.", res.Header().Get("Allow"))
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(TRACE, "/any"))
+
+	if http.StatusMethodNotAllowed != res.Code {
+		t.Errorf("Expected TRACE /any to be answered with 405, got %d.", res.Code)
+	}
+
+	router.AllowTraceMethod().Trace("/trace", generateCountableHandler(&counter))
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(TRACE, "/trace"))
+
+	if 1 != counter {
+		t.Errorf("Expected TRACE Route registered once allowed to be routed, counter set to %d.", counter)
+	}
+
+	if !router.Clone().traceAllowed {
+		t.Error("Expected Clone to keep TRACE allowed.")
+	}
+}
+
+// TestTraceResponder ensures TRACE requests are echoed with sensitive
+// headers redacted.
+func TestTraceResponder(t *testing.T) {
+	router := NewRouter().
+		AllowTraceMethod().
+		Trace("/*", TraceResponder("X-Session"))
+
+	req := httptest.NewRequest(TRACE, "/echo?q=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Session", "secret")
+	req.Header.Set("X-Request-Id", "42")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if http.StatusOK != res.Code || TraceContentType != res.Header().Get("Content-Type") {
+		t.Fatalf("Expected 200 %s response, got %d %q.", TraceContentType, res.Code, res.Header().Get("Content-Type"))
+	}
+
+	expected := "TRACE /echo?q=1 HTTP/1.1\r\nHost: example.com\r\nX-Request-Id: 42\r\n\r\n"
+
+	if expected != res.Body.String() {
+		t.Errorf("Expected echo %q, got %q.", expected, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	TraceResponder().ServeHTTP(res, httptest.NewRequest(GET, "/echo", nil))
+
+	if http.StatusMethodNotAllowed != res.Code || TRACE != res.Header().Get("Allow") {
+		t.Errorf("Expected GET to be answered with 405 allowing TRACE, got %d %q.", res.Code, res.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,59 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTraceRouting ensures traced requests report the Middleware that
+// ran and the outcome of testing each Route.
+func TestTraceRouting(t *testing.T) {
+	var logged *RouteTrace
+
+	handler := http.NotFoundHandler()
+	router := NewRouter()
+	router.RegisterNamedMiddleware("auth", MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+		return "/private" == req.URL.Path
+	}))
+	router.RegisterMiddleware(MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool { return false }))
+	router.Get(`/users/:id(\d+)`, handler).Get("/users/:name", handler).Get("/users/*", handler).Post("/users/:id", handler).Get("/posts", handler)
+	router.TraceRouting(TraceOptions{Header: RouteTraceHeader, Log: func(trace *RouteTrace) { logged = trace }})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/users/jane"))
+
+	if nil != logged || "" != res.Header().Get(RouteTraceHeader) {
+		t.Fatal("Expected requests without the trace header not to be traced.")
+	}
+
+	req := generateHttpRequest(GET, "/users/jane")
+	req.Header.Set(RouteTraceHeader, "1")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	expected := `middleware=auth,#2; GET /users/:id(\d+) constraint mismatch; GET /users/:name matched; POST /users/:id method mismatch`
+
+	if header := res.Header().Get(RouteTraceHeader); expected != header {
+		t.Errorf("Expected the trace header %q, got %q.", expected, header)
+	}
+
+	if nil == logged || 3 != len(logged.Routes) || TraceMatched != logged.Routes[1].Result {
+		t.Errorf("Expected the trace to be logged, got %+v.", logged)
+	}
+
+	router.TraceRouting(TraceOptions{Always: true, Log: func(trace *RouteTrace) { logged = trace }})
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/private"))
+
+	if "auth" != logged.ServedBy || 0 != len(logged.Routes) {
+		t.Errorf("Expected the serving Middleware to be traced, got %+v.", logged)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/missing"))
+
+	for _, step := range logged.Routes {
+		if TracePathMismatch != step.Result {
+			t.Errorf("Expected every Route to miss, got %+v.", step)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package dispatcher
+
+import (
+	"net/http"
+)
+
+// The TrapFunc type is called with requests hitting a decoy Route
+// registered with the Router's Trap method.
+type TrapFunc func(req *http.Request)
+
+// OnTrap sets the callback invoked when a request hits a decoy Route
+// registered with Trap, i.e. to log the request or ban the client.
+func (r *Router) OnTrap(callback TrapFunc) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.trapCallback = callback
+	return r
+}
+
+// Trap registers decoy Routes for any supported HTTP method matching
+// each of the `paths` provided, such as `/wp-login.php` or `/.env`.
+// Requests hitting a decoy Route are passed to the callback set with
+// OnTrap and then served by the Router's not found handler, so they
+// are indistinguishable from a missing route to the client.
+func (r *Router) Trap(paths ...string) *Router {
+	var created []*Route
+
+	for _, path := range paths {
+		r.Match(path, http.HandlerFunc(r.serveTrap))
+		created = append(created, r.current...)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.current = created
+	return r
+}
+
+// serveTrap handles requests matching decoy Routes.
+func (r *Router) serveTrap(res http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	callback := r.trapCallback
+	r.Unlock()
+
+	if nil != callback {
+		callback(req)
+	}
+
+	r.serveUnmatched(res, req, nil)
+}
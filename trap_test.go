@@ -0,0 +1,29 @@
+package dispatcher
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestTrapRoute ensures requests hitting a decoy Route invoke the
+// trap callback and are served by the not found handler.
+func TestTrapRoute(t *testing.T) {
+	trapped, notFound := 0, 0
+
+	router := NewRouter().
+		NotFound(generateCountableHandler(&notFound)).
+		OnTrap(func(req *http.Request) { trapped += 1 }).
+		Trap("/wp-login.php", "/.env")
+
+	router.ServeHTTP(nil, generateHttpRequest(GET, "/.env"))
+	router.ServeHTTP(nil, generateHttpRequest(POST, "/wp-login.php"))
+	router.ServeHTTP(nil, generateHttpRequest(GET, "/unknown"))
+
+	if 2 != trapped {
+		t.Errorf("Expected trap callback to be called 2 times, was called %d times.", trapped)
+	}
+
+	if 3 != notFound {
+		t.Errorf("Expected not found handler to be called 3 times, was called %d times.", notFound)
+	}
+}
@@ -0,0 +1,178 @@
+package dispatcher
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTunnelDialTimeout is how long a tunnel waits to connect to the
+// requested address when TunnelOptions.DialTimeout is unset.
+const DefaultTunnelDialTimeout = 10 * time.Second
+
+// TunnelOptions configures the CONNECT tunnels served by a
+// TunnelHandler.
+type TunnelOptions struct {
+	// Allow lists the addresses tunnels may be opened to as `host:port`
+	// patterns, i.e. `db.internal:5432`. The host may be `*` or start
+	// with `*.` to match any subdomain, the port may be `*`, and
+	// patterns without a port allow port 443. No address is allowed by
+	// default.
+	Allow []string
+	// AllowFunc, if set, is called with requests for addresses not
+	// listed in Allow and reports whether the tunnel may be opened,
+	// i.e. to check the caller's identity.
+	AllowFunc func(req *http.Request, address string) bool
+	// DialTimeout is how long to wait to connect to the requested
+	// address. By default DefaultTunnelDialTimeout.
+	DialTimeout time.Duration
+	// Dial, if set, connects to the requested address instead of a
+	// net.Dialer.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Tunnel registers a Route serving HTTP CONNECT requests with a
+// TunnelHandler configured by `opts`, so the Router doubles as a
+// forward proxy restricted to the addresses `opts` allows, i.e. for
+// internal tooling:
+//
+//	router.Tunnel(dispatcher.TunnelOptions{Allow: []string{"*.internal:5432"}}).Name("tunnel")
+//
+// The Route matches requests in authority form, i.e. `CONNECT
+// db.internal:5432`, which carry no path.
+func (r *Router) Tunnel(opts TunnelOptions) *Router {
+	return r.Connect("", TunnelHandler(opts))
+}
+
+// TunnelHandler returns a handler answering HTTP CONNECT requests by
+// connecting to the requested address, hijacking the client's
+// connection and copying data both ways until either side closes it.
+// Addresses `opts` does not allow are answered with 403 Forbidden, and
+// addresses that can not be reached with 502 Bad Gateway. Tunnels can
+// only be opened over HTTP/1, and the handler returns once the tunnel
+// is closed.
+func TunnelHandler(opts TunnelOptions) http.Handler {
+	if 0 == opts.DialTimeout {
+		opts.DialTimeout = DefaultTunnelDialTimeout
+	}
+
+	if nil == opts.Dial {
+		opts.Dial = new(net.Dialer).DialContext
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if CONNECT != req.Method {
+			res.Header().Set("Allow", CONNECT)
+			http.Error(res, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if 1 != req.ProtoMajor {
+			http.Error(res, http.StatusText(http.StatusHTTPVersionNotSupported), http.StatusHTTPVersionNotSupported)
+			return
+		}
+
+		address := req.Host
+
+		if _, _, err := net.SplitHostPort(address); nil != err {
+			http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		if !opts.allows(req, address) {
+			http.Error(res, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), opts.DialTimeout)
+		upstream, err := opts.Dial(ctx, "tcp", address)
+		cancel()
+
+		if nil != err {
+			http.Error(res, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+
+		conn, buffer, err := http.NewResponseController(res).Hijack()
+
+		if nil != err {
+			upstream.Close()
+			http.Error(res, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); nil != err {
+			conn.Close()
+			upstream.Close()
+			return
+		}
+
+		// Data the client sent after the request may already be
+		// buffered.
+		tunnel(conn, io.MultiReader(io.LimitReader(buffer, int64(buffer.Reader.Buffered())), conn), upstream)
+	})
+}
+
+// allows reports whether a tunnel may be opened to `address` for
+// `req`.
+func (opts TunnelOptions) allows(req *http.Request, address string) bool {
+	host, port, _ := net.SplitHostPort(address)
+
+	for _, pattern := range opts.Allow {
+		if allowedAddress(pattern, strings.ToLower(host), port) {
+			return true
+		}
+	}
+
+	return nil != opts.AllowFunc && opts.AllowFunc(req, address)
+}
+
+// allowedAddress reports whether the `host` and `port` of an address
+// match the Allow pattern `pattern`.
+func allowedAddress(pattern, host, port string) bool {
+	allowedHost, allowedPort, err := net.SplitHostPort(pattern)
+
+	if nil != err {
+		allowedHost, allowedPort = pattern, "443"
+	}
+
+	if "*" != allowedPort && port != allowedPort {
+		return false
+	}
+
+	allowedHost = strings.ToLower(allowedHost)
+
+	if suffix, ok := strings.CutPrefix(allowedHost, "*"); ok {
+		return "" == suffix || strings.HasSuffix(host, suffix)
+	}
+
+	return host == allowedHost
+}
+
+// tunnel copies data from `client`, read through `reader`, to
+// `upstream` and back until either side is done, then closes both
+// connections.
+func tunnel(client net.Conn, reader io.Reader, upstream net.Conn) {
+	var once sync.Once
+
+	closeBoth := func() {
+		client.Close()
+		upstream.Close()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		io.Copy(upstream, reader)
+		once.Do(closeBoth)
+		close(done)
+	}()
+
+	io.Copy(client, upstream)
+	once.Do(closeBoth)
+	<-done
+}
@@ -0,0 +1,125 @@
+package dispatcher
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTunnel ensures CONNECT requests for allowed addresses are
+// tunneled and others refused.
+func TestTunnel(t *testing.T) {
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	defer upstream.Close()
+
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+
+			if nil != err {
+				return
+			}
+
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(upstream.Addr().String())
+
+	server := httptest.NewServer(NewRouter().
+		Get("/", generateCountableHandler(new(int))).
+		Tunnel(TunnelOptions{
+			Allow: []string{"127.0.0.1:" + port},
+			AllowFunc: func(req *http.Request, address string) bool {
+				return "let-me-in" == req.Header.Get("Proxy-Authorization")
+			},
+		}))
+
+	defer server.Close()
+
+	connect := func(address, authorization string) (net.Conn, *bufio.Reader, int) {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		io.WriteString(conn, "CONNECT "+address+" HTTP/1.1\r\nHost: "+address+"\r\nProxy-Authorization: "+authorization+"\r\n\r\nping")
+
+		reader := bufio.NewReader(conn)
+		res, err := http.ReadResponse(reader, &http.Request{Method: CONNECT})
+
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		return conn, reader, res.StatusCode
+	}
+
+	conn, reader, code := connect(upstream.Addr().String(), "")
+	defer conn.Close()
+
+	if http.StatusOK != code {
+		t.Fatalf("Expected tunnel to allowed address to be opened, got %d.", code)
+	}
+
+	io.WriteString(conn, "pong")
+	echoed := make([]byte, 8)
+
+	if _, err := io.ReadFull(reader, echoed); nil != err || "pingpong" != string(echoed) {
+		t.Errorf("Expected data to be tunneled both ways, got %q (%v).", echoed, err)
+	}
+
+	for address, expected := range map[string]int{
+		"example.com:443":   http.StatusForbidden,
+		"127.0.0.1:1":       http.StatusForbidden,
+		"example.com":       http.StatusBadRequest,
+		"127.0.0.1:" + port: http.StatusOK,
+	} {
+		authorization := ""
+
+		if http.StatusOK == expected {
+			// Allowed by AllowFunc rather than the Allow list.
+			address, authorization = "localhost:"+port, "let-me-in"
+		}
+
+		conn, _, code := connect(address, authorization)
+		conn.Close()
+
+		if expected != code {
+			t.Errorf("Expected CONNECT %s to be answered with %d, got %d.", address, expected, code)
+		}
+	}
+}
+
+// TestAllowedAddress ensures Allow patterns match hosts and ports.
+func TestAllowedAddress(t *testing.T) {
+	for _, test := range []struct {
+		pattern, host, port string
+		expected            bool
+	}{
+		{"db.internal:5432", "db.internal", "5432", true},
+		{"db.internal:5432", "db.internal", "5433", false},
+		{"DB.internal:*", "db.internal", "22", true},
+		{"*.internal:22", "ssh.internal", "22", true},
+		{"*.internal:22", "internal", "22", false},
+		{"example.com", "example.com", "443", true},
+		{"example.com", "example.com", "80", false},
+		{"*:*", "anything", "1", true},
+	} {
+		if test.expected != allowedAddress(test.pattern, test.host, test.port) {
+			t.Errorf("Expected pattern %s to match %s:%s: %t.", test.pattern, test.host, test.port, test.expected)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package dispatcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultMaxUploadSize is the number of bytes read from an upload
+// request's body when UploadOptions.MaxTotalSize is unset.
+const DefaultMaxUploadSize = 32 << 20
+
+// sniffLength is the number of bytes of a file inspected to detect
+// its content type.
+const sniffLength = 512
+
+// UploadOptions configures how uploads are received.
+type UploadOptions struct {
+	// MaxFileSize limits the size of each file. If zero or negative,
+	// files are limited only by MaxTotalSize.
+	MaxFileSize int64
+	// MaxTotalSize limits the size of the whole request body. If zero,
+	// DefaultMaxUploadSize is used; if negative, the body is not
+	// limited.
+	MaxTotalSize int64
+	// MaxFiles limits the number of files. If zero, any number is
+	// accepted.
+	MaxFiles int
+	// AllowedTypes lists the content types files may have, as detected
+	// from their contents rather than declared by the client. Types
+	// may end in a wildcard, i.e. `image/*`. If empty, any type is
+	// accepted.
+	AllowedTypes []string
+}
+
+// Upload describes a file received by StreamUploads or SaveUploads.
+type Upload struct {
+	// Field is the name of the form field the file was sent in.
+	Field string
+	// Filename is the name of the file as sent by the client, which
+	// should not be trusted as a path.
+	Filename string
+	// Type is the content type detected from the file's contents.
+	Type string
+	// Size is the number of bytes read from the file.
+	Size int64
+	// Path is the path of the temporary file written by SaveUploads.
+	Path string
+}
+
+// StreamUploads reads a multipart request body part by part, calling
+// `fn` with each file and a reader of its contents, so files can be
+// streamed to their destination (i.e. with io.Copy) without being
+// held in memory. Other form values are collected and returned. Limits
+// and allowed types are enforced as the body is read; violations are
+// reported as a *BindError with status 413 Request Entity Too Large or
+// 415 Unsupported Media Type, as are errors returned by `fn` reading a
+// file which is too large.
+func StreamUploads(req *http.Request, opts UploadOptions, fn func(upload *Upload, file io.Reader) error) (url.Values, error) {
+	if 0 == opts.MaxTotalSize {
+		opts.MaxTotalSize = DefaultMaxUploadSize
+	}
+
+	if 0 < opts.MaxTotalSize {
+		req.Body = http.MaxBytesReader(nil, req.Body, opts.MaxTotalSize)
+	}
+
+	reader, err := req.MultipartReader()
+
+	if nil != err {
+		return nil, &BindError{Status: http.StatusBadRequest, Err: err}
+	}
+
+	var (
+		values = make(url.Values)
+		files  int
+	)
+
+	for {
+		part, err := reader.NextPart()
+
+		if io.EOF == err {
+			return values, nil
+		} else if nil != err {
+			return nil, decodeError(err)
+		}
+
+		if "" == part.FileName() {
+			value, err := io.ReadAll(io.LimitReader(part, DefaultMaxBodySize+1))
+
+			if nil != err {
+				return nil, decodeError(err)
+			} else if DefaultMaxBodySize < len(value) {
+				return nil, &BindError{Status: http.StatusRequestEntityTooLarge, Field: part.FormName(), Err: fmt.Errorf("value exceeds %d bytes", DefaultMaxBodySize)}
+			}
+
+			values.Add(part.FormName(), string(value))
+			continue
+		}
+
+		if files++; 0 < opts.MaxFiles && files > opts.MaxFiles {
+			return nil, &BindError{Status: http.StatusRequestEntityTooLarge, Field: part.FormName(), Err: fmt.Errorf("more than %d files", opts.MaxFiles)}
+		}
+
+		if err := streamUpload(part.FormName(), part.FileName(), part, opts, fn); nil != err {
+			return nil, err
+		}
+	}
+}
+
+// streamUpload detects the content type of `part`, checks it is
+// allowed and calls `fn` with the part limited to opts.MaxFileSize.
+func streamUpload(field, filename string, part io.Reader, opts UploadOptions, fn func(*Upload, io.Reader) error) error {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(part, head)
+
+	if nil != err && io.EOF != err && io.ErrUnexpectedEOF != err {
+		return decodeError(err)
+	}
+
+	upload := &Upload{Field: field, Filename: filename}
+	upload.Type, _, _ = strings.Cut(http.DetectContentType(head[:n]), ";")
+
+	if !allowedUploadType(upload.Type, opts.AllowedTypes) {
+		return &BindError{Status: http.StatusUnsupportedMediaType, Field: field, Err: fmt.Errorf("file type %v is not allowed", upload.Type)}
+	}
+
+	file := &uploadReader{Reader: io.MultiReader(bytes.NewReader(head[:n]), part), upload: upload, limit: opts.MaxFileSize}
+
+	if err := fn(upload, file); nil != err {
+		if errors.Is(err, errUploadTooLarge) {
+			return &BindError{Status: http.StatusRequestEntityTooLarge, Field: field, Err: fmt.Errorf("file exceeds %d bytes", opts.MaxFileSize)}
+		}
+
+		var bindError *BindError
+
+		if errors.As(err, &bindError) {
+			return err
+		}
+
+		return decodeError(err)
+	}
+
+	return nil
+}
+
+// SaveUploads streams each file of a multipart request body to a
+// temporary file in `dir` (os.TempDir if empty), returning the
+// Uploads with their paths and the other form values. Callers are
+// responsible for moving or removing the files; if an error occurs,
+// files already written are removed.
+func SaveUploads(req *http.Request, dir string, opts UploadOptions) ([]*Upload, url.Values, error) {
+	var uploads []*Upload
+
+	values, err := StreamUploads(req, opts, func(upload *Upload, file io.Reader) error {
+		temporary, err := os.CreateTemp(dir, "upload-*")
+
+		if nil != err {
+			return err
+		}
+
+		upload.Path = temporary.Name()
+		uploads = append(uploads, upload)
+
+		_, err = io.Copy(temporary, file)
+
+		if closeErr := temporary.Close(); nil == err {
+			err = closeErr
+		}
+
+		return err
+	})
+
+	if nil != err {
+		for _, upload := range uploads {
+			os.Remove(upload.Path)
+		}
+
+		return nil, nil, err
+	}
+
+	return uploads, values, nil
+}
+
+// allowedUploadType reports whether `typ` matches one of `allowed`.
+func allowedUploadType(typ string, allowed []string) bool {
+	if 0 == len(allowed) {
+		return true
+	}
+
+	for _, pattern := range allowed {
+		if prefix, found := strings.CutSuffix(pattern, "/*"); found && strings.HasPrefix(typ, prefix+"/") {
+			return true
+		} else if strings.EqualFold(pattern, typ) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errUploadTooLarge is returned when reading more than
+// UploadOptions.MaxFileSize bytes of a file.
+var errUploadTooLarge = errors.New("dispatcher: upload exceeds the maximum file size")
+
+// uploadReader counts the bytes read from a file into its Upload,
+// failing once more than `limit` bytes are read.
+type uploadReader struct {
+	io.Reader
+	upload *Upload
+	limit  int64
+}
+
+// Read reads from the file, enforcing the size limit.
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.Reader.Read(p)
+	u.upload.Size += int64(n)
+
+	if 0 < u.limit && u.upload.Size > u.limit {
+		return n, errUploadTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,111 @@
+package dispatcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// generateUploadRequest creates a multipart request containing the
+// field `name` and the files `files`, keyed by filename.
+func generateUploadRequest(files map[string]string) *http.Request {
+	var (
+		body   bytes.Buffer
+		writer = multipart.NewWriter(&body)
+	)
+
+	writer.WriteField("name", "Ada")
+
+	for filename, contents := range files {
+		part, _ := writer.CreateFormFile("files", filename)
+		part.Write([]byte(contents))
+	}
+
+	writer.Close()
+	return generateBindRequest(writer.FormDataContentType(), body.String())
+}
+
+// TestStreamUploads ensures files are streamed with their detected
+// types alongside form values.
+func TestStreamUploads(t *testing.T) {
+	var (
+		req      = generateUploadRequest(map[string]string{"notes.txt": "hello world"})
+		received bytes.Buffer
+		uploads  []Upload
+	)
+
+	values, err := StreamUploads(req, UploadOptions{}, func(upload *Upload, file io.Reader) error {
+		_, err := io.Copy(&received, file)
+		uploads = append(uploads, *upload)
+		return err
+	})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if "Ada" != values.Get("name") || "hello world" != received.String() {
+		t.Errorf("Expected value and file contents, got %v and %q.", values, received.String())
+	}
+
+	if 1 != len(uploads) || "notes.txt" != uploads[0].Filename || "text/plain" != uploads[0].Type || 11 != uploads[0].Size {
+		t.Errorf("Expected the upload to be described, got %+v.", uploads)
+	}
+}
+
+// TestStreamUploadsLimits ensures size, count and type limits are
+// enforced.
+func TestStreamUploadsLimits(t *testing.T) {
+	tests := []struct {
+		files   map[string]string
+		options UploadOptions
+		status  int
+	}{
+		{map[string]string{"big.txt": strings.Repeat("a", 1024)}, UploadOptions{MaxFileSize: 100}, http.StatusRequestEntityTooLarge},
+		{map[string]string{"big.txt": strings.Repeat("a", 1024)}, UploadOptions{MaxTotalSize: 100}, http.StatusRequestEntityTooLarge},
+		{map[string]string{"a.txt": "a", "b.txt": "b"}, UploadOptions{MaxFiles: 1}, http.StatusRequestEntityTooLarge},
+		{map[string]string{"fake.png": "plain text"}, UploadOptions{AllowedTypes: []string{"image/*"}}, http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		var bindError *BindError
+
+		_, err := StreamUploads(generateUploadRequest(test.files), test.options, func(upload *Upload, file io.Reader) error {
+			_, err := io.Copy(io.Discard, file)
+			return err
+		})
+
+		if !errors.As(err, &bindError) || test.status != bindError.StatusCode() {
+			t.Errorf("Expected %d for %+v, got %v.", test.status, test.options, err)
+		}
+	}
+}
+
+// TestSaveUploads ensures files are written to temporary files.
+func TestSaveUploads(t *testing.T) {
+	dir := t.TempDir()
+	uploads, values, err := SaveUploads(generateUploadRequest(map[string]string{"notes.txt": "hello world"}), dir, UploadOptions{})
+
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if 1 != len(uploads) || "Ada" != values.Get("name") {
+		t.Fatalf("Expected one upload and the name value, got %+v and %v.", uploads, values)
+	}
+
+	if contents, err := os.ReadFile(uploads[0].Path); nil != err || "hello world" != string(contents) {
+		t.Errorf("Expected the file to be saved, got %q (%v).", contents, err)
+	}
+
+	_, _, err = SaveUploads(generateUploadRequest(map[string]string{"big.txt": strings.Repeat("a", 1024)}), dir, UploadOptions{MaxFileSize: 100})
+
+	if entries, _ := os.ReadDir(dir); nil == err || 1 != len(entries) {
+		t.Errorf("Expected failed uploads to be removed, got %d files (%v).", len(entries), err)
+	}
+}
@@ -0,0 +1,110 @@
+package dispatcher
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Validator is implemented by bound structs validating themselves.
+type Validator interface {
+	Validate() error
+}
+
+// ValidatorFunc validates a value bound by Bind, i.e. by adapting a
+// third party validation package.
+type ValidatorFunc func(v any) error
+
+// validators is the registry of ValidatorFuncs run by Bind.
+var validators struct {
+	sync.RWMutex
+	registered []ValidatorFunc
+}
+
+// RegisterValidator registers `validator` to be run on every value
+// bound by Bind, after the value's own Validate method.
+func RegisterValidator(validator ValidatorFunc) {
+	validators.Lock()
+	defer validators.Unlock()
+
+	validators.registered = append(validators.registered, validator)
+}
+
+// FieldError describes why a single field failed validation.
+type FieldError struct {
+	Field   string `json:"field,omitempty" xml:"field,attr,omitempty"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+// ValidationError is returned by Bind when a bound value fails
+// validation, listing the failure of each field.
+type ValidationError struct {
+	Fields []FieldError `json:"errors" xml:"error"`
+}
+
+// Add records that `field` failed validation with `message`, returning
+// the ValidationError for chaining.
+func (e *ValidationError) Add(field, message string) *ValidationError {
+	e.Fields = append(e.Fields, FieldError{field, message})
+	return e
+}
+
+// Err returns the ValidationError, or nil if no failures were added,
+// so Validate methods can end with `return errs.Err()`.
+func (e *ValidationError) Err() error {
+	if 0 == len(e.Fields) {
+		return nil
+	}
+
+	return e
+}
+
+// Error lists the field failures.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+
+	for i, field := range e.Fields {
+		if messages[i] = field.Message; "" != field.Field {
+			messages[i] = field.Field + ": " + field.Message
+		}
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// StatusCode returns 422 Unprocessable Entity.
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// validate runs the Validate method of `v`, if any, and the registered
+// ValidatorFuncs, stopping at the first failure. Failures which are
+// not ValidationErrors are wrapped in one.
+func validate(v any) error {
+	validators.RLock()
+	registered := validators.registered
+	validators.RUnlock()
+
+	var err error
+
+	if validator, ok := v.(Validator); ok {
+		err = validator.Validate()
+	}
+
+	for _, validator := range registered {
+		if nil != err {
+			break
+		}
+
+		err = validator(v)
+	}
+
+	var validationError *ValidationError
+
+	if nil == err || errors.As(err, &validationError) {
+		return err
+	}
+
+	return new(ValidationError).Add("", err.Error())
+}
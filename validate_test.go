@@ -0,0 +1,98 @@
+package dispatcher
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// validatedUser validates itself.
+type validatedUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (u validatedUser) Validate() error {
+	errs := new(ValidationError)
+
+	if "" == u.Name {
+		errs.Add("name", "is required")
+	}
+
+	if !strings.Contains(u.Email, "@") {
+		errs.Add("email", "must be an email address")
+	}
+
+	return errs.Err()
+}
+
+// TestBindValidate ensures bound values implementing Validator are
+// validated.
+func TestBindValidate(t *testing.T) {
+	var validationError *ValidationError
+
+	if err := Bind(generateBindRequest("", `{"name":"Ada","email":"ada@example.com"}`), &validatedUser{}); nil != err {
+		t.Errorf("Expected valid user to bind, got %v.", err)
+	}
+
+	err := Bind(generateBindRequest("", `{"email":"ada"}`), &validatedUser{})
+
+	if !errors.As(err, &validationError) || 2 != len(validationError.Fields) || "name" != validationError.Fields[0].Field {
+		t.Fatalf("Expected per-field validation errors, got %v.", err)
+	}
+
+	res := httptest.NewRecorder()
+	WriteError(res, generateHttpRequest(GET, "/"), err)
+
+	expected := `{"errors":[{"field":"name","message":"is required"},{"field":"email","message":"must be an email address"}]}` + "\n"
+
+	if http.StatusUnprocessableEntity != res.Code || expected != res.Body.String() {
+		t.Errorf("Expected 422 %s, got %d %s.", expected, res.Code, res.Body.String())
+	}
+}
+
+// TestRegisterValidator ensures registered validators run and plain
+// errors are wrapped into ValidationErrors.
+func TestRegisterValidator(t *testing.T) {
+	type order struct {
+		Quantity int `json:"quantity"`
+	}
+
+	RegisterValidator(func(v any) error {
+		if o, ok := v.(*order); ok && 0 >= o.Quantity {
+			return errors.New("quantity must be positive")
+		}
+
+		return nil
+	})
+
+	var validationError *ValidationError
+
+	if err := Bind(generateBindRequest("", `{"quantity":0}`), &order{}); !errors.As(err, &validationError) || http.StatusUnprocessableEntity != validationError.StatusCode() {
+		t.Errorf("Expected registered validator to fail with a 422, got %v.", err)
+	}
+}
+
+// TestWriteError ensures errors are written with their status codes,
+// hiding the messages of server errors.
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err      error
+		status   int
+		expected string
+	}{
+		{&BindError{Status: http.StatusBadRequest, Field: "age", Err: errors.New("invalid integer")}, http.StatusBadRequest, `{"error":"age: invalid integer"}`},
+		{errors.New("connection refused"), http.StatusInternalServerError, `{"error":"Internal Server Error"}`},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		WriteError(res, generateHttpRequest(GET, "/"), test.err)
+
+		if test.status != res.Code || test.expected+"\n" != res.Body.String() {
+			t.Errorf("Expected %d %s, got %d %s.", test.status, test.expected, res.Code, res.Body.String())
+		}
+	}
+}
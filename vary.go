@@ -0,0 +1,85 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Request headers commonly named by the Vary response header, one for
+// each dimension a response may be negotiated on.
+const (
+	VaryAccept        = "Accept"
+	VaryEncoding      = "Accept-Encoding"
+	VaryLanguage      = "Accept-Language"
+	VaryAuthorization = "Authorization"
+	VaryCookie        = "Cookie"
+	VaryOrigin        = "Origin"
+)
+
+// AddVary declares that the response whose headers are `header` varies
+// on the request headers `names`, merging them into its Vary header
+// rather than replacing it, so the layers negotiating a response (i.e.
+// compression, localization and caching) do not clobber each other's
+// declarations. Names are canonicalized and recorded once, and a
+// response varying on `*` stays that way.
+func AddVary(header http.Header, names ...string) {
+	existing := VaryNames(header)
+
+	if 1 == len(existing) && "*" == existing[0] {
+		return
+	}
+
+	for _, name := range names {
+		if name = strings.TrimSpace(name); "*" == name {
+			existing = []string{"*"}
+			break
+		}
+
+		name = textproto.CanonicalMIMEHeaderKey(name)
+
+		if !containsFold(existing, name) {
+			existing = append(existing, name)
+		}
+	}
+
+	if 0 < len(existing) {
+		header.Set("Vary", strings.Join(existing, ", "))
+	}
+}
+
+// VaryNames returns the request headers named by the Vary header of
+// `header`, canonicalized, in order and without duplicates.
+func VaryNames(header http.Header) []string {
+	var names []string
+
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); "" == name {
+				continue
+			}
+
+			if "*" == name {
+				return []string{"*"}
+			}
+
+			if name = textproto.CanonicalMIMEHeaderKey(name); !containsFold(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// containsFold reports whether `values` contains `value`, ignoring
+// case.
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
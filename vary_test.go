@@ -0,0 +1,43 @@
+package dispatcher
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestAddVary ensures Vary declarations are merged rather than
+// replaced.
+func TestAddVary(t *testing.T) {
+	header := http.Header{"Vary": {"accept-encoding"}}
+
+	AddVary(header, VaryLanguage, "Accept-Encoding")
+	AddVary(header, "cookie", VaryLanguage)
+
+	if expected := "Accept-Encoding, Accept-Language, Cookie"; expected != header.Get("Vary") {
+		t.Errorf("Expected %q, got %q.", expected, header.Get("Vary"))
+	}
+
+	AddVary(header, "*")
+	AddVary(header, VaryOrigin)
+
+	if "*" != header.Get("Vary") {
+		t.Errorf("Expected the response to vary on everything, got %q.", header.Get("Vary"))
+	}
+
+	header = make(http.Header)
+	AddVary(header)
+
+	if _, ok := header["Vary"]; ok {
+		t.Error("Expected no Vary header when nothing is declared.")
+	}
+}
+
+// TestVaryNames ensures names are collected across header values.
+func TestVaryNames(t *testing.T) {
+	header := http.Header{"Vary": {"Accept-Encoding, accept", "Accept-Encoding,Origin"}}
+
+	if names := VaryNames(header); "Accept-Encoding Accept Origin" != strings.Join(names, " ") {
+		t.Errorf("Expected canonical names without duplicates, got %v.", names)
+	}
+}
@@ -0,0 +1,276 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader is the response header carrying the API version of
+// the Route serving a request.
+const APIVersionHeader = "API-Version"
+
+// Group registers Routes under the path prefix of an API version with
+// the Router it was created by. See Router.Version.
+type Group struct {
+	router  *Router
+	prefix  string
+	version string
+}
+
+// versionContextKey is the context key the API version of a matched
+// Route is stored under.
+type versionContextKey struct{}
+
+// Version registers the Routes of the API version with the path prefix
+// `prefix` through the Group passed to `fn`, i.e.
+//
+//	router.Version("/v2", func(v *dispatcher.Group) {
+//		v.Latest()
+//		v.Get("/users", ListUsersHandler).Name("users.list")
+//	})
+//
+// Requests served by the Routes carry the version, the prefix without
+// slashes, in their context and in the APIVersionHeader response
+// header.
+func (r *Router) Version(prefix string, fn func(v *Group)) *Router {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	r.Lock()
+
+	known := false
+
+	for _, version := range r.versions {
+		known = known || prefix == version
+	}
+
+	if !known {
+		r.versions = append(r.versions, prefix)
+		r.misses.reset()
+	}
+
+	r.Unlock()
+
+	fn(&Group{router: r, prefix: prefix, version: strings.Trim(prefix, "/")})
+	return r
+}
+
+// VersionFromContext returns the API version of the Route matching the
+// request whose context is `ctx`, and whether the Route was registered
+// for a version.
+func VersionFromContext(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(versionContextKey{}).(string)
+	return version, ok
+}
+
+// Latest makes the Group's version the one unversioned paths resolve
+// to: a request for `/users` matching no Route is served by the Route
+// matching `/v2/users` if `/v2` is the latest version.
+func (g *Group) Latest() *Group {
+	g.router.Lock()
+	defer g.router.Unlock()
+
+	g.router.latest = g.prefix
+	g.router.misses.reset()
+	return g
+}
+
+// Get registers a Route for HTTP GET requests to `path` within the
+// Group's version.
+func (g *Group) Get(path string, handler http.Handler) *Group {
+	return g.AddHandler(GET, path, handler)
+}
+
+// Put registers a Route for HTTP PUT requests to `path` within the
+// Group's version.
+func (g *Group) Put(path string, handler http.Handler) *Group {
+	return g.AddHandler(PUT, path, handler)
+}
+
+// Post registers a Route for HTTP POST requests to `path` within the
+// Group's version.
+func (g *Group) Post(path string, handler http.Handler) *Group {
+	return g.AddHandler(POST, path, handler)
+}
+
+// Delete registers a Route for HTTP DELETE requests to `path` within
+// the Group's version.
+func (g *Group) Delete(path string, handler http.Handler) *Group {
+	return g.AddHandler(DELETE, path, handler)
+}
+
+// Patch registers a Route for HTTP PATCH requests to `path` within the
+// Group's version.
+func (g *Group) Patch(path string, handler http.Handler) *Group {
+	return g.AddHandler(PATCH, path, handler)
+}
+
+// Match registers Routes for `path` within the Group's version for
+// all supported HTTP methods.
+func (g *Group) Match(path string, handler http.Handler) *Group {
+	g.router.Match(g.prefix+path, handler)
+	return g.tag()
+}
+
+// AddHandler registers a Route for `method` requests to `path` within
+// the Group's version. See Router.AddHandler.
+func (g *Group) AddHandler(method, path string, handler http.Handler) *Group {
+	g.router.AddHandler(method, g.prefix+path, handler)
+	return g.tag()
+}
+
+// Name names the Routes created by the most recent registration call.
+// See Router.Name.
+func (g *Group) Name(name string) *Group {
+	g.router.Name(name)
+	return g
+}
+
+// Doc describes the Routes created by the most recent registration
+// call. See Router.Doc.
+func (g *Group) Doc(doc string) *Group {
+	g.router.Doc(doc)
+	return g
+}
+
+// Strict makes the Routes created by the most recent registration call
+// strict. See Router.Strict.
+func (g *Group) Strict() *Group {
+	g.router.Strict()
+	return g
+}
+
+// Lenient makes the Routes created by the most recent registration
+// call lenient. See Router.Lenient.
+func (g *Group) Lenient() *Group {
+	g.router.Lenient()
+	return g
+}
+
+// Deprecated marks the Routes created by the most recent registration
+// call as deprecated. See Router.Deprecated.
+func (g *Group) Deprecated(sunset time.Time, link string) *Group {
+	g.router.Deprecated(sunset, link)
+	return g
+}
+
+// DeprecatedSince marks the Routes created by the most recent
+// registration call as deprecated since `since`. See
+// Router.DeprecatedSince.
+func (g *Group) DeprecatedSince(since, sunset time.Time, link string) *Group {
+	g.router.DeprecatedSince(since, sunset, link)
+	return g
+}
+
+// Meta tags the Routes created by the most recent registration call.
+// See Router.Meta.
+func (g *Group) Meta(key string, values ...string) *Group {
+	g.router.Meta(key, values...)
+	return g
+}
+
+// Headers sets headers on the responses of the Routes created by the
+// most recent registration call. See Router.Headers.
+func (g *Group) Headers(header http.Header) *Group {
+	g.router.Headers(header)
+	return g
+}
+
+// tag records the Group's version on the Routes created by the most
+// recent registration call.
+func (g *Group) tag() *Group {
+	g.router.Lock()
+	defer g.router.Unlock()
+
+	for _, route := range g.router.current {
+		route.version = g.version
+	}
+
+	return g
+}
+
+// FallbackTo makes requests for paths of the Group's version matching
+// no Route fall back to the Routes of the version with the path prefix
+// `prefix`, i.e.
+//
+//	router.Version("/v3", func(v *dispatcher.Group) {
+//		v.FallbackTo("/v2")
+//		v.Get("/users/:id", ShowUserHandler)
+//	})
+//
+// serves `/v3/orders` with the Route matching `/v2/orders`, so a new
+// version only needs to register the Routes that changed. Fallbacks
+// are followed in turn, i.e. from `/v3` to `/v2` to `/v1`.
+func (g *Group) FallbackTo(prefix string) *Group {
+	g.router.Lock()
+	defer g.router.Unlock()
+
+	if nil == g.router.fallbacks {
+		g.router.fallbacks = make(map[string]string)
+	}
+
+	g.router.fallbacks[g.prefix] = "/" + strings.Trim(prefix, "/")
+	g.router.misses.reset()
+	return g
+}
+
+// findVersionedRouteAndHandler resolves a path matching no Route
+// through the Router's API versions: an unversioned path to the latest
+// version, then a versioned path along its version's fallbacks. The
+// Route matching the resolved path, its handler and the resolved path
+// are returned. The Route and handler are nil if there is no match.
+func (r *Router) findVersionedRouteAndHandler(method, path string) (*Route, http.Handler, string) {
+	r.Lock()
+	defer r.Unlock()
+
+	prefix := r.versionPrefix(path)
+
+	if "" == prefix {
+		if "" == r.latest {
+			return nil, nil, path
+		}
+
+		prefix, path = r.latest, r.latest+path
+
+		if route, handler := r.matchPath(method, path); nil != route {
+			return route, handler, path
+		}
+	}
+
+	rest := strings.TrimPrefix(path, prefix)
+	visited := map[string]bool{prefix: true}
+
+	for {
+		fallback, ok := r.fallbacks[prefix]
+
+		if !ok || visited[fallback] {
+			return nil, nil, path
+		}
+
+		visited[fallback] = true
+		prefix, path = fallback, fallback+rest
+
+		if route, handler := r.matchPath(method, path); nil != route {
+			return route, handler, path
+		}
+	}
+}
+
+// versionPrefix returns the longest path prefix of the Router's API
+// versions `path` lies below, or an empty string. The Router must be
+// locked by the caller.
+func (r *Router) versionPrefix(path string) (prefix string) {
+	for _, version := range r.versions {
+		if hasPathPrefix(path, version) && len(prefix) < len(version) {
+			prefix = version
+		}
+	}
+
+	return
+}
+
+// hasPathPrefix reports whether `path` is `prefix` or lies below it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,110 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestVersion ensures versioned Routes are registered under their
+// prefix and report the version they were resolved to.
+func TestVersion(t *testing.T) {
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			version, _ := VersionFromContext(req.Context())
+			res.Write([]byte(body + " " + version + " " + ParamsFromContext(req.Context()).Get("id")))
+		})
+	}
+
+	router := NewRouter().
+		Version("/v1", func(v *Group) {
+			v.Get("/users/:id", handler("users"))
+		}).
+		Version("/v2/", func(v *Group) {
+			v.Latest()
+			v.Get("/users/:id", handler("accounts")).Name("users.show")
+		})
+
+	tests := []struct {
+		path, body, version string
+	}{
+		{"/v1/users/1", "users v1 1", "v1"},
+		{"/v2/users/2", "accounts v2 2", "v2"},
+		{"/users/3", "accounts v2 3", "v2"},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, test.path))
+
+		if test.body != res.Body.String() {
+			t.Errorf("Expected %q to be served with %q, got %q.", test.path, test.body, res.Body.String())
+		}
+
+		if test.version != res.Header().Get(APIVersionHeader) {
+			t.Errorf("Expected %q to report version %q, got %q.", test.path, test.version, res.Header().Get(APIVersionHeader))
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/v1/v2/users/4"))
+
+	if http.StatusNotFound != res.Code {
+		t.Errorf("Expected versioned paths not to resolve to the latest version, got %d.", res.Code)
+	}
+
+	if path, _ := router.URL("users.show", "5"); "/v2/users/5" != path {
+		t.Errorf("Expected versioned route names to build prefixed paths, got %q.", path)
+	}
+}
+
+// TestVersionFallback ensures requests for Routes missing from a
+// version are served by the versions it falls back to.
+func TestVersionFallback(t *testing.T) {
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(body + " " + ParamsFromContext(req.Context()).Get("id")))
+		})
+	}
+
+	router := NewRouter().
+		Version("/v1", func(v *Group) {
+			v.Get("/orders/:id", handler("orders v1"))
+			v.Get("/users/:id", handler("users v1"))
+		}).
+		Version("/v2", func(v *Group) {
+			v.FallbackTo("/v1").FallbackTo("/v1")
+			v.Get("/users/:id", handler("users v2"))
+		}).
+		Version("/v3", func(v *Group) {
+			v.Latest().FallbackTo("/v2")
+		}).
+		Version("/v1", func(v *Group) {
+			v.FallbackTo("/v3")
+		})
+
+	tests := []struct {
+		path, body, version string
+	}{
+		{"/v3/users/1", "users v2 1", "v2"},
+		{"/v3/orders/2", "orders v1 2", "v1"},
+		{"/orders/3", "orders v1 3", "v1"},
+		{"/v2/orders/4", "orders v1 4", "v1"},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, test.path))
+
+		if test.body != res.Body.String() || test.version != res.Header().Get(APIVersionHeader) {
+			t.Errorf("Expected %q to be served with %q by %q, got %q by %q.", test.path, test.body, test.version, res.Body.String(), res.Header().Get(APIVersionHeader))
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/v3/missing"))
+
+	if http.StatusNotFound != res.Code {
+		t.Errorf("Expected fallback cycles to end in not found, got %d.", res.Code)
+	}
+}
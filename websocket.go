@@ -0,0 +1,12 @@
+package dispatcher
+
+import (
+	"github.com/chuckpreslar/dispatcher/ws"
+)
+
+// WebSocket registers a route to match the given path argument for
+// HTTP GET requests which upgrades requests to WebSocket connections
+// with `upgrade` and serves them with `hub`.
+func (r *Router) WebSocket(path string, hub *ws.Hub, upgrade ws.UpgradeFunc) *Router {
+	return r.Get(path, hub.Handler(upgrade))
+}
@@ -0,0 +1,143 @@
+package dispatcher
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+)
+
+// WeightedHandler is one of several handlers serving a Route, receiving
+// a share of its traffic proportional to its Weight.
+type WeightedHandler struct {
+	// Name identifies the handler in sticky assignment cookies. If
+	// empty, the handler's index is used.
+	Name    string
+	Handler http.Handler
+	Weight  int
+}
+
+// WeightedOptions configures how requests are assigned to one of
+// several WeightedHandlers. By default each request is assigned
+// randomly.
+type WeightedOptions struct {
+	// Cookie, if set, is the name of a cookie recording the handler a
+	// client was assigned so it receives the same handler on later
+	// requests.
+	Cookie string
+	// Key, if set, returns a value identifying the client, i.e. a user
+	// ID or IP address, which is hashed to assign the client a handler
+	// deterministically.
+	Key func(req *http.Request) string
+}
+
+// weightedHandler serves requests with one of several handlers.
+type weightedHandler struct {
+	handlers []WeightedHandler
+	options  WeightedOptions
+	total    int
+}
+
+// NewWeightedHandler returns a handler distributing requests between
+// `handlers` proportionally to their weights, i.e. to send a
+// configurable percentage of traffic to a canary implementation.
+// Handlers with a weight less than 1 receive no traffic.
+func NewWeightedHandler(handlers []WeightedHandler, opts WeightedOptions) http.Handler {
+	weighted := &weightedHandler{options: opts}
+
+	for i, handler := range handlers {
+		if "" == handler.Name {
+			handler.Name = strconv.Itoa(i)
+		}
+
+		if 0 < handler.Weight {
+			weighted.handlers = append(weighted.handlers, handler)
+			weighted.total += handler.Weight
+		}
+	}
+
+	return weighted
+}
+
+// ServeHTTP serves the request with the handler it is assigned.
+func (w *weightedHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if 0 == w.total {
+		http.NotFound(res, req)
+		return
+	}
+
+	if "" != w.options.Cookie {
+		if cookie, err := req.Cookie(w.options.Cookie); nil == err {
+			for _, handler := range w.handlers {
+				if handler.Name == cookie.Value {
+					handler.Handler.ServeHTTP(res, req)
+					return
+				}
+			}
+		}
+	}
+
+	var bucket int
+
+	if nil != w.options.Key {
+		bucket = hashBucket(w.options.Key(req), w.total)
+	} else {
+		bucket = rand.IntN(w.total)
+	}
+
+	handler := pickWeighted(w.handlers, bucket)
+
+	if "" != w.options.Cookie {
+		http.SetCookie(res, &http.Cookie{Name: w.options.Cookie, Value: handler.Name, Path: "/", HttpOnly: true})
+	}
+
+	handler.Handler.ServeHTTP(res, req)
+}
+
+// pickWeighted returns the handler whose share of the range [0, total)
+// contains `bucket`, where total is the sum of the handlers' weights.
+func pickWeighted(handlers []WeightedHandler, bucket int) WeightedHandler {
+	for _, handler := range handlers {
+		if bucket < handler.Weight {
+			return handler
+		}
+
+		bucket -= handler.Weight
+	}
+
+	return handlers[len(handlers)-1]
+}
+
+// hashBucket deterministically maps `key` onto a bucket in the range
+// [0, n).
+func hashBucket(key string, n int) int {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	return int(hash.Sum64() % uint64(n))
+}
+
+// GetWeighted registers a route to match the given path argument for
+// HTTP GET requests, distributing them between `handlers`
+// proportionally to their weights, i.e.
+//
+//	router.GetWeighted("/search", []dispatcher.WeightedHandler{
+//		{Name: "stable", Handler: SearchHandler, Weight: 95},
+//		{Name: "canary", Handler: NewSearchHandler, Weight: 5},
+//	})
+//
+// An optional WeightedOptions argument configures sticky assignment.
+func (r *Router) GetWeighted(path string, handlers []WeightedHandler, opts ...WeightedOptions) *Router {
+	return r.AddWeighted(GET, path, handlers, opts...)
+}
+
+// AddWeighted behaves as GetWeighted for requests of the HTTP method
+// `method`.
+func (r *Router) AddWeighted(method, path string, handlers []WeightedHandler, opts ...WeightedOptions) *Router {
+	var options WeightedOptions
+
+	if 0 < len(opts) {
+		options = opts[0]
+	}
+
+	return r.AddHandler(method, path, NewWeightedHandler(handlers, options))
+}
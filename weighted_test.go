@@ -0,0 +1,54 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWeightedHashAssignment ensures requests sharing a key are
+// assigned the same handler.
+func TestWeightedHashAssignment(t *testing.T) {
+	stable, canary := 0, 0
+
+	router := NewRouter().GetWeighted("/search", []WeightedHandler{
+		{Handler: generateCountableHandler(&stable), Weight: 50},
+		{Handler: generateCountableHandler(&canary), Weight: 50},
+	}, WeightedOptions{Key: func(req *http.Request) string { return req.URL.Query().Get("user") }})
+
+	for i := 0; i < 10; i++ {
+		router.ServeHTTP(nil, generateHttpRequest(GET, "/search?user=42"))
+	}
+
+	if !(10 == stable && 0 == canary) && !(0 == stable && 10 == canary) {
+		t.Errorf("Expected all requests to be assigned one handler, got %d and %d.", stable, canary)
+	}
+}
+
+// TestWeightedCookieAssignment ensures the handler named by the
+// assignment cookie serves the request, and new clients are assigned
+// a cookie.
+func TestWeightedCookieAssignment(t *testing.T) {
+	stable, canary := 0, 0
+
+	router := NewRouter().GetWeighted("/search", []WeightedHandler{
+		{Name: "stable", Handler: generateCountableHandler(&stable), Weight: 100},
+		{Name: "canary", Handler: generateCountableHandler(&canary), Weight: 0},
+		{Name: "beta", Handler: generateCountableHandler(&canary), Weight: 0},
+	}, WeightedOptions{Cookie: "variant"})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/search"))
+
+	if cookie := res.Result().Cookies(); 1 != len(cookie) || "stable" != cookie[0].Value {
+		t.Errorf("Expected assignment cookie to be set, got %v.", cookie)
+	}
+
+	req := generateHttpRequest(GET, "/search")
+	req.AddCookie(&http.Cookie{Name: "variant", Value: "stable"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if 2 != stable || 0 != canary {
+		t.Errorf("Expected stable handler to serve both requests, got %d and %d.", stable, canary)
+	}
+}
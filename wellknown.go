@@ -0,0 +1,128 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WellKnownPrefix is the path prefix of well-known URIs, as defined by
+// RFC 8615.
+const WellKnownPrefix = "/.well-known"
+
+// WellKnown registers Routes for well-known URIs with the Router it was
+// created by. See Router.WellKnown.
+type WellKnown struct {
+	router *Router
+}
+
+// SecurityTxt is the content of a `security.txt` file, as defined by
+// RFC 9116, telling security researchers how to report
+// vulnerabilities.
+type SecurityTxt struct {
+	// Contact are the URIs vulnerabilities are reported to, i.e.
+	// `mailto:security@example.com`. At least one is required.
+	Contact []string
+	// Expires is the time after which the file is stale. It is
+	// required.
+	Expires time.Time
+	// Encryption are the URIs of keys to encrypt reports with.
+	Encryption []string
+	// Acknowledgments are the URIs of pages thanking researchers.
+	Acknowledgments []string
+	// PreferredLanguages are the language tags reports are preferred
+	// in, i.e. `en`.
+	PreferredLanguages []string
+	// Canonical are the URIs the file is served from.
+	Canonical []string
+	// Policy are the URIs of vulnerability disclosure policies.
+	Policy []string
+	// Hiring are the URIs of security related job openings.
+	Hiring []string
+}
+
+// WellKnown registers Routes for the well-known URIs beneath
+// WellKnownPrefix through the WellKnown passed to `fn`, i.e.
+//
+//	router.WellKnown(func(w *dispatcher.WellKnown) {
+//		w.SecurityTxt(dispatcher.SecurityTxt{Contact: []string{"mailto:security@example.com"}, Expires: expires})
+//		w.ChangePassword("/account/password")
+//		w.JSON("assetlinks.json", links)
+//		w.Handle("acme-challenge/*", manager.HTTPHandler(nil))
+//	})
+func (r *Router) WellKnown(fn func(w *WellKnown)) *Router {
+	fn(&WellKnown{router: r})
+	return r
+}
+
+// Handle registers Routes for HTTP GET and HEAD requests to the
+// well-known URI `name`, i.e. `acme-challenge/:token`, served by
+// `handler`, such as one answering the challenges of a certificate
+// provider.
+func (w *WellKnown) Handle(name string, handler http.Handler) *WellKnown {
+	w.router.getAndHead(WellKnownPrefix+"/"+strings.Trim(name, "/"), handler)
+	return w
+}
+
+// Content registers Routes serving `content` with `contentType` at the
+// well-known URI `name`. Responses carry an `ETag` and a
+// `Cache-Control` header, as those of Router.Robots do.
+func (w *WellKnown) Content(name, contentType string, content []byte) *WellKnown {
+	return w.Handle(name, newAsset(content, contentType))
+}
+
+// JSON registers Routes serving `v` encoded as JSON at the well-known
+// URI `name`, i.e. `assetlinks.json` or
+// `apple-app-site-association`. The value is encoded on the first
+// request for it, and the requests are served 404 if it can't be.
+func (w *WellKnown) JSON(name string, v any) *WellKnown {
+	return w.Handle(name, &assetHandler{load: func() ([]byte, string, error) {
+		data, err := json.Marshal(v)
+		return data, "application/json", err
+	}})
+}
+
+// SecurityTxt registers Routes serving `txt` at the well-known URI
+// `security.txt`.
+func (w *WellKnown) SecurityTxt(txt SecurityTxt) *WellKnown {
+	return w.Content("security.txt", "text/plain; charset=utf-8", []byte(txt.String()))
+}
+
+// ChangePassword registers Routes redirecting the well-known URI
+// `change-password` to `url`, the page users change their password on,
+// so password managers can take them there.
+func (w *WellKnown) ChangePassword(url string) *WellKnown {
+	return w.Handle("change-password", http.RedirectHandler(url, http.StatusFound))
+}
+
+// String formats the SecurityTxt as the content of a `security.txt`
+// file.
+func (txt SecurityTxt) String() string {
+	var builder strings.Builder
+
+	field := func(name string, values ...string) {
+		for _, value := range values {
+			builder.WriteString(name + ": " + value + "\n")
+		}
+	}
+
+	field("Contact", txt.Contact...)
+
+	if !txt.Expires.IsZero() {
+		field("Expires", txt.Expires.UTC().Format(time.RFC3339))
+	}
+
+	field("Encryption", txt.Encryption...)
+	field("Acknowledgments", txt.Acknowledgments...)
+
+	if 0 < len(txt.PreferredLanguages) {
+		field("Preferred-Languages", strings.Join(txt.PreferredLanguages, ", "))
+	}
+
+	field("Canonical", txt.Canonical...)
+	field("Policy", txt.Policy...)
+	field("Hiring", txt.Hiring...)
+
+	return builder.String()
+}
@@ -0,0 +1,45 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWellKnown ensures well-known URIs are served beneath
+// WellKnownPrefix with their content types.
+func TestWellKnown(t *testing.T) {
+	router := NewRouter().WellKnown(func(w *WellKnown) {
+		w.SecurityTxt(SecurityTxt{
+			Contact:            []string{"mailto:security@example.com"},
+			Expires:            time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			PreferredLanguages: []string{"en", "de"},
+		})
+		w.ChangePassword("/account/password")
+		w.JSON("assetlinks.json", []map[string]string{{"relation": "handle_all_urls"}})
+		w.Handle("acme-challenge/:token", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(ParamsFromContext(req.Context()).Get("token") + ".thumbprint"))
+		}))
+	})
+
+	for path, expected := range map[string][2]string{
+		"/.well-known/security.txt":         {"text/plain; charset=utf-8", "Contact: mailto:security@example.com\nExpires: 2030-01-01T00:00:00Z\nPreferred-Languages: en, de\n"},
+		"/.well-known/assetlinks.json":      {"application/json", `[{"relation":"handle_all_urls"}]`},
+		"/.well-known/acme-challenge/token": {"text/plain; charset=utf-8", "token.thumbprint"},
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, generateHttpRequest(GET, path))
+
+		if expected[0] != res.Header().Get("Content-Type") || expected[1] != res.Body.String() {
+			t.Errorf("Expected %s to serve %q as %q, got %q as %q.", path, expected[1], expected[0], res.Body.String(), res.Header().Get("Content-Type"))
+		}
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, generateHttpRequest(GET, "/.well-known/change-password"))
+
+	if http.StatusFound != res.Code || "/account/password" != res.Header().Get("Location") {
+		t.Errorf("Expected change-password to redirect, got %d to %q.", res.Code, res.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,53 @@
+package dispatcher
+
+import (
+	"net/http"
+	"sort"
+)
+
+// The HandlerWrapper type wraps the handler serving a request, allowing
+// code to run both before and after the Router's Middleware and Routes
+// serve it (i.e. to inspect or replace the response written). Unlike
+// Middleware, a HandlerWrapper decides whether and how to call `next`.
+type HandlerWrapper func(next http.Handler) http.Handler
+
+// prioritizedWrapper pairs a HandlerWrapper with the priority it was
+// registered with.
+type prioritizedWrapper struct {
+	wrapper  HandlerWrapper
+	priority int
+}
+
+// RegisterWrapper registers a HandlerWrapper around the Router's
+// Middleware and Routes. Wrappers run after Plugins, the first
+// registered being the outermost. The chain of wrappers is built as
+// they are registered rather than per request, so state a wrapper sets
+// up is shared by all requests. Wrappers registered with
+// RegisterWrapper have a priority of 0.
+func (r *Router) RegisterWrapper(wrapper HandlerWrapper) *Router {
+	return r.RegisterWrapperPriority(wrapper, 0)
+}
+
+// RegisterWrapperPriority registers a HandlerWrapper with an explicit
+// priority. Wrappers with lower priorities are outermost, so a
+// wrapper recovering from panics can be registered last with a low
+// priority and still wrap all others; wrappers sharing a priority run
+// in the order they were registered.
+func (r *Router) RegisterWrapperPriority(wrapper HandlerWrapper, priority int) *Router {
+	r.Lock()
+	defer r.Unlock()
+
+	r.wrappers = append(r.wrappers, prioritizedWrapper{wrapper, priority})
+
+	sort.SliceStable(r.wrappers, func(i, j int) bool {
+		return r.wrappers[i].priority < r.wrappers[j].priority
+	})
+
+	r.handler = http.HandlerFunc(r.dispatch)
+
+	for i := len(r.wrappers) - 1; 0 <= i; i-- {
+		r.handler = r.wrappers[i].wrapper(r.handler)
+	}
+
+	return r
+}
@@ -0,0 +1,78 @@
+package dispatcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegisterWrapper ensures wrappers run in registration order
+// around middleware and routes, and are built once.
+func TestRegisterWrapper(t *testing.T) {
+	var (
+		calls  []string
+		builds int
+	)
+
+	wrapper := func(name string) HandlerWrapper {
+		return func(next http.Handler) http.Handler {
+			builds++
+
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name+">")
+				next.ServeHTTP(res, req)
+				calls = append(calls, "<"+name)
+			})
+		}
+	}
+
+	router := NewRouter().
+		RegisterWrapper(wrapper("outer")).
+		RegisterWrapper(wrapper("inner")).
+		RegisterMiddleware(MiddlewareHandler(func(res http.ResponseWriter, req *http.Request) bool {
+			calls = append(calls, "middleware")
+			return false
+		})).
+		Get("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			calls = append(calls, "handler")
+		}))
+
+	builds = 0
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+
+	if expected := "outer> inner> middleware handler <inner <outer"; expected != strings.Join(calls[:6], " ") {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls[:6], " "))
+	}
+
+	if 0 != builds {
+		t.Errorf("Expected wrappers to be built once when registered, got %d builds while serving.", builds)
+	}
+}
+
+// TestRegisterWrapperPriority ensures wrappers with lower priorities
+// are outermost.
+func TestRegisterWrapperPriority(t *testing.T) {
+	var calls []string
+
+	wrapper := func(name string) HandlerWrapper {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(res, req)
+			})
+		}
+	}
+
+	router := NewRouter().
+		RegisterWrapper(wrapper("cache")).
+		RegisterWrapperPriority(wrapper("recover"), -100).
+		RegisterWrapperPriority(wrapper("etag"), 10)
+
+	router.ServeHTTP(httptest.NewRecorder(), generateHttpRequest(GET, "/"))
+
+	if expected := "recover cache etag"; expected != strings.Join(calls, " ") {
+		t.Errorf("Expected %q, got %q.", expected, strings.Join(calls, " "))
+	}
+}
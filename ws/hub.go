@@ -0,0 +1,390 @@
+// Package ws provides a hub managing WebSocket clients, rooms, and
+// message delivery, independent of the WebSocket implementation used.
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Message types, matching the WebSocket opcodes used by common
+// implementations such as github.com/gorilla/websocket.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+)
+
+// DefaultSendBuffer is the number of messages buffered per client when
+// HubOptions.SendBuffer is unset.
+const DefaultSendBuffer = 32
+
+// ErrDraining is returned when serving a connection while the Hub is
+// draining.
+var ErrDraining = errors.New("ws: hub is draining")
+
+// Conn is a WebSocket connection. It is satisfied by *websocket.Conn
+// from the github.com/gorilla/websocket package.
+type Conn interface {
+	ReadMessage() (messageType int, data []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// UpgradeFunc upgrades an HTTP request to a WebSocket connection,
+// writing an error response if it fails.
+type UpgradeFunc func(res http.ResponseWriter, req *http.Request) (Conn, error)
+
+// Message is a message sent or received over a WebSocket connection.
+type Message struct {
+	Type int
+	Data []byte
+}
+
+// HubOptions configures a Hub.
+type HubOptions struct {
+	// SendBuffer is the number of outgoing messages buffered per client.
+	// Clients falling further behind are disconnected.
+	SendBuffer int
+	// OnConnect, if set, is called when a client connects.
+	OnConnect func(client *Client)
+	// OnMessage, if set, is called with each message a client sends.
+	OnMessage func(client *Client, message Message)
+	// OnDisconnect, if set, is called when a client disconnects.
+	OnDisconnect func(client *Client)
+}
+
+// Hub manages connected clients and the rooms they have joined,
+// delivering broadcast and targeted messages. It is safe for concurrent
+// use.
+type Hub struct {
+	sync.Mutex
+	options HubOptions
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+	// sequence is the ID of the most recently connected client.
+	sequence atomic.Uint64
+	// pumps tracks the goroutines writing to clients.
+	pumps    sync.WaitGroup
+	draining bool
+}
+
+// Client is a connection managed by a Hub.
+type Client struct {
+	sync.Mutex
+	// ID uniquely identifies the client within its Hub.
+	ID uint64
+	// Request is the HTTP request the connection was upgraded from, if
+	// any.
+	Request *http.Request
+	hub     *Hub
+	conn    Conn
+	send    chan Message
+	rooms   map[string]struct{}
+	closed  bool
+	// disconnected is set once OnDisconnect was called for the client.
+	disconnected bool
+}
+
+// NewHub creates a Hub configured by `opts`.
+func NewHub(opts HubOptions) *Hub {
+	if 0 >= opts.SendBuffer {
+		opts.SendBuffer = DefaultSendBuffer
+	}
+
+	return &Hub{
+		options: opts,
+		clients: make(map[*Client]struct{}),
+		rooms:   make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Handler returns an http.Handler upgrading requests to WebSocket
+// connections with `upgrade` and serving them with the Hub.
+func (h *Hub) Handler(upgrade UpgradeFunc) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		conn, err := upgrade(res, req)
+
+		if nil != err {
+			return
+		}
+
+		h.Serve(conn, req)
+	})
+}
+
+// Serve registers `conn`, upgraded from the request `req`, with the Hub
+// and reads messages from it until it is closed. Serve blocks until the
+// client disconnects.
+func (h *Hub) Serve(conn Conn, req *http.Request) error {
+	client, err := h.register(conn, req)
+
+	if nil != err {
+		conn.Close()
+		return err
+	}
+
+	defer h.disconnect(client)
+
+	if nil != h.options.OnConnect {
+		h.options.OnConnect(client)
+	}
+
+	for {
+		typ, data, err := conn.ReadMessage()
+
+		if nil != err {
+			return nil
+		}
+
+		if nil != h.options.OnMessage {
+			h.options.OnMessage(client, Message{typ, data})
+		}
+	}
+}
+
+// register adds a client for `conn` to the Hub and starts delivering
+// messages to it.
+func (h *Hub) register(conn Conn, req *http.Request) (*Client, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.draining {
+		return nil, ErrDraining
+	}
+
+	client := &Client{
+		ID:      h.sequence.Add(1),
+		Request: req,
+		hub:     h,
+		conn:    conn,
+		send:    make(chan Message, h.options.SendBuffer),
+		rooms:   make(map[string]struct{}),
+	}
+
+	h.clients[client] = struct{}{}
+	h.pumps.Add(1)
+
+	go client.writePump()
+
+	return client, nil
+}
+
+// disconnect removes `client` from the Hub, closing its connection
+// once queued messages are written. OnDisconnect is called once, even
+// if the client was already removed, i.e. for falling behind or by
+// Drain.
+func (h *Hub) disconnect(client *Client) {
+	h.Lock()
+	h.remove(client)
+	h.Unlock()
+
+	client.Lock()
+	notify := !client.disconnected
+	client.disconnected = true
+	client.Unlock()
+
+	if notify && nil != h.options.OnDisconnect {
+		h.options.OnDisconnect(client)
+	}
+}
+
+// remove removes `client` from the Hub and its rooms, ending delivery
+// of messages to it. The hub must be locked by the caller.
+func (h *Hub) remove(client *Client) {
+	delete(h.clients, client)
+
+	for room := range client.rooms {
+		delete(h.rooms[room], client)
+
+		if 0 == len(h.rooms[room]) {
+			delete(h.rooms, room)
+		}
+	}
+
+	client.stop()
+}
+
+// Broadcast sends `message` to every connected client.
+func (h *Hub) Broadcast(message Message) {
+	h.Lock()
+	defer h.Unlock()
+
+	for client := range h.clients {
+		h.deliver(client, message)
+	}
+}
+
+// BroadcastRoom sends `message` to every client in `room`.
+func (h *Hub) BroadcastRoom(room string, message Message) {
+	h.Lock()
+	defer h.Unlock()
+
+	for client := range h.rooms[room] {
+		h.deliver(client, message)
+	}
+}
+
+// Send sends `message` to the client whose ID is `id`, returning false
+// if no such client is connected.
+func (h *Hub) Send(id uint64, message Message) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	for client := range h.clients {
+		if id == client.ID {
+			return h.deliver(client, message)
+		}
+	}
+
+	return false
+}
+
+// deliver queues `message` for `client`, disconnecting the client if
+// its queue is full. The hub must be locked by the caller.
+func (h *Hub) deliver(client *Client, message Message) bool {
+	if client.enqueue(message) {
+		return true
+	}
+
+	h.remove(client)
+	return false
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	h.Lock()
+	defer h.Unlock()
+
+	return len(h.clients)
+}
+
+// Members returns the number of clients in `room`.
+func (h *Hub) Members(room string) int {
+	h.Lock()
+	defer h.Unlock()
+
+	return len(h.rooms[room])
+}
+
+// Drain gracefully shuts down the Hub: new connections are refused and
+// connected clients are sent a close message, once their queued
+// messages are written, and disconnected. Drain blocks until all
+// clients are disconnected or `ctx` expires.
+func (h *Hub) Drain(ctx context.Context) error {
+	h.Lock()
+	h.draining = true
+
+	for client := range h.clients {
+		client.enqueue(Message{Type: CloseMessage})
+		h.remove(client)
+	}
+
+	h.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		h.pumps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Join adds the client to `room`.
+func (c *Client) Join(room string) {
+	c.hub.Lock()
+	defer c.hub.Unlock()
+
+	if _, ok := c.hub.clients[c]; !ok {
+		return
+	}
+
+	if _, ok := c.hub.rooms[room]; !ok {
+		c.hub.rooms[room] = make(map[*Client]struct{})
+	}
+
+	c.hub.rooms[room][c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+// Leave removes the client from `room`.
+func (c *Client) Leave(room string) {
+	c.hub.Lock()
+	defer c.hub.Unlock()
+
+	delete(c.hub.rooms[room], c)
+	delete(c.rooms, room)
+
+	if 0 == len(c.hub.rooms[room]) {
+		delete(c.hub.rooms, room)
+	}
+}
+
+// Send sends `message` to the client, returning false if it is no
+// longer connected.
+func (c *Client) Send(message Message) bool {
+	c.hub.Lock()
+	defer c.hub.Unlock()
+
+	if _, ok := c.hub.clients[c]; !ok {
+		return false
+	}
+
+	return c.hub.deliver(c, message)
+}
+
+// enqueue queues `message` for delivery, returning false if the
+// client's queue is full or it has been stopped.
+func (c *Client) enqueue(message Message) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop ends delivery of messages to the client. Messages already
+// queued are still written before the connection is closed.
+func (c *Client) stop() {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// writePump writes queued messages to the client's connection, closing
+// it once the client is stopped or a write fails.
+func (c *Client) writePump() {
+	defer c.hub.pumps.Done()
+	defer c.conn.Close()
+
+	for message := range c.send {
+		if err := c.conn.WriteMessage(message.Type, message.Data); nil != err {
+			c.hub.Lock()
+			c.hub.remove(c)
+			c.hub.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConn is a Conn reading from and recording writes to channels.
+type fakeConn struct {
+	sync.Mutex
+	incoming chan Message
+	written  []Message
+	closed   bool
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{incoming: make(chan Message)}
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	message, ok := <-c.incoming
+
+	if !ok {
+		return 0, nil, errors.New("closed")
+	}
+
+	return message.Type, message.Data, nil
+}
+
+func (c *fakeConn) WriteMessage(typ int, data []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.written = append(c.written, Message{typ, data})
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) messages() []Message {
+	c.Lock()
+	defer c.Unlock()
+
+	return append([]Message(nil), c.written...)
+}
+
+// TestHubRooms ensures room broadcasts only reach room members and
+// Drain closes connected clients after flushing their messages.
+func TestHubRooms(t *testing.T) {
+	connected := make(chan *Client, 2)
+	hub := NewHub(HubOptions{OnConnect: func(client *Client) { connected <- client }})
+	member, outsider := newFakeConn(), newFakeConn()
+
+	go hub.Serve(member, nil)
+	go hub.Serve(outsider, nil)
+
+	first, second := <-connected, <-connected
+
+	if first.conn == member {
+		first.Join("lobby")
+	} else {
+		second.Join("lobby")
+	}
+
+	hub.BroadcastRoom("lobby", Message{TextMessage, []byte("hello lobby")})
+	hub.Broadcast(Message{TextMessage, []byte("hello all")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := hub.Drain(ctx); nil != err {
+		t.Fatal(err)
+	}
+
+	if messages := member.messages(); 3 != len(messages) || "hello lobby" != string(messages[0].Data) || CloseMessage != messages[2].Type {
+		t.Errorf("Expected member to receive room, broadcast, and close messages, got %v.", messages)
+	}
+
+	if messages := outsider.messages(); 2 != len(messages) || "hello all" != string(messages[0].Data) {
+		t.Errorf("Expected outsider to receive broadcast and close messages, got %v.", messages)
+	}
+
+	if !member.closed || !outsider.closed {
+		t.Error("Expected connections to be closed after draining.")
+	}
+
+	if err := hub.Serve(newFakeConn(), nil); ErrDraining != err {
+		t.Errorf("Expected draining hub to refuse connections, got %v.", err)
+	}
+}
+
+// TestHubDisconnectAfterRemoval ensures OnDisconnect is called once for
+// clients removed from the Hub before their connection closed.
+func TestHubDisconnectAfterRemoval(t *testing.T) {
+	connected, disconnected := make(chan *Client, 1), make(chan *Client, 2)
+
+	hub := NewHub(HubOptions{
+		OnConnect:    func(client *Client) { connected <- client },
+		OnDisconnect: func(client *Client) { disconnected <- client },
+	})
+
+	conn := newFakeConn()
+	served := make(chan error)
+
+	go func() { served <- hub.Serve(conn, nil) }()
+
+	client := <-connected
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := hub.Drain(ctx); nil != err {
+		t.Fatal(err)
+	}
+
+	if client.Send(Message{TextMessage, []byte("late")}) {
+		t.Error("Expected sending to a removed client to fail.")
+	}
+
+	close(conn.incoming)
+	<-served
+	hub.disconnect(client)
+
+	if client != <-disconnected {
+		t.Fatal("Expected OnDisconnect to be called with the client.")
+	}
+
+	select {
+	case <-disconnected:
+		t.Error("Expected OnDisconnect to be called once.")
+	default:
+	}
+}